@@ -0,0 +1,153 @@
+package audit
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/IntouchOpec/user_management/models"
+)
+
+// Defaults applied unless overridden with options
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+)
+
+// Store persists audit entries
+type Store interface {
+	CreateAuditEntries(entries []models.AuditEntry) error
+}
+
+// Writer records audit entries off the request path: entries are queued
+// on a buffered channel and inserted in batches by a background goroutine.
+// When the buffer is full, or after Close, entries are written
+// synchronously instead of being dropped.
+type Writer struct {
+	store         Store
+	entries       chan models.AuditEntry
+	batchSize     int
+	flushInterval time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// Option configures optional Writer behavior
+type Option func(*Writer)
+
+// WithBatchSize sets the most entries inserted per statement
+func WithBatchSize(n int) Option {
+	return func(w *Writer) {
+		if n > 0 {
+			w.batchSize = n
+		}
+	}
+}
+
+// WithFlushInterval sets how long queued entries may wait before a
+// partial batch is written
+func WithFlushInterval(d time.Duration) Option {
+	return func(w *Writer) {
+		if d > 0 {
+			w.flushInterval = d
+		}
+	}
+}
+
+// NewWriter starts a writer that buffers up to bufferSize entries. Call
+// Close on shutdown to flush what is still queued.
+func NewWriter(store Store, bufferSize int, opts ...Option) *Writer {
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+	w := &Writer{
+		store:         store,
+		entries:       make(chan models.AuditEntry, bufferSize),
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+		done:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	go w.run()
+	return w
+}
+
+// Record queues an entry, writing it synchronously when the buffer is
+// full or the writer has been closed
+func (w *Writer) Record(entry models.AuditEntry) {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	w.mu.RLock()
+	if !w.closed {
+		select {
+		case w.entries <- entry:
+			w.mu.RUnlock()
+			return
+		default:
+		}
+	}
+	w.mu.RUnlock()
+
+	w.write([]models.AuditEntry{entry})
+}
+
+// Close stops accepting queued entries and waits until every queued
+// entry has been written
+func (w *Writer) Close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		<-w.done
+		return
+	}
+	w.closed = true
+	close(w.entries)
+	w.mu.Unlock()
+
+	<-w.done
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.AuditEntry, 0, w.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		w.write(batch)
+		batch = make([]models.AuditEntry, 0, w.batchSize)
+	}
+
+	for {
+		select {
+		case entry, ok := <-w.entries:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= w.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (w *Writer) write(entries []models.AuditEntry) {
+	if err := w.store.CreateAuditEntries(entries); err != nil {
+		log.Printf("Error writing %d audit entries: %v", len(entries), err)
+	}
+}
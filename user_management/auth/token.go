@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, expired or not
+// signed with the configured secret
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// RoleAdmin is the role granting administrative access
+const RoleAdmin = "admin"
+
+// Claims are the JWT claims issued to API callers. The subject is the
+// user ID; TenantID, when set, is the only tenant the token may act in.
+type Claims struct {
+	Email    string `json:"email,omitempty"`
+	Role     string `json:"role,omitempty"`
+	TenantID string `json:"tenant_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Sign issues an HS256 token carrying the claims
+func Sign(secret string, claims Claims) (string, error) {
+	if secret == "" {
+		return "", errors.New("JWT secret is not configured")
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// Parse verifies an HS256 token against the secret and returns its claims.
+// Tokens without an expiry are rejected, as is everything when no secret
+// is configured.
+func Parse(secret, token string) (*Claims, error) {
+	if secret == "" {
+		return nil, ErrInvalidToken
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// ExpiresIn returns how long the token remains valid, relative to now
+func (c *Claims) ExpiresIn(now time.Time) time.Duration {
+	if c.ExpiresAt == nil {
+		return 0
+	}
+	return c.ExpiresAt.Sub(now)
+}
+
+// UserID returns the user ID carried in the subject, or false when the
+// subject is not a user ID
+func (c *Claims) UserID() (uint, bool) {
+	id, err := strconv.ParseUint(c.Subject, 10, 32)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// IsAdmin reports whether the claims carry the admin role
+func (c *Claims) IsAdmin() bool {
+	return c.Role == RoleAdmin
+}
+
+// BearerToken extracts the token from an Authorization header of the form
+// "Bearer <token>", returning "" for any other scheme
+func BearerToken(header string) string {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
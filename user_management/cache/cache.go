@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMiss is returned by Get for keys that are not cached
+var ErrMiss = errors.New("cache miss")
+
+// Cache stores byte values under string keys, each with its own TTL. A
+// failing cache must never fail the operation using it, so callers treat
+// every error as a miss.
+type Cache interface {
+	// Get returns the value cached under key, or ErrMiss
+	Get(ctx context.Context, key string) ([]byte, error)
+	// GetMany returns the values cached under keys in order, with nil
+	// for keys that are not cached
+	GetMany(ctx context.Context, keys ...string) ([][]byte, error)
+	// Set caches value under key for ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetMany caches every value under its key for ttl
+	SetMany(ctx context.Context, values map[string][]byte, ttl time.Duration) error
+	// Del removes keys; missing keys are ignored
+	Del(ctx context.Context, keys ...string) error
+	// Incr adds one to the integer cached under key, starting from 0 when
+	// it is missing, and returns the new value. Any TTL is kept.
+	Incr(ctx context.Context, key string) (int64, error)
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Memory is a Cache held in process memory. Expired entries are dropped
+// when next read. It suits tests and single-instance deployments.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemory creates an empty in-memory cache
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]memoryEntry), now: time.Now}
+}
+
+// SetClock replaces the clock used for expiry, letting tests move time
+// forward
+func (m *Memory) SetClock(now func() time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+}
+
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.lookup(key)
+	if !ok {
+		return nil, ErrMiss
+	}
+	return value, nil
+}
+
+func (m *Memory) GetMany(ctx context.Context, keys ...string) ([][]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i], _ = m.lookup(key)
+	}
+	return values, nil
+}
+
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.store(key, value, ttl)
+	return nil
+}
+
+func (m *Memory) SetMany(ctx context.Context, values map[string][]byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, value := range values {
+		m.store(key, value, ttl)
+	}
+	return nil
+}
+
+func (m *Memory) Del(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+func (m *Memory) Incr(ctx context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var count int64
+	if value, ok := m.lookup(key); ok {
+		parsed, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("value of %s is not an integer", key)
+		}
+		count = parsed
+	}
+	count++
+
+	entry := m.entries[key]
+	entry.value = []byte(strconv.FormatInt(count, 10))
+	m.entries[key] = entry
+	return count, nil
+}
+
+// lookup returns the live value under key, dropping it if it has expired.
+// The caller holds mu.
+func (m *Memory) lookup(key string) ([]byte, bool) {
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !entry.expiresAt.IsZero() && !m.now().Before(entry.expiresAt) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// store caches a copy of value, without expiry when ttl is not positive.
+// The caller holds mu.
+func (m *Memory) store(key string, value []byte, ttl time.Duration) {
+	entry := memoryEntry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		entry.expiresAt = m.now().Add(ttl)
+	}
+	m.entries[key] = entry
+}
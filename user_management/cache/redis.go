@@ -0,0 +1,50 @@
+// Package cache provides the caches used by the service layer and builds
+// the Redis clients behind them
+package cache
+
+import (
+	"github.com/IntouchOpec/user_management/config"
+	"github.com/go-redis/redis/v8"
+)
+
+// NewRedisClient builds a client for the configured Redis deployment: a
+// plain client in single mode, a sentinel-backed failover client in
+// sentinel mode and a cluster client in cluster mode. Sentinel and cluster
+// node addresses come from Addrs, falling back to Host:Port.
+func NewRedisClient(cfg config.RedisConfig) redis.UniversalClient {
+	addrs := cfg.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{cfg.Host + ":" + cfg.Port}
+	}
+
+	switch cfg.Mode {
+	case config.RedisModeSentinel:
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    cfg.MasterName,
+			SentinelAddrs: addrs,
+			Password:      cfg.Password,
+			DB:            cfg.DB,
+			PoolSize:      cfg.PoolSize,
+			MinIdleConns:  cfg.MinIdleConns,
+			DialTimeout:   cfg.DialTimeout,
+		})
+	case config.RedisModeCluster:
+		// Cluster mode has no numbered databases, so DB does not apply
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        addrs,
+			Password:     cfg.Password,
+			PoolSize:     cfg.PoolSize,
+			MinIdleConns: cfg.MinIdleConns,
+			DialTimeout:  cfg.DialTimeout,
+		})
+	default:
+		return redis.NewClient(&redis.Options{
+			Addr:         cfg.Host + ":" + cfg.Port,
+			Password:     cfg.Password,
+			DB:           cfg.DB,
+			PoolSize:     cfg.PoolSize,
+			MinIdleConns: cfg.MinIdleConns,
+			DialTimeout:  cfg.DialTimeout,
+		})
+	}
+}
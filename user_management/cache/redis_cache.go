@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis is a Cache backed by a Redis client
+type Redis struct {
+	client redis.Cmdable
+
+	// cluster is set for Redis Cluster, which rejects multi-key commands
+	// whose keys hash to different slots with CROSSSLOT
+	cluster bool
+}
+
+// NewRedis creates a cache storing values in Redis. With a
+// *redis.ClusterClient, GetMany and Del send one command per key in a
+// pipeline instead of a single MGET or DEL.
+func NewRedis(client redis.Cmdable) *Redis {
+	_, cluster := client.(*redis.ClusterClient)
+	return &Redis{client: client, cluster: cluster}
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+func (r *Redis) GetMany(ctx context.Context, keys ...string) ([][]byte, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	if r.cluster {
+		return r.getEach(ctx, keys)
+	}
+
+	results, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([][]byte, len(keys))
+	for i, result := range results {
+		if s, ok := result.(string); ok {
+			values[i] = []byte(s)
+		}
+	}
+	return values, nil
+}
+
+// getEach reads keys with one GET per key in a pipeline, which the cluster
+// client splits by slot
+func (r *Redis) getEach(ctx context.Context, keys []string) ([][]byte, error) {
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Get(ctx, key)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	values := make([][]byte, len(keys))
+	for i, cmd := range cmds {
+		if value, err := cmd.Bytes(); err == nil {
+			values[i] = value
+		}
+	}
+	return values, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, key, value, ttl).Err()
+}
+
+// SetMany writes every value in one pipeline
+func (r *Redis) SetMany(ctx context.Context, values map[string][]byte, ttl time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, value := range values {
+			pipe.Set(ctx, key, value, ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+func (r *Redis) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if r.cluster {
+		_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				pipe.Del(ctx, key)
+			}
+			return nil
+		})
+		return err
+	}
+	return r.client.Del(ctx, keys...).Err()
+}
+
+func (r *Redis) Incr(ctx context.Context, key string) (int64, error) {
+	return r.client.Incr(ctx, key).Result()
+}
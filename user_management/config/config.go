@@ -2,14 +2,24 @@ package config
 
 import (
 	"fmt"
+	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	Redis    RedisConfig
+	Database  DatabaseConfig
+	Server    ServerConfig
+	Redis     RedisConfig
+	API       APIConfig
+	Auth      AuthConfig
+	RateLimit RateLimitConfig
+	Purge     PurgeConfig
+	Audit     AuditConfig
+	Features  Features
 }
 
 // DatabaseConfig holds database configuration
@@ -20,19 +30,181 @@ type DatabaseConfig struct {
 	Name     string
 	Port     string
 	SSLMode  string
+	// TenantScopedEmails makes emails unique per tenant instead of globally
+	TenantScopedEmails bool
+	// LogLevel is the GORM log level: silent, error, warn or info
+	LogLevel string
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Port string
+	Port        string
+	Mode        string
+	ServiceName string
+	Version     string
+	// PanicLogFile receives stack traces of recovered panics; empty disables it
+	PanicLogFile string
+	// ForceHTTPS redirects requests a proxy forwards as http to https
+	ForceHTTPS bool
+	// ChaosLatency delays every request for chaos testing; zero disables it
+	ChaosLatency time.Duration
 }
 
 // RedisConfig holds Redis configuration
 type RedisConfig struct {
-	Host     string
-	Port     string
-	Password string
-	DB       int
+	// Mode is single, sentinel or cluster
+	Mode       string
+	Host       string
+	Port       string
+	Password   string
+	DB         int
+	ServeStale bool
+	// Addrs lists the sentinel or cluster node addresses; empty uses
+	// Host:Port
+	Addrs []string
+	// MasterName is the master set monitored by the sentinels
+	MasterName string
+	// PoolSize caps open connections; 0 keeps the client default of 10
+	// per CPU
+	PoolSize     int
+	MinIdleConns int
+	DialTimeout  time.Duration
+
+	// CacheTTL is how long cached users live
+	CacheTTL time.Duration
+
+	// WarmupUsers is the number of recent users cached at startup before
+	// /health/ready reports ready; 0 skips warmup
+	WarmupUsers int
+}
+
+// APIConfig holds HTTP API behavior configuration
+type APIConfig struct {
+	MaxBulkSize          int
+	MaxSearchOffset      int
+	CacheTTLTrustedCIDRs []string
+	StrictJSON           bool
+	MethodOverride       bool
+	DefaultSortOrder     string
+	SortNulls            string
+	MinRegistrationAge   int
+	DefaultPhoneRegion   string
+	ETagStyle            string
+
+	AllowTimestampOverride bool
+
+	// AdminOnlyFields lists fields only admin callers may change
+	AdminOnlyFields []string
+	// ImmutableFields lists fields no update may change, even an admin's
+	ImmutableFields []string
+
+	// RoleConfirmThreshold is how many users a bulk role assignment may
+	// match before it must be confirmed
+	RoleConfirmThreshold int
+
+	// CacheControlMaxAge is how long clients may reuse successful GET
+	// responses; 0 makes them revalidate every time
+	CacheControlMaxAge time.Duration
+
+	// AgeRequired rejects user bodies that leave out age; an explicit 0 is
+	// always accepted
+	AgeRequired bool
+
+	// IDAsString renders user IDs as JSON strings instead of numbers
+	IDAsString bool
+}
+
+// AuthConfig holds token verification settings. An empty JWTSecret
+// rejects every token.
+type AuthConfig struct {
+	JWTSecret string
+
+	// RequireAuthForReads makes GET user routes require a token too;
+	// writes always do
+	RequireAuthForReads bool
+
+	// RequireTenant rejects user route requests that resolve to no tenant,
+	// unless the caller is an admin, so a multi-tenant deployment never
+	// runs them unscoped across tenants
+	RequireTenant bool
+}
+
+// Feature names for optional endpoints that deployments can switch off
+const (
+	FeatureSimilar = "similar"
+	FeatureImport  = "import"
+	FeatureSwagger = "swagger"
+	FeatureDebug   = "debug"
+	FeatureExport  = "export"
+)
+
+// featureNames lists every known feature, in the order they are reported
+var featureNames = []string{FeatureSimilar, FeatureImport, FeatureSwagger, FeatureDebug, FeatureExport}
+
+// defaultFeatures lists the state of each feature when no FEATURE_ variable
+// overrides it. Features not listed here are disabled by default.
+var defaultFeatures = Features{
+	FeatureSimilar: true,
+	FeatureImport:  true,
+	FeatureSwagger: true,
+	FeatureExport:  true,
+}
+
+// Features maps lowercase feature names to whether they are enabled
+type Features map[string]bool
+
+// IsEnabled reports whether the named feature is switched on, falling back
+// to its default when not configured
+func (f Features) IsEnabled(name string) bool {
+	name = strings.ToLower(name)
+	if enabled, ok := f[name]; ok {
+		return enabled
+	}
+	return defaultFeatures[name]
+}
+
+// States reports whether each known feature is switched on
+func (f Features) States() map[string]bool {
+	states := make(map[string]bool, len(featureNames))
+	for _, name := range featureNames {
+		states[name] = f.IsEnabled(name)
+	}
+	return states
+}
+
+// IsEnabled reports whether the named feature is switched on
+func (c *Config) IsEnabled(name string) bool {
+	return c.Features.IsEnabled(name)
+}
+
+// RateLimitConfig holds per-client request limits, counted per window
+type RateLimitConfig struct {
+	PerIP   int
+	PerUser int
+	Window  time.Duration
+}
+
+// PurgeConfig holds settings for permanently deleting soft-deleted users.
+// A zero Retention disables purging.
+type PurgeConfig struct {
+	Retention time.Duration
+	Interval  time.Duration
+
+	// DeletionGrace is how long requested account deletions wait before
+	// the purge job deletes the account
+	DeletionGrace time.Duration
+
+	// OnUserDelete is cascade or retain: whether deleting a user soft
+	// deletes its email history too
+	OnUserDelete string
+}
+
+// AuditConfig holds settings for the asynchronous audit writer
+type AuditConfig struct {
+	// BufferSize is how many entries may wait to be written before
+	// callers write synchronously
+	BufferSize    int
+	FlushInterval time.Duration
 }
 
 // LoadConfig loads configuration from environment variables
@@ -45,16 +217,79 @@ func LoadConfig() *Config {
 			Name:     getEnv("DB_NAME", "users_db"),
 			Port:     getEnv("DB_PORT", "5432"),
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+
+			TenantScopedEmails: getEnvBool("TENANT_SCOPED_EMAILS", false),
+			LogLevel:           getEnvLogLevel("DB_LOG_LEVEL", "info"),
 		},
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
+			Port:         getEnv("SERVER_PORT", "8080"),
+			Mode:         getEnvMode("GIN_MODE", "debug"),
+			ServiceName:  getEnv("SERVICE_NAME", "user-management"),
+			Version:      getEnv("SERVICE_VERSION", "1.0"),
+			PanicLogFile: getEnv("PANIC_LOG_FILE", ""),
+			ForceHTTPS:   getEnvBool("FORCE_HTTPS", false),
+			ChaosLatency: getEnvDuration("CHAOS_LATENCY", 0),
 		},
 		Redis: RedisConfig{
-			Host:     getEnv("REDIS_HOST", "redis"),
-			Port:     getEnv("REDIS_PORT", "6379"),
-			Password: getEnv("REDIS_PASSWORD", ""),
-			DB:       0,
+			Mode:         getEnvRedisMode("REDIS_MODE", RedisModeSingle),
+			Host:         getEnv("REDIS_HOST", "redis"),
+			Port:         getEnv("REDIS_PORT", "6379"),
+			Password:     getEnv("REDIS_PASSWORD", ""),
+			DB:           0,
+			ServeStale:   getEnvBool("SERVE_STALE_ON_DB_ERROR", false),
+			Addrs:        getEnvList("REDIS_ADDRS"),
+			MasterName:   getEnv("REDIS_MASTER_NAME", "mymaster"),
+			PoolSize:     getEnvInt("REDIS_POOL_SIZE", 0),
+			MinIdleConns: getEnvInt("REDIS_MIN_IDLE_CONNS", 0),
+			DialTimeout:  getEnvDuration("REDIS_DIAL_TIMEOUT", 5*time.Second),
+
+			CacheTTL:    getEnvDuration("CACHE_TTL", 15*time.Minute),
+			WarmupUsers: getEnvInt("CACHE_WARMUP_USERS", 0),
+		},
+		API: APIConfig{
+			MaxBulkSize:          getEnvInt("MAX_BULK_SIZE", 1000),
+			MaxSearchOffset:      getEnvInt("SEARCH_MAX_OFFSET", 10000),
+			RoleConfirmThreshold: getEnvInt("BULK_ROLE_CONFIRM_THRESHOLD", 100),
+			CacheTTLTrustedCIDRs: getEnvList("CACHE_TTL_TRUSTED_CIDRS"),
+			StrictJSON:           getEnvBool("STRICT_JSON", false),
+			MethodOverride:       getEnvBool("METHOD_OVERRIDE", false),
+			DefaultSortOrder:     getEnv("SORT_DEFAULT_ORDER", "asc"),
+			SortNulls:            getEnv("SORT_NULLS", "last"),
+			MinRegistrationAge:   getEnvInt("MIN_REGISTRATION_AGE", 0),
+			DefaultPhoneRegion:   strings.ToUpper(getEnv("DEFAULT_PHONE_REGION", "")),
+			ETagStyle:            strings.ToLower(getEnv("ETAG_STYLE", "weak")),
+
+			AllowTimestampOverride: getEnvBool("ALLOW_TIMESTAMP_OVERRIDE", false),
+			AdminOnlyFields:        getEnvList("ADMIN_ONLY_FIELDS"),
+			ImmutableFields:        getEnvListOr("IMMUTABLE_FIELDS", []string{"id", "created_at"}),
+			CacheControlMaxAge:     getEnvDuration("CACHE_CONTROL_MAX_AGE", 0),
+
+			AgeRequired: getEnvBool("AGE_REQUIRED", true),
+			IDAsString:  getEnvBool("ID_AS_STRING", false),
+		},
+		Auth: AuthConfig{
+			JWTSecret: getEnv("JWT_SECRET", ""),
+
+			RequireAuthForReads: getEnvBool("AUTH_REQUIRE_READS", false),
+			RequireTenant:       getEnvBool("AUTH_REQUIRE_TENANT", false),
 		},
+		RateLimit: RateLimitConfig{
+			PerIP:   getEnvInt("RATE_LIMIT_IP", 300),
+			PerUser: getEnvInt("RATE_LIMIT_USER", 120),
+			Window:  getEnvDuration("RATE_LIMIT_WINDOW", time.Minute),
+		},
+		Purge: PurgeConfig{
+			Retention: getEnvDuration("SOFT_DELETE_RETENTION", 0),
+			Interval:  getEnvDuration("PURGE_INTERVAL", time.Hour),
+
+			DeletionGrace: getEnvDuration("ACCOUNT_DELETION_GRACE", 30*24*time.Hour),
+			OnUserDelete:  getEnvDeletePolicy("ON_USER_DELETE", OnDeleteRetain),
+		},
+		Audit: AuditConfig{
+			BufferSize:    getEnvInt("AUDIT_BUFFER_SIZE", 1000),
+			FlushInterval: getEnvDuration("AUDIT_FLUSH_INTERVAL", time.Second),
+		},
+		Features: getEnvFeatures("FEATURE_"),
 	}
 }
 
@@ -71,3 +306,144 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvInt gets a positive integer environment variable with fallback
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// getEnvBool gets a boolean environment variable with fallback
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvDuration gets a positive Go duration environment variable with fallback
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// ginModes lists the accepted Gin modes
+var ginModes = map[string]bool{"debug": true, "release": true, "test": true}
+
+// getEnvMode gets a Gin mode environment variable with fallback, warning
+// about values Gin does not know
+func getEnvMode(key, fallback string) string {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
+	if value == "" {
+		return fallback
+	}
+	if !ginModes[value] {
+		log.Printf("Warning: invalid %s %q, expected debug, release or test; using %s", key, value, fallback)
+		return fallback
+	}
+	return value
+}
+
+// dbLogLevels lists the accepted GORM log levels
+var dbLogLevels = map[string]bool{"silent": true, "error": true, "warn": true, "info": true}
+
+// getEnvLogLevel gets a GORM log level environment variable with fallback,
+// warning about unknown levels
+func getEnvLogLevel(key, fallback string) string {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
+	if value == "" {
+		return fallback
+	}
+	if !dbLogLevels[value] {
+		log.Printf("Warning: invalid %s %q, expected silent, error, warn or info; using %s", key, value, fallback)
+		return fallback
+	}
+	return value
+}
+
+// Redis deployment modes accepted by REDIS_MODE
+const (
+	RedisModeSingle   = "single"
+	RedisModeSentinel = "sentinel"
+	RedisModeCluster  = "cluster"
+)
+
+// getEnvRedisMode gets a Redis mode environment variable with fallback,
+// warning about unknown modes
+func getEnvRedisMode(key, fallback string) string {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
+	if value == "" {
+		return fallback
+	}
+	if value != RedisModeSingle && value != RedisModeSentinel && value != RedisModeCluster {
+		log.Printf("Warning: invalid %s %q, expected single, sentinel or cluster; using %s", key, value, fallback)
+		return fallback
+	}
+	return value
+}
+
+// Policies accepted by ON_USER_DELETE for a deleted user's dependent records
+const (
+	OnDeleteCascade = "cascade"
+	OnDeleteRetain  = "retain"
+)
+
+// getEnvDeletePolicy gets a delete policy environment variable with
+// fallback, warning about unknown policies
+func getEnvDeletePolicy(key, fallback string) string {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
+	if value == "" {
+		return fallback
+	}
+	if value != OnDeleteCascade && value != OnDeleteRetain {
+		log.Printf("Warning: invalid %s %q, expected cascade or retain; using %s", key, value, fallback)
+		return fallback
+	}
+	return value
+}
+
+// getEnvList gets a comma-separated environment variable as a list,
+// skipping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvListOr gets a comma-separated environment variable as a list,
+// using fallback when the variable is unset or lists nothing
+func getEnvListOr(key string, fallback []string) []string {
+	if values := getEnvList(key); len(values) > 0 {
+		return values
+	}
+	return fallback
+}
+
+// getEnvFeatures collects boolean environment variables with the given
+// prefix into a feature map keyed by the lowercased remainder of the name
+// (FEATURE_SEARCH=true enables "search"), skipping unparseable values
+func getEnvFeatures(prefix string) Features {
+	features := Features{}
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		name, ok := strings.CutPrefix(key, prefix)
+		if !ok || name == "" {
+			continue
+		}
+		if enabled, err := strconv.ParseBool(value); err == nil {
+			features[strings.ToLower(name)] = enabled
+		}
+	}
+	return features
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/IntouchOpec/user_management/database"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminController handles debugging endpoints for operators
+type AdminController struct{}
+
+// NewAdminController creates a new admin controller instance
+func NewAdminController() *AdminController {
+	return &AdminController{}
+}
+
+// GetSchema handles GET /admin/schema
+// @Summary Get the users table schema
+// @Description List the users table columns and types as they exist in the database, to diagnose migration drift. Only available when FEATURE_DEBUG is enabled.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Table columns"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/schema [get]
+func (ac *AdminController) GetSchema(c *gin.Context) {
+	columns, err := database.UserColumns()
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"table":   "users",
+		"columns": columns,
+	})
+}
+
+// GetDuplicateEmails handles GET /admin/duplicates/emails
+// @Summary Find duplicate emails
+// @Description List emails, ignoring case, held by more than one users row across active and soft-deleted users, to find data-integrity problems before tightening constraints. Requires an admin bearer token; only available when FEATURE_DEBUG is enabled.
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Bearer token with the admin role"
+// @Success 200 {object} map[string]interface{} "Duplicate emails with active and deleted row counts"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Caller is not an admin"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /admin/duplicates/emails [get]
+func (ac *AdminController) GetDuplicateEmails(c *gin.Context) {
+	duplicates, err := database.DuplicateEmails()
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"data":  duplicates,
+		"total": len(duplicates),
+	})
+}
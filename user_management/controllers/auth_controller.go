@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/IntouchOpec/user_management/auth"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/gin-gonic/gin"
+)
+
+// AuthController handles token endpoints
+type AuthController struct {
+	secret string
+}
+
+// NewAuthController creates a new auth controller that verifies tokens
+// with the given HS256 secret
+func NewAuthController(secret string) *AuthController {
+	return &AuthController{secret: secret}
+}
+
+// IntrospectRequest is the token to introspect, sent as JSON or as a
+// form field
+type IntrospectRequest struct {
+	Token string `json:"token" form:"token"`
+}
+
+// Introspect handles POST /auth/introspect
+// @Summary Introspect a JWT
+// @Description Report whether a token is valid along with its claims and remaining lifetime, without acting on it. Invalid, expired and tampered tokens yield {"active": false}.
+// @Tags auth
+// @Accept json
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param request body IntrospectRequest true "Token to introspect"
+// @Success 200 {object} map[string]interface{} "Token state and claims"
+// @Failure 400 {object} map[string]interface{} "Missing token"
+// @Router /auth/introspect [post]
+func (ac *AuthController) Introspect(c *gin.Context) {
+	var req IntrospectRequest
+	if err := c.ShouldBind(&req); err != nil {
+		invalidRequestBody(c, err)
+		return
+	}
+	if req.Token == "" {
+		writeError(c, http.StatusBadRequest, models.CodeValidationFailed, "token is required")
+		return
+	}
+
+	// Introspection results are about a credential and must not be reused
+	c.Header("Cache-Control", "no-store")
+
+	claims, err := auth.Parse(ac.secret, req.Token)
+	if err != nil {
+		writeJSON(c, http.StatusOK, gin.H{"active": false})
+		return
+	}
+
+	response := gin.H{
+		"active":     true,
+		"sub":        claims.Subject,
+		"exp":        claims.ExpiresAt.Unix(),
+		"expires_in": int64(claims.ExpiresIn(time.Now()).Seconds()),
+	}
+	if claims.Role != "" {
+		response["role"] = claims.Role
+	}
+	if claims.TenantID != "" {
+		response["tenant_id"] = claims.TenantID
+	}
+	if claims.IssuedAt != nil {
+		response["iat"] = claims.IssuedAt.Unix()
+	}
+	writeJSON(c, http.StatusOK, response)
+}
+
+// WhoAmI handles GET /auth/whoami
+// @Summary Describe the caller
+// @Description Return the ID, email and role of the user the bearer token was issued to, and whether they are an admin
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Caller identity"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Router /auth/whoami [get]
+func (ac *AuthController) WhoAmI(c *gin.Context) {
+	token := auth.BearerToken(c.GetHeader("Authorization"))
+	if token == "" {
+		writeError(c, http.StatusUnauthorized, models.CodeUnauthorized, "Authentication required")
+		return
+	}
+
+	claims, err := auth.Parse(ac.secret, token)
+	if err != nil {
+		writeError(c, http.StatusUnauthorized, models.CodeUnauthorized, err.Error())
+		return
+	}
+	id, ok := claims.UserID()
+	if !ok {
+		writeError(c, http.StatusUnauthorized, models.CodeUnauthorized, "Token does not identify a user")
+		return
+	}
+
+	// The answer depends on the credential, so shared caches must not keep it
+	c.Header("Cache-Control", "private, no-store")
+	writeJSON(c, http.StatusOK, gin.H{
+		"id":       id,
+		"email":    claims.Email,
+		"role":     claims.Role,
+		"is_admin": claims.IsAdmin(),
+	})
+}
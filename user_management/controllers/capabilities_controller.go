@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Capabilities describes the optional behavior a deployment offers, so
+// clients can adapt without probing endpoints
+type Capabilities struct {
+	// Search is text search on GET /users (search or q)
+	Search bool `json:"search"`
+	// Auth is whether bearer tokens can be verified; without a JWT secret
+	// every token is rejected
+	Auth bool `json:"auth"`
+	// Caching is whether reads are served from Redis
+	Caching bool `json:"caching"`
+	// Metrics is a metrics endpoint, which this service does not expose yet
+	Metrics bool `json:"metrics"`
+	// Export is GET /users/export
+	Export bool `json:"export"`
+	// Features holds the state of every feature flag
+	Features map[string]bool `json:"features"`
+}
+
+// CapabilitiesController reports the capabilities fixed at startup
+type CapabilitiesController struct {
+	capabilities Capabilities
+}
+
+// NewCapabilitiesController creates a controller reporting capabilities
+func NewCapabilitiesController(capabilities Capabilities) *CapabilitiesController {
+	return &CapabilitiesController{capabilities: capabilities}
+}
+
+// GetCapabilities handles GET /capabilities
+// @Summary List API capabilities
+// @Description Report which optional behavior is available (search, auth, caching, metrics, export) and the state of every feature flag, so clients can adapt
+// @Tags health
+// @Produce json
+// @Success 200 {object} controllers.Capabilities "Available capabilities"
+// @Router /capabilities [get]
+func (cc *CapabilitiesController) GetCapabilities(c *gin.Context) {
+	writeJSON(c, http.StatusOK, cc.capabilities)
+}
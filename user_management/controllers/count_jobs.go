@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// countTokenTTL is how long a finished count stays retrievable by its token
+const countTokenTTL = 10 * time.Minute
+
+// countJob is a filtered user count running in the background
+type countJob struct {
+	tenant   string
+	done     bool
+	count    int64
+	err      error
+	finished time.Time
+}
+
+// countJobs tracks background counts started by list requests, keyed by
+// the token handed to the client
+type countJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*countJob
+}
+
+func newCountJobs() *countJobs {
+	return &countJobs{jobs: make(map[string]*countJob)}
+}
+
+// start runs count in a new goroutine and returns the token to poll it with.
+// Finished jobs older than countTokenTTL are dropped as new ones start.
+func (j *countJobs) start(tenant string, count func() (int64, error)) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	job := &countJob{tenant: tenant}
+	j.mu.Lock()
+	for key, old := range j.jobs {
+		if old.done && time.Since(old.finished) > countTokenTTL {
+			delete(j.jobs, key)
+		}
+	}
+	j.jobs[token] = job
+	j.mu.Unlock()
+
+	go func() {
+		n, err := count()
+
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		job.count, job.err = n, err
+		job.done = true
+		job.finished = time.Now()
+	}()
+	return token
+}
+
+// get returns a snapshot of the job for token, if it exists and belongs
+// to tenant
+func (j *countJobs) get(token, tenant string) (countJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job, ok := j.jobs[token]
+	if !ok || job.tenant != tenant {
+		return countJob{}, false
+	}
+	return *job, true
+}
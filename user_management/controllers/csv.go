@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IntouchOpec/user_management/models"
+)
+
+// requiredCSVColumns must appear in the header row of an imported file
+var requiredCSVColumns = []string{"name", "email", "age"}
+
+// parseUsersCSV reads user rows from CSV. The first row is a header naming
+// the columns in any order; unknown columns are rejected rather than
+// silently dropped.
+func parseUsersCSV(r io.Reader) ([]models.UserRequest, error) {
+	if r == nil {
+		return nil, errors.New("file is empty")
+	}
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "name", "email", "age", "phone", "address", "is_active", "created_at":
+			columns[name] = i
+		default:
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+	}
+	for _, name := range requiredCSVColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing required column %q", name)
+		}
+	}
+
+	var reqs []models.UserRequest
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return reqs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		req := models.UserRequest{
+			Name:    field("name"),
+			Email:   field("email"),
+			Phone:   field("phone"),
+			Address: field("address"),
+		}
+
+		if req.Age, err = strconv.Atoi(field("age")); err != nil {
+			return nil, fmt.Errorf("line %d: invalid age %q", line, field("age"))
+		}
+
+		if value := field("is_active"); value != "" {
+			active, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid is_active %q", line, value)
+			}
+			req.IsActive = &active
+		}
+
+		if value := field("created_at"); value != "" {
+			createdAt, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid created_at %q, want RFC 3339", line, value)
+			}
+			req.CreatedAt = &createdAt
+		}
+
+		reqs = append(reqs, req)
+	}
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/gin-gonic/gin"
+)
+
+// errorMapping ties a typed error to the HTTP status and code it produces
+type errorMapping struct {
+	match  func(error) bool
+	status int
+	code   string
+}
+
+// errorRegistry maps typed errors returned by the service layer to HTTP
+// responses. Entries are checked in order; the first match wins.
+var errorRegistry = []errorMapping{
+	{match: isError(models.ErrUserNotFound), status: http.StatusNotFound, code: models.CodeUserNotFound},
+	{match: isError(models.ErrUserNotDeleted), status: http.StatusConflict, code: models.CodeUserNotDeleted},
+	{match: isError(models.ErrEmailExists), status: http.StatusConflict, code: models.CodeEmailExists},
+	{match: asError[*models.UniqueViolationError], status: http.StatusConflict, code: models.CodeDuplicateValue},
+	{match: asError[*models.ValidationError], status: http.StatusBadRequest, code: models.CodeValidationFailed},
+	{match: asError[*models.MinimumAgeError], status: http.StatusBadRequest, code: models.CodeValidationFailed},
+	{match: asError[*models.ConfirmationRequiredError], status: http.StatusBadRequest, code: models.CodeConfirmRequired},
+	{match: asError[*models.StatusTransitionError], status: http.StatusConflict, code: models.CodeInvalidTransition},
+	{match: isError(models.ErrForbidden), status: http.StatusForbidden, code: models.CodeForbidden},
+	{match: asError[*models.FieldForbiddenError], status: http.StatusForbidden, code: models.CodeForbidden},
+	{match: asError[*models.FieldImmutableError], status: http.StatusBadRequest, code: models.CodeFieldImmutable},
+	{match: isError(models.ErrDatabaseUnavailable), status: http.StatusServiceUnavailable, code: models.CodeDBUnavailable},
+}
+
+// statusCodes supplies a generic code for errors missing from the registry
+var statusCodes = map[int]string{
+	http.StatusBadRequest:          models.CodeBadRequest,
+	http.StatusUnauthorized:        models.CodeUnauthorized,
+	http.StatusForbidden:           models.CodeForbidden,
+	http.StatusNotFound:            models.CodeNotFound,
+	http.StatusConflict:            models.CodeConflict,
+	http.StatusInternalServerError: models.CodeInternal,
+}
+
+func isError(target error) func(error) bool {
+	return func(err error) bool {
+		return errors.Is(err, target)
+	}
+}
+
+func asError[T error](err error) bool {
+	var target T
+	return errors.As(err, &target)
+}
+
+// resolveError returns the status and code for err, falling back to
+// fallbackStatus and its generic code when err is not registered
+func resolveError(err error, fallbackStatus int) (int, string) {
+	for _, mapping := range errorRegistry {
+		if mapping.match(err) {
+			return mapping.status, mapping.code
+		}
+	}
+
+	code, ok := statusCodes[fallbackStatus]
+	if !ok {
+		code = models.CodeInternal
+	}
+	return fallbackStatus, code
+}
+
+// respondError writes err as an error response, using the registry to pick
+// the status and code
+func respondError(c *gin.Context, err error, fallbackStatus int, fields ...gin.H) {
+	status, code := resolveError(err, fallbackStatus)
+	writeError(c, status, code, err.Error(), fields...)
+}
+
+// writeError writes an error response with the given code and message,
+// merging in any extra fields
+func writeError(c *gin.Context, status int, code, message string, fields ...gin.H) {
+	body := gin.H{
+		"error": message,
+		"code":  code,
+	}
+	for _, extra := range fields {
+		for key, value := range extra {
+			body[key] = value
+		}
+	}
+	writeJSON(c, status, body)
+}
+
+// invalidRequestBody writes the response for a body that failed to bind
+func invalidRequestBody(c *gin.Context, err error) {
+	writeError(c, http.StatusBadRequest, models.CodeInvalidRequestBody, "Invalid request body", gin.H{
+		"details": err.Error(),
+	})
+}
+
+// conflictFields names the field of a unique violation, or lists the
+// conflicting emails of a failed batch request so clients can fix them all
+// at once
+func conflictFields(err error) gin.H {
+	var exists *models.EmailExistsError
+	if errors.As(err, &exists) && len(exists.Emails) > 0 {
+		return gin.H{"emails": exists.Emails}
+	}
+	var duplicate *models.UniqueViolationError
+	if errors.As(err, &duplicate) && duplicate.Field != "" {
+		return gin.H{"field": duplicate.Field}
+	}
+	return nil
+}
+
+// validationFields lists what is wrong with each field of a rejected
+// request, keyed by JSON name
+func validationFields(err error) gin.H {
+	var validation *models.ValidationError
+	if errors.As(err, &validation) && len(validation.Fields) > 0 {
+		return gin.H{"fields": validation.Fields}
+	}
+	return nil
+}
+
+// minimumAgeFields names age and the minimum when a new user is too young
+// to register
+func minimumAgeFields(err error) gin.H {
+	var tooYoung *models.MinimumAgeError
+	if errors.As(err, &tooYoung) {
+		return gin.H{"field": "age", "min_age": tooYoung.MinAge}
+	}
+	return nil
+}
+
+// confirmationFields reports how many users a bulk change matched and the
+// most it may match without confirmation
+func confirmationFields(err error) gin.H {
+	var unconfirmed *models.ConfirmationRequiredError
+	if errors.As(err, &unconfirmed) {
+		return gin.H{"matched": unconfirmed.Matched, "threshold": unconfirmed.Threshold}
+	}
+	return nil
+}
+
+// forbiddenField names the field a caller may not change, because it is
+// admin-only or immutable
+func forbiddenField(err error) gin.H {
+	var forbidden *models.FieldForbiddenError
+	if errors.As(err, &forbidden) {
+		return gin.H{"field": forbidden.Field}
+	}
+	var immutable *models.FieldImmutableError
+	if errors.As(err, &immutable) {
+		return gin.H{"field": immutable.Field}
+	}
+	return nil
+}
+
+// invalidPagination writes the response for malformed page or page_size
+// parameters, listing each problem in details
+func invalidPagination(c *gin.Context, err error) {
+	var fields []gin.H
+	var pagination *PaginationError
+	if errors.As(err, &pagination) {
+		fields = append(fields, gin.H{"details": pagination.Problems})
+	}
+	writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, err.Error(), fields...)
+}
+
+// invalidUserID writes the response for a malformed :id path parameter
+func invalidUserID(c *gin.Context) {
+	writeError(c, http.StatusBadRequest, models.CodeInvalidID, "Invalid user ID")
+}
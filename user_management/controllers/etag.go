@@ -0,0 +1,19 @@
+package controllers
+
+import "strings"
+
+// etagMatches reports whether an If-None-Match header value matches etag
+// using the weak comparison required for If-None-Match (RFC 9110 13.1.2)
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/IntouchOpec/user_management/models"
+)
+
+// exportCSVColumns matches the columns accepted by the CSV import, so an
+// exported file can be imported again as is
+var exportCSVColumns = []string{"name", "email", "age", "phone", "address", "is_active"}
+
+// userExporter writes users to a download in batches
+type userExporter interface {
+	Begin() error
+	Write(users []models.UserResponse) error
+	End() error
+}
+
+// exportFormat describes a supported export file type
+type exportFormat struct {
+	contentType string
+	extension   string
+	newExporter func(w io.Writer) userExporter
+}
+
+// exportFormats lists the formats accepted by the format query parameter
+var exportFormats = map[string]exportFormat{
+	"csv": {
+		contentType: "text/csv; charset=utf-8",
+		extension:   "csv",
+		newExporter: func(w io.Writer) userExporter { return &csvExporter{w: csv.NewWriter(w)} },
+	},
+	"json": {
+		contentType: "application/json; charset=utf-8",
+		extension:   "json",
+		newExporter: func(w io.Writer) userExporter { return &jsonExporter{w: w} },
+	},
+}
+
+// csvExporter writes a header row followed by one row per user
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func (e *csvExporter) Begin() error {
+	return e.w.Write(exportCSVColumns)
+}
+
+func (e *csvExporter) Write(users []models.UserResponse) error {
+	for _, user := range users {
+		err := e.w.Write([]string{
+			user.Name,
+			user.Email,
+			strconv.Itoa(user.Age),
+			user.Phone,
+			user.Address,
+			strconv.FormatBool(user.IsActive),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExporter) End() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonExporter writes a JSON array of users without holding the whole
+// array in memory
+type jsonExporter struct {
+	w       io.Writer
+	written bool
+}
+
+func (e *jsonExporter) Begin() error {
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonExporter) Write(users []models.UserResponse) error {
+	for _, user := range users {
+		data, err := json.Marshal(user)
+		if err != nil {
+			return err
+		}
+		if e.written {
+			data = append([]byte(","), data...)
+		}
+		if _, err := e.w.Write(data); err != nil {
+			return err
+		}
+		e.written = true
+	}
+	return nil
+}
+
+func (e *jsonExporter) End() error {
+	_, err := io.WriteString(e.w, "]\n")
+	return err
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows a gzip
+// response, treating q=0 as a refusal
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(value, 64); err == nil && q == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// warmupRetryAfter is the Retry-After, in seconds, sent while the
+	// server is still warming up
+	warmupRetryAfter = 5
+
+	// dependencyTimeout bounds each dependency check of a readiness probe
+	dependencyTimeout = 2 * time.Second
+)
+
+// DependencyCheck reports whether a dependency such as the database is
+// reachable, returning an error when it is not
+type DependencyCheck func(ctx context.Context) error
+
+// errNotConfigured is reported for dependencies registered without a check
+var errNotConfigured = errors.New("not configured")
+
+type dependency struct {
+	name  string
+	check DependencyCheck
+}
+
+// HealthController reports whether the server is ready for traffic
+type HealthController struct {
+	ready        atomic.Bool
+	dependencies []dependency
+}
+
+// HealthOption configures optional HealthController behavior
+type HealthOption func(*HealthController)
+
+// WithDependency makes readiness require the named dependency to pass
+// check. A nil check always fails, for dependencies that could not be set
+// up.
+func WithDependency(name string, check DependencyCheck) HealthOption {
+	return func(hc *HealthController) {
+		hc.dependencies = append(hc.dependencies, dependency{name: name, check: check})
+	}
+}
+
+// NewHealthController creates a health controller that reports not ready
+// until SetReady(true) is called
+func NewHealthController(opts ...HealthOption) *HealthController {
+	hc := &HealthController{}
+	for _, opt := range opts {
+		opt(hc)
+	}
+	return hc
+}
+
+// SetReady marks the server ready or, during shutdown, not ready
+func (hc *HealthController) SetReady(ready bool) {
+	hc.ready.Store(ready)
+}
+
+// Ready handles GET /health/ready
+// @Summary Readiness check
+// @Description Report whether startup warmup has finished and every dependency (database, Redis) answers a ping within 2 seconds. Until warmup is done, respond 503 with a Retry-After header so load balancers hold traffic back; when a dependency is down, respond 503 naming each dependency as up or down.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Ready for traffic"
+// @Failure 503 {object} map[string]interface{} "Still warming up, or a dependency is down"
+// @Router /health/ready [get]
+func (hc *HealthController) Ready(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	if !hc.ready.Load() {
+		c.Header("Retry-After", strconv.Itoa(warmupRetryAfter))
+		writeJSON(c, http.StatusServiceUnavailable, gin.H{"status": "warming_up"})
+		return
+	}
+
+	body, healthy := hc.checkDependencies(c.Request.Context())
+	if !healthy {
+		body["status"] = "unavailable"
+		writeJSON(c, http.StatusServiceUnavailable, body)
+		return
+	}
+	body["status"] = "ready"
+	writeJSON(c, http.StatusOK, body)
+}
+
+// checkDependencies runs every dependency check concurrently and reports
+// each as up or down, and whether all are up
+func (hc *HealthController) checkDependencies(ctx context.Context) (gin.H, bool) {
+	ctx, cancel := context.WithTimeout(ctx, dependencyTimeout)
+	defer cancel()
+
+	errs := make([]error, len(hc.dependencies))
+	var wg sync.WaitGroup
+	for i, dep := range hc.dependencies {
+		if dep.check == nil {
+			errs[i] = errNotConfigured
+			continue
+		}
+		wg.Add(1)
+		go func(i int, check DependencyCheck) {
+			defer wg.Done()
+			errs[i] = check(ctx)
+		}(i, dep.check)
+	}
+	wg.Wait()
+
+	body := gin.H{}
+	healthy := true
+	for i, dep := range hc.dependencies {
+		if errs[i] != nil {
+			body[dep.name] = "down"
+			healthy = false
+		} else {
+			body[dep.name] = "up"
+		}
+	}
+	return body, healthy
+}
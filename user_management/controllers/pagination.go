@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Page size bounds applied to list requests
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 100
+
+	// defaultRecentLimit is the number of newest users returned by default
+	defaultRecentLimit = 5
+)
+
+// PaginationError lists every malformed pagination parameter of a request,
+// so clients can fix them all at once
+type PaginationError struct {
+	Problems []string
+}
+
+func (e *PaginationError) Error() string {
+	return "Invalid pagination: " + strings.Join(e.Problems, "; ")
+}
+
+// ParsePagination reads the page and page_size query parameters, or
+// their offset and limit equivalents; a request may use either style but
+// not both. Missing values take the defaults and out-of-range values are
+// clamped (page to at least 1, offset to at least 0, page_size and limit to
+// 1..MaxPageSize). Values that are not integers, an offset that is not a
+// multiple of the limit, and mixed styles are reported as a
+// *PaginationError.
+func ParsePagination(c *gin.Context) (page, size int, err error) {
+	var problems []string
+	parse := func(name string, fallback int) int {
+		raw := strings.TrimSpace(c.Query(name))
+		if raw == "" {
+			return fallback
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s must be an integer, got %q", name, raw))
+			return fallback
+		}
+		return value
+	}
+	present := func(names ...string) bool {
+		for _, name := range names {
+			if _, ok := c.GetQuery(name); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	if present("offset", "limit") {
+		if present("page", "page_size") {
+			return 0, 0, &PaginationError{Problems: []string{"use either page/page_size or offset/limit, not both"}}
+		}
+
+		offset := parse("offset", 0)
+		size = parse("limit", DefaultPageSize)
+		if len(problems) > 0 {
+			return 0, 0, &PaginationError{Problems: problems}
+		}
+		if offset < 0 {
+			offset = 0
+		}
+		size = clampPageSize(size)
+		if offset%size != 0 {
+			return 0, 0, &PaginationError{Problems: []string{fmt.Sprintf("offset must be a multiple of limit (%d), got %d", size, offset)}}
+		}
+		return offset/size + 1, size, nil
+	}
+
+	page = parse("page", 1)
+	size = parse("page_size", DefaultPageSize)
+	if len(problems) > 0 {
+		return 0, 0, &PaginationError{Problems: problems}
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	return page, clampPageSize(size), nil
+}
+
+// ParseCursor reads the cursor and limit query parameters of a cursor
+// paginated request. An empty cursor starts from the first user; limit
+// defaults and is clamped like page_size. Malformed values, and cursors
+// mixed with page/offset parameters, are reported as a *PaginationError.
+func ParseCursor(c *gin.Context) (afterID uint, limit int, err error) {
+	for _, name := range []string{"page", "page_size", "offset"} {
+		if _, ok := c.GetQuery(name); ok {
+			return 0, 0, &PaginationError{Problems: []string{"use either cursor/limit or page/page_size/offset, not both"}}
+		}
+	}
+
+	var problems []string
+	if raw := strings.TrimSpace(c.Query("cursor")); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 0)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("cursor must be a user ID, got %q", raw))
+		}
+		afterID = uint(id)
+	}
+
+	limit = DefaultPageSize
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("limit must be an integer, got %q", raw))
+		}
+		limit = value
+	}
+	if len(problems) > 0 {
+		return 0, 0, &PaginationError{Problems: problems}
+	}
+
+	return afterID, clampPageSize(limit), nil
+}
+
+// clampPageSize bounds a page size to 1..MaxPageSize
+func clampPageSize(size int) int {
+	if size < 1 {
+		return 1
+	}
+	if size > MaxPageSize {
+		return MaxPageSize
+	}
+	return size
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"encoding/xml"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// render writes body as XML when the Accept header prefers it and as JSON
+// otherwise, including when no Accept header is sent
+func render(c *gin.Context, status int, body gin.H) {
+	c.Header("Vary", "Accept")
+
+	if renderFormat(c) == formatXML {
+		c.XML(status, xmlDocument(body))
+		return
+	}
+	writeJSON(c, status, body)
+}
+
+// Formats render can produce
+const (
+	formatJSON = "json"
+	formatXML  = "xml"
+)
+
+// renderFormat returns the format render picks for the request's Accept
+// header, so validators can tell the representations apart
+func renderFormat(c *gin.Context) string {
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEXML, binding.MIMEXML2) {
+	case binding.MIMEXML, binding.MIMEXML2:
+		return formatXML
+	default:
+		return formatJSON
+	}
+}
+
+// writeJSON writes body as JSON, indented when the request asks for
+// ?pretty=true. Without the parameter output is indented only in Gin's
+// debug mode, so release builds stay compact.
+func writeJSON(c *gin.Context, status int, body interface{}) {
+	if wantsPretty(c) {
+		c.IndentedJSON(status, body)
+		return
+	}
+	c.JSON(status, body)
+}
+
+func wantsPretty(c *gin.Context) bool {
+	if raw, ok := c.GetQuery("pretty"); ok {
+		// A bare ?pretty counts as true
+		if raw == "" {
+			return true
+		}
+		pretty, err := strconv.ParseBool(raw)
+		return err == nil && pretty
+	}
+	return gin.Mode() == gin.DebugMode
+}
+
+// xmlDocument is the root <response> element of an XML response
+type xmlDocument gin.H
+
+func (d xmlDocument) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return xmlMap(d).MarshalXML(e, xml.StartElement{Name: xml.Name{Local: "response"}})
+}
+
+// xmlMap marshals a gin.H with one child element per key, in key order.
+// Unlike gin.H's own MarshalXML it keeps the element name of nested maps
+// instead of renaming them to <map>.
+type xmlMap gin.H
+
+func (m xmlMap) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := m[key]
+		if nested, ok := value.(gin.H); ok {
+			value = xmlMap(nested)
+		}
+		if err := e.EncodeElement(value, xml.StartElement{Name: xml.Name{Local: key}}); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
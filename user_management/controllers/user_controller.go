@@ -1,233 +1,1816 @@
 package controllers
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/reqctx"
 	"github.com/IntouchOpec/user_management/service"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
+// defaultMaxBulkSize is the maximum number of items per bulk request
+// when no limit is configured
+const defaultMaxBulkSize = 1000
+
+// defaultMaxSearchOffset is the deepest row a text search can page to when
+// no limit is configured
+const defaultMaxSearchOffset = 10000
+
+// defaultRoleConfirmThreshold is how many users a bulk role assignment may
+// match before it must be confirmed
+const defaultRoleConfirmThreshold = 100
+
+// Service identity reported by the root endpoint unless overridden
+const (
+	defaultServiceName = "user-management"
+	defaultVersion     = "1.0"
+)
+
+// staleWarning is the Warning header sent with users served from cache
+// while the database is unreachable
+const staleWarning = `110 - "Response is Stale"`
+
 // UserController handles HTTP requests for user operations
 type UserController struct {
 	userService service.UserService
+	maxBulkSize int
+	maxSearch   int
+	strictJSON  bool
+	sortDesc    bool
+	nullsFirst  bool
+	minAge      int
+	ageRequired bool
+	strongETags bool
+	roleConfirm int
+	serviceName string
+	version     string
+	counts      *countJobs
+}
+
+// Option configures optional UserController behavior
+type Option func(*UserController)
+
+// WithMaxBulkSize sets the maximum number of items accepted per bulk request
+func WithMaxBulkSize(size int) Option {
+	return func(uc *UserController) {
+		if size > 0 {
+			uc.maxBulkSize = size
+		}
+	}
+}
+
+// WithMaxSearchOffset sets how deep a text search can page: requests with
+// page * page_size beyond it are rejected, since every earlier match must
+// be scanned to reach them
+func WithMaxSearchOffset(offset int) Option {
+	return func(uc *UserController) {
+		if offset > 0 {
+			uc.maxSearch = offset
+		}
+	}
+}
+
+// WithRoleConfirmThreshold sets how many users a bulk role assignment may
+// match before it requires confirm=true
+func WithRoleConfirmThreshold(threshold int) Option {
+	return func(uc *UserController) {
+		if threshold > 0 {
+			uc.roleConfirm = threshold
+		}
+	}
+}
+
+// WithStrictJSON rejects request bodies containing fields that are not part
+// of the target request type instead of silently ignoring them
+func WithStrictJSON(strict bool) Option {
+	return func(uc *UserController) {
+		uc.strictJSON = strict
+	}
+}
+
+// WithSortDefaults sets the direction ("asc" or "desc") and null position
+// ("first" or "last") used when a sorted list request does not specify
+// them. Unrecognized values keep the defaults of ascending, nulls last.
+func WithSortDefaults(order, nulls string) Option {
+	return func(uc *UserController) {
+		if desc, err := parseSortOrder(order); err == nil {
+			uc.sortDesc = desc
+		}
+		if first, err := parseNullsOrder(nulls); err == nil {
+			uc.nullsFirst = first
+		}
+	}
+}
+
+// WithETagStyle selects "strong" or "weak" entity tags. Strong tags
+// promise byte-identical responses, which field selection and compression
+// can break, so anything other than "strong" keeps the weak default.
+func WithETagStyle(style string) Option {
+	return func(uc *UserController) {
+		uc.strongETags = strings.EqualFold(strings.TrimSpace(style), "strong")
+	}
+}
+
+// WithMinRegistrationAge makes POST /users/validate report users below the
+// given age. Creates are rejected by service.WithMinRegistrationAge, which
+// should be given the same age.
+func WithMinRegistrationAge(age int) Option {
+	return func(uc *UserController) {
+		if age > 0 {
+			uc.minAge = age
+		}
+	}
+}
+
+// WithAgeRequired makes POST /users/validate report bodies that omit age
+// and the schema list age as required. Writes are rejected by
+// service.WithAgeRequired, which should be given the same setting.
+func WithAgeRequired(required bool) Option {
+	return func(uc *UserController) {
+		uc.ageRequired = required
+	}
+}
+
+// WithServiceInfo sets the service name and version reported at the root path
+func WithServiceInfo(name, version string) Option {
+	return func(uc *UserController) {
+		if name != "" {
+			uc.serviceName = name
+		}
+		if version != "" {
+			uc.version = version
+		}
+	}
 }
 
 // NewUserController creates a new user controller instance
-func NewUserController(userService service.UserService) *UserController {
-	return &UserController{
+func NewUserController(userService service.UserService, opts ...Option) *UserController {
+	uc := &UserController{
 		userService: userService,
+		maxBulkSize: defaultMaxBulkSize,
+		maxSearch:   defaultMaxSearchOffset,
+		roleConfirm: defaultRoleConfirmThreshold,
+		serviceName: defaultServiceName,
+		version:     defaultVersion,
+		counts:      newCountJobs(),
+	}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+// serviceFor returns the user service bound to the request's context
+func (uc *UserController) serviceFor(c *gin.Context) service.UserService {
+	return uc.userService.WithContext(c.Request.Context())
+}
+
+// bindJSON decodes and validates the request body into obj. In strict mode
+// unknown fields produce an error naming the offending field.
+func (uc *UserController) bindJSON(c *gin.Context, obj interface{}) error {
+	if !uc.strictJSON {
+		return c.ShouldBindJSON(obj)
+	}
+
+	if c.Request.Body == nil {
+		return errors.New("request body is empty")
+	}
+
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(obj); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// bindUserRequests decodes a body holding either a single user request or
+// an array of them, honoring strict JSON mode. missingAge reports, per
+// request, whether age was left out.
+func (uc *UserController) bindUserRequests(c *gin.Context) (reqs []models.UserRequest, missingAge []bool, err error) {
+	body, err := c.GetRawData()
+	if err != nil {
+		return nil, nil, err
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, nil, errors.New("request body is empty")
+	}
+	if body[0] != '[' {
+		body = append(append([]byte{'['}, body...), ']')
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if uc.strictJSON {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(&reqs); err != nil {
+		return nil, nil, err
+	}
+
+	var fields []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, nil, err
+	}
+	missingAge = make([]bool, len(fields))
+	for i, item := range fields {
+		_, given := item["age"]
+		missingAge[i] = !given
 	}
+	return reqs, missingAge, nil
+}
+
+// bindUserRequest binds a single user request, marking whether it left out
+// age. It writes the error response and returns false when the body is
+// malformed.
+func (uc *UserController) bindUserRequest(c *gin.Context, req *models.UserRequest) bool {
+	body, err := c.GetRawData()
+	if err != nil {
+		invalidRequestBody(c, err)
+		return false
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err := uc.bindJSON(c, req); err != nil {
+		invalidRequestBody(c, err)
+		return false
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) == nil {
+		_, given := fields["age"]
+		req.AgeOmitted = !given
+	}
+	return true
 }
 
 // CreateUser handles POST /users
 // @Summary Create a new user
-// @Description Create a new user with name, email, age, phone, and address
+// @Description Create a new user with name, email, age, phone, and address. The age must meet MIN_REGISTRATION_AGE when configured.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param user body models.UserRequest true "User data"
 // @Success 201 {object} map[string]interface{} "User created successfully"
 // @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 409 {object} map[string]interface{} "Email already exists"
 // @Router /users [post]
 func (uc *UserController) CreateUser(c *gin.Context) {
 	var req models.UserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+	if !uc.bindUserRequest(c, &req) {
 		return
 	}
 
-	user, err := uc.userService.CreateUser(req)
+	user, err := uc.serviceFor(c).CreateUser(req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		respondError(c, err, http.StatusBadRequest, conflictFields(err), validationFields(err), minimumAgeFields(err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	writeJSON(c, http.StatusCreated, gin.H{
 		"message": "User created successfully",
 		"data":    user,
 	})
 }
 
+// GetUserSchema handles GET /users/schema
+// @Summary Get the user request schema
+// @Description Describe the body of POST /users and PUT /users/{id} as a JSON Schema built from the validation rules, so clients can generate forms. age is required under AGE_REQUIRED, and unknown fields are disallowed under STRICT_JSON.
+// @Tags users
+// @Produce json
+// @Success 200 {object} models.JSONSchema "JSON Schema of UserRequest"
+// @Router /users/schema [get]
+func (uc *UserController) GetUserSchema(c *gin.Context) {
+	schema := models.SchemaFor(models.UserRequest{})
+	if uc.ageRequired {
+		schema.Required = append(schema.Required, "age")
+	}
+	if uc.strictJSON {
+		additional := false
+		schema.AdditionalProperties = &additional
+	}
+
+	writeJSON(c, http.StatusOK, schema)
+}
+
+// GetUserByEmail handles GET /users/by-email
+// @Summary Get user by email
+// @Description Get the user with the given email address, ignoring case
+// @Tags users
+// @Produce json
+// @Produce xml
+// @Param email query string true "Email address"
+// @Success 200 {object} map[string]interface{} "User data"
+// @Failure 400 {object} map[string]interface{} "Missing or malformed email"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Router /users/by-email [get]
+func (uc *UserController) GetUserByEmail(c *gin.Context) {
+	email := strings.TrimSpace(c.Query("email"))
+	if email == "" {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "email is required")
+		return
+	}
+	if !models.IsValidEmail(email) {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "email must be a valid email")
+		return
+	}
+
+	user, err := uc.serviceFor(c).GetUserByEmail(email)
+	if err != nil {
+		respondError(c, err, http.StatusNotFound)
+		return
+	}
+
+	render(c, http.StatusOK, gin.H{"data": user})
+}
+
 // GetUser handles GET /users/:id
 // @Summary Get user by ID
-// @Description Get a user by their ID
+// @Description Get a user by their ID. Request /users/{id}.vcf or send Accept: text/vcard to download the user as a vCard 3.0 contact.
 // @Tags users
 // @Accept json
 // @Produce json
+// @Produce xml
+// @Produce text/vcard
 // @Param id path int true "User ID"
 // @Success 200 {object} map[string]interface{} "User data"
 // @Failure 400 {object} map[string]interface{} "Invalid user ID"
 // @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 503 {object} map[string]interface{} "Database unavailable and no cached copy"
 // @Router /users/{id} [get]
 func (uc *UserController) GetUser(c *gin.Context) {
-	idParam := c.Param("id")
+	idParam, vcf := strings.CutSuffix(c.Param("id"), vCardExtension)
 	id, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-		})
+		invalidUserID(c)
 		return
 	}
 
-	user, err := uc.userService.GetUserByID(uint(id))
+	user, err := uc.serviceFor(c).GetUserByID(uint(id))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		respondError(c, err, http.StatusNotFound)
+		return
+	}
+
+	body := gin.H{"data": user}
+	if user.Stale {
+		c.Header("Warning", staleWarning)
+		body["stale"] = true
+	}
+
+	if vcf || acceptsVCard(c) {
+		renderVCard(c, user)
+		return
+	}
+
+	render(c, http.StatusOK, body)
+}
+
+// GetRandomUser handles GET /users/random
+// @Summary Get a random user
+// @Description Get a user picked at random, for demos and spot checks
+// @Tags users
+// @Produce json
+// @Success 200 {object} map[string]interface{} "A random user"
+// @Failure 404 {object} map[string]interface{} "There are no users"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/random [get]
+func (uc *UserController) GetRandomUser(c *gin.Context) {
+	user, err := uc.serviceFor(c).GetRandomUser()
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	// Each request should get a fresh pick
+	c.Header("Cache-Control", "no-store")
+	writeJSON(c, http.StatusOK, gin.H{
 		"data": user,
 	})
 }
 
-// GetUsers handles GET /users
-// @Summary Get all users with pagination
-// @Description Get a paginated list of all users
+// GetRecentUsers handles GET /users/recent
+// @Summary Get the newest users
+// @Description Get the most recently created users, newest first, for a latest signups widget. Results are cached for up to 30 seconds.
 // @Tags users
-// @Accept json
 // @Produce json
-// @Param page query int false "Page number" default(1)
-// @Param page_size query int false "Page size" default(10)
-// @Success 200 {object} map[string]interface{} "Paginated users list"
+// @Param limit query int false "Number of users (1-100)" default(5)
+// @Success 200 {object} map[string]interface{} "Newest users"
+// @Failure 400 {object} map[string]interface{} "Invalid limit"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
-// @Router /users [get]
-func (uc *UserController) GetUsers(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-
-	users, total, err := uc.userService.GetAllUsers(page, pageSize)
+// @Router /users/recent [get]
+func (uc *UserController) GetRecentUsers(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultRecentLimit)))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "limit must be an integer")
 		return
 	}
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
 
-	totalPages := (int(total) + pageSize - 1) / pageSize
+	users, err := uc.serviceFor(c).GetRecentUsers(limit)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data": users,
-		"pagination": gin.H{
-			"current_page": page,
-			"page_size":    pageSize,
-			"total_items":  total,
-			"total_pages":  totalPages,
-		},
+	writeJSON(c, http.StatusOK, gin.H{
+		"data":  users,
+		"limit": limit,
 	})
 }
 
-// UpdateUser handles PUT /users/:id
-// @Summary Update user by ID
-// @Description Update a user's information by their ID
+// GetSimilarUsers handles GET /users/:id/similar
+// @Summary Find potential duplicate users
+// @Description Find users sharing the given user's normalized name, phone or email local part, scored by how many signals match
 // @Tags users
 // @Accept json
 // @Produce json
+// @Produce xml
 // @Param id path int true "User ID"
-// @Param user body models.UserRequest true "Updated user data"
-// @Success 200 {object} map[string]interface{} "User updated successfully"
-// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Success 200 {object} map[string]interface{} "Candidate matches, highest score first"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
 // @Failure 404 {object} map[string]interface{} "User not found"
-// @Router /users/{id} [put]
-func (uc *UserController) UpdateUser(c *gin.Context) {
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/{id}/similar [get]
+func (uc *UserController) GetSimilarUsers(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-		})
+		invalidUserID(c)
 		return
 	}
 
-	var req models.UserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+	similar, err := uc.serviceFor(c).FindSimilarUsers(uint(id))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
 
-	user, err := uc.userService.UpdateUser(uint(id), req)
+	render(c, http.StatusOK, gin.H{
+		"data": similar,
+	})
+}
+
+// GetUserNeighbors handles GET /users/:id/neighbors
+// @Summary Get the previous and next users
+// @Description Get the users immediately before and after the given user by ID, for prev/next navigation. Either is null at the ends of the list.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "Previous and next users"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/{id}/neighbors [get]
+func (uc *UserController) GetUserNeighbors(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		if err.Error() == "user not found" {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		invalidUserID(c)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "User updated successfully",
-		"data":    user,
+	neighbors, err := uc.serviceFor(c).GetUserNeighbors(uint(id))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"data": neighbors,
 	})
 }
 
-// DeleteUser handles DELETE /users/:id
-// @Summary Delete user by ID
-// @Description Soft delete a user by their ID
+// CompareUsers handles GET /users/compare
+// @Summary Compare two users
+// @Description Diff two users field by field, marking each field same or different, for reviewing potential duplicates before merging them
+// @Tags users
+// @Produce json
+// @Param a query int true "ID of the first user"
+// @Param b query int true "ID of the second user"
+// @Success 200 {object} models.UserComparison "Both users and the diff of their fields"
+// @Failure 400 {object} map[string]interface{} "Missing or invalid user IDs"
+// @Failure 404 {object} map[string]interface{} "Either user not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/compare [get]
+func (uc *UserController) CompareUsers(c *gin.Context) {
+	a, errA := strconv.ParseUint(c.Query("a"), 10, 32)
+	b, errB := strconv.ParseUint(c.Query("b"), 10, 32)
+	if errA != nil || errB != nil {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "a and b must be user IDs")
+		return
+	}
+
+	comparison, err := uc.serviceFor(c).CompareUsers(uint(a), uint(b))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"data": comparison,
+	})
+}
+
+// GetSignupCalendar handles GET /users/calendar
+// @Summary Get daily signup counts for a month
+// @Description Count the users created on each UTC day of a month, for a calendar heatmap. Every day of the month is listed, including days without signups.
+// @Tags users
+// @Produce json
+// @Param year query int true "Year, 1 to 9999"
+// @Param month query int true "Month, 1 to 12"
+// @Success 200 {object} models.SignupCalendar "Signups per day"
+// @Failure 400 {object} map[string]interface{} "Missing or invalid year or month"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/calendar [get]
+func (uc *UserController) GetSignupCalendar(c *gin.Context) {
+	year, err := strconv.Atoi(c.Query("year"))
+	if err != nil || year < 1 || year > 9999 {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "year must be an integer between 1 and 9999")
+		return
+	}
+	month, err := strconv.Atoi(c.Query("month"))
+	if err != nil || month < 1 || month > 12 {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "month must be an integer between 1 and 12")
+		return
+	}
+
+	calendar, err := uc.serviceFor(c).SignupCalendar(year, month)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"data": calendar,
+	})
+}
+
+// GetEmailHistory handles GET /users/:id/email-history
+// @Summary Get a user's email history
+// @Description List the email addresses the user had before, oldest first, with when and by whom each was changed. Only the user themselves or an admin may call it.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param id path int true "User ID"
-// @Success 200 {object} map[string]interface{} "User deleted successfully"
+// @Param Authorization header string true "Bearer token of the user or an admin"
+// @Success 200 {object} map[string]interface{} "Previous emails"
 // @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Caller is neither the user nor an admin"
 // @Failure 404 {object} map[string]interface{} "User not found"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
-// @Router /users/{id} [delete]
-func (uc *UserController) DeleteUser(c *gin.Context) {
+// @Router /users/{id}/email-history [get]
+func (uc *UserController) GetEmailHistory(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-		})
+		invalidUserID(c)
 		return
 	}
 
-	err = uc.userService.DeleteUser(uint(id))
+	history, err := uc.serviceFor(c).GetEmailHistory(uint(id))
 	if err != nil {
-		if err.Error() == "failed to delete user: user not found" {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "User not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		respondError(c, err, http.StatusInternalServerError)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "User deleted successfully",
+	writeJSON(c, http.StatusOK, gin.H{
+		"data": history,
+	})
+}
+
+// GetUserProfile handles GET /users/:id/profile
+// @Summary Get a user's profile
+// @Description Get the user together with derived stats (days_since_signup, email_change_count, audit_event_count) in one response
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.UserProfile "User profile"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/{id}/profile [get]
+func (uc *UserController) GetUserProfile(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		invalidUserID(c)
+		return
+	}
+
+	profile, err := uc.serviceFor(c).GetUserProfile(uint(id))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"data": profile,
+	})
+}
+
+// ExportUserData handles GET /users/:id/export
+// @Summary Export a user's data
+// @Description Download everything stored about the user (profile, email history and audit log) for data portability requests. Only the user themselves or an admin may call it.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param Authorization header string true "Bearer token of the user or an admin"
+// @Success 200 {object} models.UserDataExport "User data"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Caller is neither the user nor an admin"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Router /users/{id}/export [get]
+func (uc *UserController) ExportUserData(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		invalidUserID(c)
+		return
+	}
+
+	export, err := uc.serviceFor(c).ExportUserData(uint(id))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	// Personal data must not be kept by shared caches
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d.json"`, id))
+	writeJSON(c, http.StatusOK, gin.H{
+		"data": export,
 	})
 }
 
+// GetUsers handles GET /users
+// @Summary Get all users with pagination
+// @Description Get a paginated list of all users
+// @Tags users
+// @Accept json
+// @Produce json
+// @Produce xml
+// @Param page query int false "Page number; values below 1 are treated as 1" default(1)
+// @Param page_size query int false "Page size, clamped to 1-100" default(10)
+// @Param offset query int false "Alternative to page: rows to skip, a multiple of limit"
+// @Param limit query int false "Alternative to page_size, clamped to 1-100; also the page size in cursor mode"
+// @Param updated_by query int false "Only users last modified by this user ID"
+// @Param is_active query bool false "Only active or inactive users"
+// @Param active query bool false "Alias for is_active"
+// @Param status query string false "Only users with this status" Enums(pending, active, suspended, banned)
+// @Param min_age query int false "Only users at least this old"
+// @Param max_age query int false "Only users at most this old"
+// @Param name query string false "Only users whose name contains this text, ignoring case"
+// @Param email query string false "Only the user with this email, ignoring case"
+// @Param search query string false "Only users whose name or email contains this text, ignoring case. Without sort_by, results are ranked: exact email, email prefix, name prefix, then other matches"
+// @Param q query string false "Alias for search"
+// @Param sort_by query string false "Column to order by; unknown columns fall back to id" Enums(id, name, email, age, created_at, updated_at, updated_by)
+// @Param order query string false "Sort direction (defaults to SORT_DEFAULT_ORDER)" Enums(asc, desc)
+// @Param nulls query string false "Position of NULLs for nullable columns (defaults to SORT_NULLS)" Enums(first, last)
+// @Param include_total query bool false "Include filtered_count, total_count and total_pages in meta; when false, meta reports has_next and has_prev instead" default(true)
+// @Param async_count query bool false "Return the page without counting; meta then has null counts and a count_token for GET /users/count-status" default(false)
+// @Param stream query bool false "Return every matching user as a bare JSON array in ID order, streamed in batches; pagination is ignored and sort_by is not allowed" default(false)
+// @Param cursor query int false "Switch to cursor pagination: return users after this ID (empty for the first page) with next_cursor instead of meta. Cannot be combined with page, offset, filters or sort_by"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} map[string]interface{} "Paginated users list with filtered and total counts in meta"
+// @Success 304 "Collection unchanged since the given ETag"
+// @Failure 400 {object} map[string]interface{} "Invalid filter, or a search paged past SEARCH_MAX_OFFSET"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users [get]
+func (uc *UserController) GetUsers(c *gin.Context) {
+	if _, ok := c.GetQuery("cursor"); ok {
+		uc.getUsersAfter(c)
+		return
+	}
+
+	stream, err := strconv.ParseBool(c.DefaultQuery("stream", "false"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "stream must be a boolean")
+		return
+	}
+	if stream {
+		uc.streamUserList(c)
+		return
+	}
+
+	page, pageSize, err := ParsePagination(c)
+	if err != nil {
+		invalidPagination(c, err)
+		return
+	}
+
+	filter, ok := bindFilter(c)
+	if !ok {
+		return
+	}
+	if !uc.checkSearchDepth(c, filter, page, pageSize) {
+		return
+	}
+
+	sort, err := uc.parseSort(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, err.Error())
+		return
+	}
+
+	includeTotal, err := strconv.ParseBool(c.DefaultQuery("include_total", "true"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "include_total must be a boolean")
+		return
+	}
+
+	version, err := uc.serviceFor(c).UsersVersion()
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	// The 304 skips render, so Vary must be set here too
+	c.Header("Vary", "Accept")
+	etag := version.ETag(uc.strongETags, renderFormat(c))
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	asyncCount, err := strconv.ParseBool(c.DefaultQuery("async_count", "false"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "async_count must be a boolean")
+		return
+	}
+
+	if asyncCount {
+		uc.getUsersPageAsyncCount(c, filter, sort, page, pageSize)
+		return
+	}
+	if !includeTotal {
+		uc.getUsersPage(c, filter, sort, page, pageSize)
+		return
+	}
+
+	var users []models.UserResponse
+	var filtered, total int64
+	if filter.IsEmpty() && sort.IsEmpty() {
+		users, total, err = uc.serviceFor(c).GetAllUsers(page, pageSize)
+		filtered = total
+	} else {
+		users, filtered, err = uc.serviceFor(c).SearchUsers(filter, sort, page, pageSize)
+		if err == nil && filter.IsEmpty() {
+			total = filtered
+		} else if err == nil {
+			total, err = uc.serviceFor(c).CountUsers()
+		}
+	}
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	totalPages := (int(filtered) + pageSize - 1) / pageSize
+
+	render(c, http.StatusOK, gin.H{
+		"data": users,
+		"meta": gin.H{
+			"current_page":   page,
+			"page_size":      pageSize,
+			"total_pages":    totalPages,
+			"filtered_count": filtered,
+			"total_count":    total,
+		},
+	})
+}
+
+// getUsersAfter responds with the users following the cursor, in ID order,
+// and the cursor of the next page. next_cursor is left out on the last
+// page. Filters and sorting do not apply to cursor pages.
+func (uc *UserController) getUsersAfter(c *gin.Context) {
+	afterID, limit, err := ParseCursor(c)
+	if err != nil {
+		invalidPagination(c, err)
+		return
+	}
+
+	filter, ok := bindFilter(c)
+	if !ok {
+		return
+	}
+	sort, err := uc.parseSort(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, err.Error())
+		return
+	}
+	if !filter.IsEmpty() || !sort.IsEmpty() {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "cursor cannot be combined with filters or sort_by")
+		return
+	}
+
+	users, next, err := uc.serviceFor(c).GetUsersAfter(afterID, limit)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	response := gin.H{"data": users}
+	if next != nil {
+		response["next_cursor"] = *next
+	}
+	render(c, http.StatusOK, response)
+}
+
+// streamUserList responds with every user matching the filters as a bare
+// JSON array, written batch by batch in ID order. Pagination parameters
+// are ignored and there is no meta.
+func (uc *UserController) streamUserList(c *gin.Context) {
+	filter, ok := bindFilter(c)
+	if !ok {
+		return
+	}
+	sort, err := uc.parseSort(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, err.Error())
+		return
+	}
+	if !sort.IsEmpty() {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "stream cannot be combined with sort_by")
+		return
+	}
+
+	uc.streamUsers(c, filter, exportFormats["json"], false)
+}
+
+// getUsersPage responds with a page of users and next/previous page flags
+// instead of counts, sparing the COUNT queries
+func (uc *UserController) getUsersPage(c *gin.Context, filter models.UserFilter, sort models.UserSort, page, pageSize int) {
+	users, hasNext, err := uc.serviceFor(c).GetUsersPage(filter, sort, page, pageSize)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	render(c, http.StatusOK, gin.H{
+		"data": users,
+		"meta": gin.H{
+			"current_page": page,
+			"page_size":    pageSize,
+			"has_next":     hasNext,
+			"has_prev":     page > 1,
+		},
+	})
+}
+
+// getUsersPageAsyncCount serves a page without waiting for a count, and
+// starts counting the filtered users in the background. The response has
+// null counts and a count_token to poll GET /users/count-status with.
+func (uc *UserController) getUsersPageAsyncCount(c *gin.Context, filter models.UserFilter, sort models.UserSort, page, pageSize int) {
+	users, hasNext, err := uc.serviceFor(c).GetUsersPage(filter, sort, page, pageSize)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	// The count outlives the request, so it must not be cancelled with it
+	ctx := context.WithoutCancel(c.Request.Context())
+	tenant, _ := reqctx.TenantID(ctx)
+	userService := uc.userService.WithContext(ctx)
+	token := uc.counts.start(tenant, func() (int64, error) {
+		return userService.CountFilteredUsers(filter)
+	})
+
+	render(c, http.StatusOK, gin.H{
+		"data": users,
+		"meta": gin.H{
+			"current_page":   page,
+			"page_size":      pageSize,
+			"has_next":       hasNext,
+			"has_prev":       page > 1,
+			"total_pages":    nil,
+			"filtered_count": nil,
+			"count_token":    token,
+		},
+	})
+}
+
+// GetCountStatus handles GET /users/count-status
+// @Summary Get a background count
+// @Description Poll the filtered count started by GET /users?async_count=true. The status is pending until the count finishes, then ready with filtered_count, or failed. Tokens expire 10 minutes after the count finishes.
+// @Tags users
+// @Produce json
+// @Param token query string true "count_token from the list response"
+// @Success 200 {object} map[string]interface{} "Count status"
+// @Failure 400 {object} map[string]interface{} "Missing token"
+// @Failure 404 {object} map[string]interface{} "Unknown or expired token"
+// @Router /users/count-status [get]
+func (uc *UserController) GetCountStatus(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "token is required")
+		return
+	}
+
+	tenant, _ := reqctx.TenantID(c.Request.Context())
+	job, ok := uc.counts.get(token, tenant)
+	if !ok {
+		writeError(c, http.StatusNotFound, models.CodeNotFound, "Unknown or expired count token")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	switch {
+	case !job.done:
+		writeJSON(c, http.StatusOK, gin.H{"status": "pending"})
+	case job.err != nil:
+		writeJSON(c, http.StatusOK, gin.H{"status": "failed", "error": "failed to count users"})
+	default:
+		writeJSON(c, http.StatusOK, gin.H{"status": "ready", "filtered_count": job.count})
+	}
+}
+
+// ExportUsers handles GET /users/export
+// @Summary Export users
+// @Description Download every user matching the list filters as CSV (in the import column layout) or as a JSON array. Users are streamed in batches, so large exports are not held in memory. Clients sending Accept-Encoding: gzip get the stream gzip-compressed with Content-Encoding: gzip. Requires an admin bearer token.
+// @Tags users
+// @Produce text/csv
+// @Produce json
+// @Param format query string false "File format" Enums(csv, json) default(csv)
+// @Param Authorization header string true "Bearer token with the admin role"
+// @Param Accept-Encoding header string false "gzip to compress the download"
+// @Param updated_by query int false "Only users last modified by this user ID"
+// @Param is_active query bool false "Only active or inactive users"
+// @Param active query bool false "Alias for is_active"
+// @Param status query string false "Only users with this status" Enums(pending, active, suspended, banned)
+// @Param min_age query int false "Only users at least this old"
+// @Param max_age query int false "Only users at most this old"
+// @Param name query string false "Only users whose name contains this text, ignoring case"
+// @Param email query string false "Only the user with this email, ignoring case"
+// @Param search query string false "Only users whose name or email contains this text, ignoring case"
+// @Param q query string false "Alias for search"
+// @Success 200 {file} file "Exported users"
+// @Failure 400 {object} map[string]interface{} "Invalid filter or format"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Caller is not an admin"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/export [get]
+func (uc *UserController) ExportUsers(c *gin.Context) {
+	name := strings.ToLower(c.DefaultQuery("format", "csv"))
+	format, ok := exportFormats[name]
+	if !ok {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "format must be csv or json")
+		return
+	}
+
+	filter, ok := bindFilter(c)
+	if !ok {
+		return
+	}
+
+	uc.streamUsers(c, filter, format, true)
+}
+
+// streamUsers writes every user matching the filter in the given format,
+// one batch at a time, gzip-compressed for clients that accept it. As an
+// attachment the response is named for download.
+func (uc *UserController) streamUsers(c *gin.Context, filter models.UserFilter, format exportFormat, attachment bool) {
+	// Compress on the fly for clients that accept it; nothing is written
+	// to the response until the first batch
+	var out io.Writer = c.Writer
+	var compressed *gzip.Writer
+	c.Header("Vary", "Accept-Encoding")
+	if acceptsGzip(c.GetHeader("Accept-Encoding")) {
+		compressed = gzip.NewWriter(c.Writer)
+		out = compressed
+	}
+
+	exporter := format.newExporter(out)
+	started := false
+	begin := func() error {
+		started = true
+		if attachment {
+			c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="users.%s"`, format.extension))
+		}
+		c.Header("Content-Type", format.contentType)
+		if compressed != nil {
+			c.Header("Content-Encoding", "gzip")
+		}
+		c.Status(http.StatusOK)
+		return exporter.Begin()
+	}
+
+	// The response starts with the first batch, so a query that fails
+	// outright still gets a proper error response
+	err := uc.serviceFor(c).ExportUsers(filter, func(users []models.UserResponse) error {
+		if !started {
+			if err := begin(); err != nil {
+				return err
+			}
+		}
+		return exporter.Write(users)
+	})
+	if err != nil && !started {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+	if err == nil && !started {
+		err = begin()
+	}
+	if err == nil {
+		err = exporter.End()
+	}
+	if err == nil && compressed != nil {
+		err = compressed.Close()
+	}
+	if err != nil {
+		// Headers are already sent; record the error for the request log
+		// and leave the response truncated (a gzip stream is left
+		// unterminated, so clients notice)
+		_ = c.Error(err)
+	}
+}
+
+// UpdateUser handles PUT /users/:id
+// @Summary Update user by ID
+// @Description Update a user's information by their ID. The response lists the fields that actually changed in `changed`; a request matching the stored values writes nothing and returns `changed: []`.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param user body models.UserRequest true "Updated user data"
+// @Success 200 {object} map[string]interface{} "User updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 409 {object} map[string]interface{} "Email already exists"
+// @Failure 403 {object} map[string]interface{} "Change to an admin-only field by a non-admin"
+// @Router /users/{id} [put]
+func (uc *UserController) UpdateUser(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		invalidUserID(c)
+		return
+	}
+
+	var req models.UserRequest
+	if !uc.bindUserRequest(c, &req) {
+		return
+	}
+
+	user, changed, err := uc.serviceFor(c).UpdateUser(uint(id), req)
+	if err != nil {
+		respondError(c, err, http.StatusBadRequest, conflictFields(err), forbiddenField(err), validationFields(err))
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"message": "User updated successfully",
+		"data":    user,
+		"changed": changed,
+	})
+}
+
+// ResetUser handles POST /users/:id/reset
+// @Summary Reset a user to defaults
+// @Description Clear the user's phone and address and reactivate the account, keeping name, email and age. Requires an admin bearer token.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param Authorization header string true "Bearer token with the admin role"
+// @Success 200 {object} map[string]interface{} "User reset successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID, or a cleared field is immutable"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Caller is not an admin"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Router /users/{id}/reset [post]
+func (uc *UserController) ResetUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		invalidUserID(c)
+		return
+	}
+
+	user, err := uc.serviceFor(c).ResetUser(uint(id))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError, forbiddenField(err))
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"message": "User reset successfully",
+		"data":    user,
+	})
+}
+
+// RequestDeletion handles POST /users/:id/delete-request
+// @Summary Schedule account deletion
+// @Description Mark the account for deletion after the grace period (ACCOUNT_DELETION_GRACE) instead of deleting it now. The date is returned in deletion_scheduled_at; repeating the request keeps it. Only the user themselves or an admin may call it.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param Authorization header string true "Bearer token of the user or an admin"
+// @Success 200 {object} map[string]interface{} "Deletion scheduled"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Caller is neither the user nor an admin"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Router /users/{id}/delete-request [post]
+func (uc *UserController) RequestDeletion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		invalidUserID(c)
+		return
+	}
+
+	user, err := uc.serviceFor(c).ScheduleDeletion(uint(id))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"message": "Account deletion scheduled",
+		"data":    user,
+	})
+}
+
+// CancelDeletion handles POST /users/:id/cancel-deletion
+// @Summary Cancel a scheduled account deletion
+// @Description Abort a pending deletion request before it takes effect. Only the user themselves or an admin may call it.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param Authorization header string true "Bearer token of the user or an admin"
+// @Success 200 {object} map[string]interface{} "Deletion cancelled"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Caller is neither the user nor an admin"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Router /users/{id}/cancel-deletion [post]
+func (uc *UserController) CancelDeletion(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		invalidUserID(c)
+		return
+	}
+
+	user, err := uc.serviceFor(c).CancelDeletion(uint(id))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"message": "Account deletion cancelled",
+		"data":    user,
+	})
+}
+
+// SetUserStatus handles PUT /users/:id/status
+// @Summary Change a user's status
+// @Description Move a user to pending, active, suspended or banned. Allowed changes: pending to any other status; active to suspended or banned; suspended to active or banned; banned to active or suspended. Nothing returns to pending.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body models.StatusRequest true "New status"
+// @Success 200 {object} map[string]interface{} "Status updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid status"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 409 {object} map[string]interface{} "Transition not allowed"
+// @Failure 403 {object} map[string]interface{} "Change to an admin-only field by a non-admin"
+// @Router /users/{id}/status [put]
+func (uc *UserController) SetUserStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		invalidUserID(c)
+		return
+	}
+
+	var req models.StatusRequest
+	if err := uc.bindJSON(c, &req); err != nil {
+		invalidRequestBody(c, err)
+		return
+	}
+
+	status, err := models.ParseUserStatus(req.Status)
+	if err != nil {
+		respondError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := uc.serviceFor(c).SetUserStatus(uint(id), status)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError, forbiddenField(err))
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"message": "Status updated successfully",
+		"data":    user,
+	})
+}
+
+// DeleteUser handles DELETE /users/:id
+// @Summary Delete user by ID
+// @Description Soft delete a user by their ID
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "User deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/{id} [delete]
+func (uc *UserController) DeleteUser(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		invalidUserID(c)
+		return
+	}
+
+	err = uc.serviceFor(c).DeleteUser(uint(id))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"message": "User deleted successfully",
+	})
+}
+
+// RestoreUser handles POST /users/:id/restore
+// @Summary Restore a deleted user
+// @Description Undo the soft delete of a user. Fails with 409 when the user is not deleted, or when another user has taken their email since.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "User restored successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 409 {object} map[string]interface{} "User is not deleted, or their email is taken"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/{id}/restore [post]
+func (uc *UserController) RestoreUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		invalidUserID(c)
+		return
+	}
+
+	user, err := uc.serviceFor(c).RestoreUser(uint(id))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"message": "User restored successfully",
+		"data":    user,
+	})
+}
+
+// BulkCreateUsers handles POST /users/bulk
+// @Summary Create users in bulk
+// @Description Create multiple users in a single transaction. Every user must meet MIN_REGISTRATION_AGE and, under AGE_REQUIRED, give an age.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param users body models.BulkCreateRequest true "Users to create"
+// @Success 201 {object} map[string]interface{} "Users created successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 409 {object} map[string]interface{} "Email already exists"
+// @Router /users/bulk [post]
+func (uc *UserController) BulkCreateUsers(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		invalidRequestBody(c, err)
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	var req models.BulkCreateRequest
+	if err := uc.bindJSON(c, &req); err != nil {
+		invalidRequestBody(c, err)
+		return
+	}
+
+	if !uc.checkBulkSize(c, len(req.Users)) {
+		return
+	}
+
+	var fields struct {
+		Users []map[string]json.RawMessage `json:"users"`
+	}
+	if json.Unmarshal(body, &fields) == nil {
+		for i, item := range fields.Users {
+			if i < len(req.Users) {
+				_, given := item["age"]
+				req.Users[i].AgeOmitted = !given
+			}
+		}
+	}
+
+	users, err := uc.serviceFor(c).BulkCreateUsers(req.Users)
+	if err != nil {
+		respondError(c, err, http.StatusBadRequest, conflictFields(err), validationFields(err), minimumAgeFields(err))
+		return
+	}
+
+	writeJSON(c, http.StatusCreated, gin.H{
+		"message": "Users created successfully",
+		"data":    users,
+	})
+}
+
+// ValidateUsers handles POST /users/validate
+// @Summary Validate users without saving
+// @Description Check one user object or an array of them against the same field rules as POST /users, reporting each by index. Nothing is written and email uniqueness is not checked.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param users body []models.UserRequest true "User or users to validate"
+// @Success 200 {object} map[string]interface{} "Per-item validation results"
+// @Failure 400 {object} map[string]interface{} "Malformed body or too many items"
+// @Router /users/validate [post]
+func (uc *UserController) ValidateUsers(c *gin.Context) {
+	reqs, missingAge, err := uc.bindUserRequests(c)
+	if err != nil {
+		invalidRequestBody(c, err)
+		return
+	}
+
+	if !uc.checkBulkSize(c, len(reqs)) {
+		return
+	}
+
+	results := make([]models.ValidationResult, len(reqs))
+	invalid := 0
+	for i, req := range reqs {
+		fields := map[string]string{}
+		if err := models.Validate(req); err != nil {
+			var validation *models.ValidationError
+			if !errors.As(err, &validation) {
+				respondError(c, err, http.StatusInternalServerError)
+				return
+			}
+			for field, problem := range validation.Fields {
+				fields[field] = problem
+			}
+		}
+		if uc.ageRequired && missingAge[i] {
+			fields["age"] = "is required"
+		}
+		if _, failed := fields["age"]; !failed && req.CurrentAge(time.Now()) < uc.minAge {
+			fields["age"] = fmt.Sprintf("must be at least %d to register", uc.minAge)
+		}
+
+		results[i] = models.ValidationResult{Index: i, Valid: len(fields) == 0}
+		if !results[i].Valid {
+			results[i].Fields = fields
+			invalid++
+		}
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"data": results,
+		"meta": gin.H{
+			"total":   len(reqs),
+			"valid":   len(reqs) - invalid,
+			"invalid": invalid,
+		},
+	})
+}
+
+// BulkUpdateUsers handles PUT /users/bulk
+// @Summary Update users in bulk
+// @Description Update multiple users in a single transaction
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param users body models.BulkUpdateRequest true "Users to update"
+// @Success 200 {object} map[string]interface{} "Users updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 409 {object} map[string]interface{} "Email already exists"
+// @Failure 403 {object} map[string]interface{} "Change to an admin-only field by a non-admin"
+// @Router /users/bulk [put]
+func (uc *UserController) BulkUpdateUsers(c *gin.Context) {
+	var req models.BulkUpdateRequest
+	if err := uc.bindJSON(c, &req); err != nil {
+		invalidRequestBody(c, err)
+		return
+	}
+
+	if !uc.checkBulkSize(c, len(req.Users)) {
+		return
+	}
+
+	users, err := uc.serviceFor(c).BulkUpdateUsers(req.Users)
+	if err != nil {
+		respondError(c, err, http.StatusBadRequest, conflictFields(err), forbiddenField(err))
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"message": "Users updated successfully",
+		"data":    users,
+	})
+}
+
+// BulkDeleteUsers handles DELETE /users
+// @Summary Delete users in bulk
+// @Description Soft delete multiple users by their IDs, recording an audit entry for each in the same transaction
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param ids body models.BulkDeleteRequest true "IDs of users to delete"
+// @Success 200 {object} map[string]interface{} "Users deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users [delete]
+func (uc *UserController) BulkDeleteUsers(c *gin.Context) {
+	var req models.BulkDeleteRequest
+	if err := uc.bindJSON(c, &req); err != nil {
+		invalidRequestBody(c, err)
+		return
+	}
+
+	if !uc.checkBulkSize(c, len(req.IDs)) {
+		return
+	}
+
+	deleted, err := uc.serviceFor(c).BulkDeleteUsers(models.UintIDs(req.IDs))
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"message": "Users deleted successfully",
+		"deleted": deleted,
+	})
+}
+
+// DeleteInactiveUsers handles DELETE /users/inactive
+// @Summary Delete inactive users
+// @Description Soft delete all inactive users last updated before the cutoff. Requires confirm=true and an admin bearer token.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param before query string true "Cutoff timestamp (RFC3339)"
+// @Param confirm query bool true "Must be true to perform the deletion"
+// @Param Authorization header string true "Bearer token with the admin role"
+// @Success 200 {object} map[string]interface{} "Inactive users deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Caller is not an admin"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/inactive [delete]
+func (uc *UserController) DeleteInactiveUsers(c *gin.Context) {
+	if c.Query("confirm") != "true" {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "Confirmation required: set confirm=true to delete inactive users")
+		return
+	}
+
+	before, err := time.Parse(time.RFC3339, c.Query("before"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid or missing before parameter, expected RFC3339 timestamp")
+		return
+	}
+
+	deleted, err := uc.serviceFor(c).DeleteInactiveUsers(before)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError, gin.H{"deleted": deleted})
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"message": "Inactive users deleted successfully",
+		"deleted": deleted,
+	})
+}
+
+// BulkSetActive handles POST /users/bulk-set-active
+// @Summary Activate or deactivate users by filter
+// @Description Set is_active for every user matching the filter in a single update. The filter must not be empty.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body models.BulkSetActiveRequest true "Target state and filter"
+// @Success 200 {object} map[string]interface{} "Users updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 403 {object} map[string]interface{} "Change to an admin-only field by a non-admin"
+// @Router /users/bulk-set-active [post]
+func (uc *UserController) BulkSetActive(c *gin.Context) {
+	var req models.BulkSetActiveRequest
+	if err := uc.bindJSON(c, &req); err != nil {
+		invalidRequestBody(c, err)
+		return
+	}
+
+	if req.Active == nil {
+		writeError(c, http.StatusBadRequest, models.CodeValidationFailed, "active is required")
+		return
+	}
+
+	if req.Filter.IsEmpty() {
+		writeError(c, http.StatusBadRequest, models.CodeValidationFailed, "filter must contain at least one criterion")
+		return
+	}
+
+	updated, err := uc.serviceFor(c).BulkSetActive(req.Filter, *req.Active)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError, forbiddenField(err))
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"message": "Users updated successfully",
+		"updated": updated,
+	})
+}
+
+// BulkSetRole handles POST /users/bulk-role
+// @Summary Assign a role to users by filter
+// @Description Set the role of every user matching the filter in a single update. The filter must not be empty, and when it matches more users than BULK_ROLE_CONFIRM_THRESHOLD the request must set confirm=true. Admin only.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token with the admin role"
+// @Param request body models.BulkRoleRequest true "Role and filter"
+// @Param confirm query bool false "Must be true when the filter matches more users than the threshold"
+// @Success 200 {object} map[string]interface{} "Users updated successfully"
+// @Failure 400 {object} map[string]interface{} "Bad request, confirmation required, or role is immutable"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Caller is not an admin"
+// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Router /users/bulk-role [post]
+func (uc *UserController) BulkSetRole(c *gin.Context) {
+	var req models.BulkRoleRequest
+	if err := uc.bindJSON(c, &req); err != nil {
+		invalidRequestBody(c, err)
+		return
+	}
+
+	role, err := models.ParseRole(req.Role)
+	if err != nil {
+		respondError(c, err, http.StatusBadRequest)
+		return
+	}
+
+	if req.Filter.IsEmpty() {
+		writeError(c, http.StatusBadRequest, models.CodeValidationFailed, "filter must contain at least one criterion")
+		return
+	}
+
+	// Without confirmation the update is rolled back when it touches more
+	// users than the threshold
+	maxAffected := uc.roleConfirm
+	if c.Query("confirm") == "true" {
+		maxAffected = 0
+	}
+
+	updated, err := uc.serviceFor(c).BulkSetRole(req.Filter, role, maxAffected)
+	if err != nil {
+		respondError(c, err, http.StatusInternalServerError, forbiddenField(err), confirmationFields(err))
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"message": "Users updated successfully",
+		"updated": updated,
+	})
+}
+
+// ImportUsers handles POST /users/import
+// @Summary Import users from CSV
+// @Description Create users from a CSV file with a header row (name, email, age, phone, address, is_active, created_at). Created users must meet MIN_REGISTRATION_AGE. Rows whose email already exists are handled according to duplicate_strategy. created_at (RFC 3339) is only kept for admin callers when ALLOW_TIMESTAMP_OVERRIDE is on.
+// @Param Authorization header string false "Bearer token; an admin token lets created_at be kept"
+// @Tags users
+// @Accept text/csv,multipart/form-data
+// @Produce json
+// @Param file formData file false "CSV file (multipart upload); otherwise the request body is read as CSV"
+// @Param duplicate_strategy query string false "What to do with existing emails" Enums(skip, update, error) default(skip)
+// @Success 200 {object} map[string]interface{} "Import summary"
+// @Failure 400 {object} map[string]interface{} "Bad request"
+// @Failure 409 {object} map[string]interface{} "Email already exists"
+// @Router /users/import [post]
+func (uc *UserController) ImportUsers(c *gin.Context) {
+	strategy, err := models.ParseDuplicateStrategy(c.Query("duplicate_strategy"))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, err.Error())
+		return
+	}
+
+	body := c.Request.Body
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			writeError(c, http.StatusBadRequest, models.CodeInvalidRequestBody, "Invalid upload", gin.H{
+				"details": err.Error(),
+			})
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	reqs, err := parseUsersCSV(body)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidRequestBody, "Invalid CSV", gin.H{
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if !uc.checkBulkSize(c, len(reqs)) {
+		return
+	}
+
+	result, err := uc.serviceFor(c).ImportUsers(reqs, strategy)
+	if err != nil {
+		respondError(c, err, http.StatusBadRequest, conflictFields(err), forbiddenField(err), minimumAgeFields(err))
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{
+		"message": "Users imported successfully",
+		"data":    result,
+	})
+}
+
+// bindFilter reads the list filter from the query string, accepting active
+// as an alias for is_active. It responds with 400 and returns false when a
+// criterion is malformed.
+func bindFilter(c *gin.Context) (models.UserFilter, bool) {
+	var filter models.UserFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "Invalid filter", gin.H{
+			"details": err.Error(),
+		})
+		return filter, false
+	}
+
+	if value := c.Query("active"); value != "" && filter.IsActive == nil {
+		active, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "active must be a boolean")
+			return filter, false
+		}
+		filter.IsActive = &active
+	}
+
+	if filter.Search == "" {
+		filter.Search = c.Query("q")
+	}
+
+	if filter.Status != "" {
+		status, err := models.ParseUserStatus(string(filter.Status))
+		if err != nil {
+			writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, err.Error())
+			return filter, false
+		}
+		filter.Status = status
+	}
+
+	if filter.MinAge != nil && filter.MaxAge != nil && *filter.MinAge > *filter.MaxAge {
+		writeError(c, http.StatusBadRequest, models.CodeInvalidParameter, "min_age must not be greater than max_age")
+		return filter, false
+	}
+
+	return filter, true
+}
+
+// parseSort reads the sort_by, order and nulls query parameters, applying
+// the configured defaults. Unknown sort columns fall back to id.
+func (uc *UserController) parseSort(c *gin.Context) (models.UserSort, error) {
+	column := c.Query("sort_by")
+	if column == "" {
+		return models.UserSort{}, nil
+	}
+	if !models.IsSortableColumn(column) {
+		column = "id"
+	}
+
+	sort := models.UserSort{Column: column, Descending: uc.sortDesc, NullsFirst: uc.nullsFirst}
+	if order := c.Query("order"); order != "" {
+		desc, err := parseSortOrder(order)
+		if err != nil {
+			return sort, err
+		}
+		sort.Descending = desc
+	}
+	if nulls := c.Query("nulls"); nulls != "" {
+		first, err := parseNullsOrder(nulls)
+		if err != nil {
+			return sort, err
+		}
+		sort.NullsFirst = first
+	}
+	return sort, nil
+}
+
+// parseSortOrder reports whether order requests descending order
+func parseSortOrder(order string) (bool, error) {
+	switch strings.ToLower(order) {
+	case "asc":
+		return false, nil
+	case "desc":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid order %q, expected asc or desc", order)
+	}
+}
+
+// parseNullsOrder reports whether nulls requests NULLs before other values
+func parseNullsOrder(nulls string) (bool, error) {
+	switch strings.ToLower(nulls) {
+	case "first":
+		return true, nil
+	case "last":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid nulls %q, expected first or last", nulls)
+	}
+}
+
+// checkBulkSize rejects empty bulk requests and those over the configured
+// limit, writing the 400 response itself
+func (uc *UserController) checkBulkSize(c *gin.Context, size int) bool {
+	if size == 0 {
+		writeError(c, http.StatusBadRequest, models.CodeValidationFailed, "Bulk request must contain at least one item")
+		return false
+	}
+
+	if size > uc.maxBulkSize {
+		writeError(c, http.StatusBadRequest, models.CodeBulkLimitExceeded,
+			fmt.Sprintf("Bulk request exceeds the maximum of %d items", uc.maxBulkSize),
+			gin.H{"max_bulk_size": uc.maxBulkSize})
+		return false
+	}
+
+	return true
+}
+
+// checkSearchDepth writes an error and returns false when a text search
+// asks for a page past the configured maximum offset
+func (uc *UserController) checkSearchDepth(c *gin.Context, filter models.UserFilter, page, pageSize int) bool {
+	if strings.TrimSpace(filter.Search) == "" || page*pageSize <= uc.maxSearch {
+		return true
+	}
+
+	writeError(c, http.StatusBadRequest, models.CodeSearchTooDeep,
+		fmt.Sprintf("Search results are limited to the first %d matches; narrow the search or add filters instead of paging further", uc.maxSearch),
+		gin.H{"max_offset": uc.maxSearch})
+	return false
+}
+
+// Root returns the handler for GET /, which describes the service. The docs
+// link is omitted when docsPath is empty.
+// @Summary Service information
+// @Description Get the service name, version and a link to the API documentation
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Service information"
+// @Router / [get]
+func (uc *UserController) Root(docsPath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body := gin.H{
+			"service": uc.serviceName,
+			"version": uc.version,
+		}
+		if docsPath != "" {
+			body["docs"] = docsPath
+		}
+		writeJSON(c, http.StatusOK, body)
+	}
+}
+
 // HealthCheck handles GET /health
 // @Summary Health check endpoint
 // @Description Check if the API is running and healthy
 // @Tags health
 // @Accept json
 // @Produce json
+// @Produce xml
 // @Success 200 {object} map[string]interface{} "API is healthy"
 // @Router /health [get]
 func (uc *UserController) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
+	render(c, http.StatusOK, gin.H{
 		"status":    "healthy",
 		"timestamp": time.Now().Unix(),
 	})
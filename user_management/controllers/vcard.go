@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/gin-gonic/gin"
+)
+
+// vCardExtension selects the vCard representation when appended to a user
+// ID in the path (/users/5.vcf)
+const vCardExtension = ".vcf"
+
+const vCardContentType = "text/vcard; charset=utf-8"
+
+// acceptsVCard reports whether the Accept header asks for a vCard
+func acceptsVCard(c *gin.Context) bool {
+	for _, part := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && (mediaType == "text/vcard" || mediaType == "text/x-vcard") {
+			return true
+		}
+	}
+	return false
+}
+
+// renderVCard writes the user as a downloadable vCard
+func renderVCard(c *gin.Context, user *models.UserResponse) {
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d.vcf"`, user.ID))
+	c.Data(http.StatusOK, vCardContentType, []byte(user.ToVCard()))
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/IntouchOpec/user_management/models"
+	"gorm.io/gorm"
+)
+
+// RegisterAvailabilityCheck installs GORM callbacks that wrap errors caused
+// by an unreachable database in models.ErrDatabaseUnavailable, so callers
+// can tell an outage apart from a failed query
+func RegisterAvailabilityCheck(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().After("*").Register("availability:check", markUnavailable); err != nil {
+		return err
+	}
+	if err := cb.Query().After("*").Register("availability:check", markUnavailable); err != nil {
+		return err
+	}
+	if err := cb.Update().After("*").Register("availability:check", markUnavailable); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("*").Register("availability:check", markUnavailable); err != nil {
+		return err
+	}
+	if err := cb.Row().After("*").Register("availability:check", markUnavailable); err != nil {
+		return err
+	}
+	return cb.Raw().After("*").Register("availability:check", markUnavailable)
+}
+
+func markUnavailable(db *gorm.DB) {
+	if db.Error == nil || errors.Is(db.Error, models.ErrDatabaseUnavailable) || !isConnectionError(db.Error) {
+		return
+	}
+	db.Error = fmt.Errorf("%w: %w", models.ErrDatabaseUnavailable, db.Error)
+}
+
+// isConnectionError reports whether err means the database could not be
+// reached, as opposed to rejecting the statement
+func isConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	// database/sql does not export its closed-pool error
+	return strings.Contains(err.Error(), "sql: database is closed")
+}
@@ -1,11 +1,14 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 
 	"github.com/IntouchOpec/user_management/config"
 	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -13,17 +16,43 @@ import (
 
 var DB *gorm.DB
 
+// logLevels maps the configured DB_LOG_LEVEL names to GORM log levels
+var logLevels = map[string]logger.LogLevel{
+	"silent": logger.Silent,
+	"error":  logger.Error,
+	"warn":   logger.Warn,
+	"info":   logger.Info,
+}
+
+// GormConfig builds the GORM configuration ConnectDatabase opens the
+// database with. An empty or unknown log level logs every statement.
+func GormConfig(cfg *config.Config) *gorm.Config {
+	level, ok := logLevels[cfg.Database.LogLevel]
+	if !ok {
+		level = logger.Info
+	}
+	return &gorm.Config{
+		Logger: logger.Default.LogMode(level),
+	}
+}
+
 // ConnectDatabase initializes the database connection
 func ConnectDatabase(cfg *config.Config) error {
 	dsn := cfg.Database.GetDSN()
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	})
+	db, err := gorm.Open(postgres.Open(dsn), GormConfig(cfg))
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %v", err)
 	}
 
+	if err := RegisterTenantScope(db); err != nil {
+		return fmt.Errorf("failed to register tenant scope: %v", err)
+	}
+
+	if err := RegisterAvailabilityCheck(db); err != nil {
+		return fmt.Errorf("failed to register availability check: %v", err)
+	}
+
 	// Configure connection pool
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -42,26 +71,134 @@ func ConnectDatabase(cfg *config.Config) error {
 	return nil
 }
 
+// migrateOptions holds the schema choices applied by MigrateDatabase
+type migrateOptions struct {
+	tenantScopedEmails bool
+}
+
+// MigrateOption configures MigrateDatabase
+type MigrateOption func(*migrateOptions)
+
+// WithTenantScopedEmails makes emails unique per tenant instead of across
+// all tenants, so each tenant may register the same address once
+func WithTenantScopedEmails(enabled bool) MigrateOption {
+	return func(o *migrateOptions) {
+		o.tenantScopedEmails = enabled
+	}
+}
+
 // MigrateDatabase runs database migrations
-func MigrateDatabase() error {
+func MigrateDatabase(opts ...MigrateOption) error {
 	if DB == nil {
 		return fmt.Errorf("database not connected")
 	}
 
-	err := DB.AutoMigrate(&models.User{})
+	var options migrateOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	hadStatus := DB.Migrator().HasTable(&models.User{}) && DB.Migrator().HasColumn(&models.User{}, "status")
+
+	err := DB.AutoMigrate(&models.User{}, &models.UserEmailHistory{}, &models.AuditEntry{})
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %v", err)
 	}
 
+	if !hadStatus {
+		if err := backfillStatus(DB); err != nil {
+			return fmt.Errorf("failed to backfill user status: %v", err)
+		}
+	}
+
+	if err := lowercaseEmails(DB); err != nil {
+		return fmt.Errorf("failed to lowercase emails: %v", err)
+	}
+
+	if err := migrateEmailIndex(DB, options.tenantScopedEmails); err != nil {
+		return fmt.Errorf("failed to migrate email index: %v", err)
+	}
+
 	log.Println("Database migrated successfully")
 	return nil
 }
 
+// migrateEmailIndex replaces the plain unique index on email with a
+// functional unique index on LOWER(email), so addresses differing only
+// by case are rejected as duplicates. The index is partial, covering only
+// rows that are not soft deleted, so a deleted user's address can be
+// reused. With tenantScoped the index also covers tenant_id, making
+// emails unique within each tenant; switching modes swaps one index for
+// the other.
+func migrateEmailIndex(db *gorm.DB, tenantScoped bool) error {
+	// Earlier versions of these indexes covered soft-deleted rows too
+	drop := []string{"idx_users_email", "idx_users_email_lower", "idx_users_tenant_email_lower"}
+	create := "CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower_active ON users (LOWER(email)) WHERE deleted_at IS NULL"
+	if tenantScoped {
+		drop = append(drop, "idx_users_email_lower_active")
+		create = "CREATE UNIQUE INDEX IF NOT EXISTS idx_users_tenant_email_lower_active ON users (COALESCE(tenant_id, ''), LOWER(email)) WHERE deleted_at IS NULL"
+	} else {
+		drop = append(drop, "idx_users_tenant_email_lower_active")
+	}
+
+	for _, index := range drop {
+		if err := db.Exec("DROP INDEX IF EXISTS " + index).Error; err != nil {
+			return err
+		}
+	}
+	return db.Exec(create).Error
+}
+
+// lowercaseEmails rewrites emails stored before writes normalized them into
+// the canonical lowercased form. The email index already compares
+// LOWER(email), so this cannot create duplicates, and rows that are
+// already lowercase are left untouched.
+func lowercaseEmails(db *gorm.DB) error {
+	return db.Unscoped().Model(&models.User{}).
+		Where("email <> LOWER(email)").
+		UpdateColumn("email", gorm.Expr("LOWER(email)")).Error
+}
+
+// backfillStatus derives the status of users that predate the status
+// column from is_active, which the column's default treated as active
+func backfillStatus(db *gorm.DB) error {
+	return db.Unscoped().Model(&models.User{}).
+		Where("is_active = ?", false).
+		UpdateColumn("status", models.StatusSuspended).Error
+}
+
+// Transaction runs fn with a user repository bound to a single database
+// transaction. The transaction commits when fn returns nil and rolls back
+// when it returns an error or panics.
+func Transaction(fn func(txRepo repository.UserRepository) error) error {
+	if DB == nil {
+		return fmt.Errorf("database not connected")
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return fn(repository.NewUserRepository(tx))
+	})
+}
+
 // GetDB returns the database instance
 func GetDB() *gorm.DB {
 	return DB
 }
 
+// Ping checks that the database is reachable
+func Ping(ctx context.Context) error {
+	if DB == nil {
+		return errors.New("database is not connected")
+	}
+
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.PingContext(ctx)
+}
+
 // CloseDatabase closes the database connection
 func CloseDatabase() error {
 	if DB == nil {
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/IntouchOpec/user_management/models"
+)
+
+// Column describes a table column as reported by the database driver.
+// Properties the driver cannot report are omitted.
+type Column struct {
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	Length     *int64  `json:"length,omitempty"`
+	Nullable   *bool   `json:"nullable,omitempty"`
+	PrimaryKey *bool   `json:"primary_key,omitempty"`
+	Unique     *bool   `json:"unique,omitempty"`
+	Default    *string `json:"default,omitempty"`
+}
+
+// UserColumns returns the columns of the users table as they exist in the
+// database, which may differ from the model when migrations have drifted
+func UserColumns() ([]Column, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	columnTypes, err := DB.Migrator().ColumnTypes(&models.User{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read users columns: %w", err)
+	}
+
+	columns := make([]Column, 0, len(columnTypes))
+	for _, ct := range columnTypes {
+		column := Column{Name: ct.Name(), Type: ct.DatabaseTypeName()}
+		if full, ok := ct.ColumnType(); ok && full != "" {
+			column.Type = full
+		}
+		if length, ok := ct.Length(); ok {
+			column.Length = &length
+		}
+		if nullable, ok := ct.Nullable(); ok {
+			column.Nullable = &nullable
+		}
+		if primaryKey, ok := ct.PrimaryKey(); ok {
+			column.PrimaryKey = &primaryKey
+		}
+		if unique, ok := ct.Unique(); ok {
+			column.Unique = &unique
+		}
+		if value, ok := ct.DefaultValue(); ok {
+			column.Default = &value
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// DuplicateEmail is an address shared by more than one users row, counting
+// soft-deleted rows
+type DuplicateEmail struct {
+	Email   string `json:"email"`
+	Count   int64  `json:"count"`
+	Active  int64  `json:"active"`
+	Deleted int64  `json:"deleted"`
+}
+
+// DuplicateEmails reports the emails, compared ignoring case, that appear
+// on more than one users row across active and soft-deleted users. Such
+// rows block stricter unique constraints and must be resolved first.
+func DuplicateEmails() ([]DuplicateEmail, error) {
+	if DB == nil {
+		return nil, fmt.Errorf("database not connected")
+	}
+
+	duplicates := []DuplicateEmail{}
+	err := DB.Unscoped().Model(&models.User{}).
+		Select("LOWER(email) AS email, COUNT(*) AS count, " +
+			"SUM(CASE WHEN deleted_at IS NULL THEN 1 ELSE 0 END) AS active, " +
+			"SUM(CASE WHEN deleted_at IS NULL THEN 0 ELSE 1 END) AS deleted").
+		Group("LOWER(email)").
+		Having("COUNT(*) > 1").
+		Order("email").
+		Scan(&duplicates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicate emails: %w", err)
+	}
+	return duplicates, nil
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"reflect"
+
+	"github.com/IntouchOpec/user_management/reqctx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// tenantColumn is the column that partitions rows between tenants
+const tenantColumn = "tenant_id"
+
+// RegisterTenantScope installs GORM callbacks that scope every query,
+// update and delete on tenant-aware models to the tenant stored in the
+// statement context, and stamp that tenant on newly created rows.
+// Statements without a tenant in their context are left unscoped.
+func RegisterTenantScope(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("tenant:assign", assignTenant); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	return cb.Row().Before("gorm:row").Register("tenant:scope", scopeTenant)
+}
+
+// tenantField returns the tenant field and tenant ID for the statement,
+// or nil when the model is not tenant-aware or no tenant is set
+func tenantField(db *gorm.DB) (*schema.Field, string) {
+	if db.Statement.Schema == nil {
+		return nil, ""
+	}
+	field := db.Statement.Schema.LookUpField(tenantColumn)
+	if field == nil {
+		return nil, ""
+	}
+	tenantID, ok := reqctx.TenantID(db.Statement.Context)
+	if !ok {
+		return nil, ""
+	}
+	return field, tenantID
+}
+
+// scopeTenant restricts the statement to rows owned by the current tenant
+func scopeTenant(db *gorm.DB) {
+	field, tenantID := tenantField(db)
+	if field == nil {
+		return
+	}
+
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantID},
+	}})
+}
+
+// assignTenant sets the current tenant on every record being created,
+// overriding any value supplied by the caller
+func assignTenant(db *gorm.DB) {
+	field, tenantID := tenantField(db)
+	if field == nil {
+		return
+	}
+
+	ctx := db.Statement.Context
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := field.Set(ctx, reflect.Indirect(rv.Index(i)), tenantID); err != nil {
+				db.AddError(err)
+				return
+			}
+		}
+	case reflect.Struct:
+		if err := field.Set(ctx, rv, tenantID); err != nil {
+			db.AddError(err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/IntouchOpec/user_management/repository"
+)
+
+// PurgeSoftDeleted permanently deletes users that were soft deleted more
+// than retention ago, once immediately and then every interval, until ctx
+// is cancelled. It is meant to run in its own goroutine.
+func PurgeSoftDeleted(ctx context.Context, repo repository.UserRepository, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purgeOnce(repo, retention)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func purgeOnce(repo repository.UserRepository, retention time.Duration) {
+	purged, err := repo.PurgeSoftDeletedBefore(time.Now().Add(-retention))
+	if err != nil {
+		log.Printf("Error purging soft-deleted users: %v", err)
+		return
+	}
+	log.Printf("Purged %d users soft deleted more than %s ago", purged, retention)
+}
@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/IntouchOpec/user_management/service"
+)
+
+// DeleteScheduledAccounts deletes accounts whose requested deletion is due,
+// once immediately and then every interval, until ctx is cancelled. It is
+// meant to run in its own goroutine.
+func DeleteScheduledAccounts(ctx context.Context, userService service.UserService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleteScheduledOnce(userService)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func deleteScheduledOnce(userService service.UserService) {
+	deleted, err := userService.DeleteDueAccounts(time.Now())
+	if err != nil {
+		log.Printf("Error deleting scheduled accounts: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d accounts past their scheduled deletion date", deleted)
+	}
+}
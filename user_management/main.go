@@ -19,28 +19,34 @@ package main
 
 import (
 	"context"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/IntouchOpec/user_management/audit"
+	"github.com/IntouchOpec/user_management/cache"
 	"github.com/IntouchOpec/user_management/config"
 	"github.com/IntouchOpec/user_management/controllers"
 	"github.com/IntouchOpec/user_management/database"
 	_ "github.com/IntouchOpec/user_management/docs"
+	"github.com/IntouchOpec/user_management/jobs"
 	"github.com/IntouchOpec/user_management/middleware"
+	"github.com/IntouchOpec/user_management/models"
 	"github.com/IntouchOpec/user_management/repository"
 	"github.com/IntouchOpec/user_management/routes"
 	"github.com/IntouchOpec/user_management/service"
 	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis/v8"
 )
 
 func main() {
 	// Load configuration
 	cfg := config.LoadConfig()
+	gin.SetMode(cfg.Server.Mode)
 
 	// Connect to database
 	if err := database.ConnectDatabase(cfg); err != nil {
@@ -54,54 +60,159 @@ func main() {
 	}()
 
 	// Run migrations
-	if err := database.MigrateDatabase(); err != nil {
+	if err := database.MigrateDatabase(database.WithTenantScopedEmails(cfg.Database.TenantScopedEmails)); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
-	// Connect to Redis
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     cfg.Redis.Host + ":" + cfg.Redis.Port,
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
-	})
+	// Connect to Redis. The client is kept for readiness checks even if
+	// the startup ping fails and caching is disabled.
+	redisClient := cache.NewRedisClient(cfg.Redis)
+	redisProbe := redisClient
 
 	// Test Redis connection
 	ctx := context.Background()
+	var userCache cache.Cache
 	if err := redisClient.Ping(ctx).Err(); err != nil {
 		log.Printf("Warning: Redis connection failed: %v", err)
 		redisClient = nil // Continue without Redis caching
 	} else {
 		log.Println("Redis connected successfully")
+		userCache = cache.NewRedis(redisClient)
 	}
 
-	// Initialize repository, service, and controller
-	userRepo := repository.NewUserRepository(database.GetDB())
-	userService := service.NewUserService(userRepo, redisClient)
-	userController := controllers.NewUserController(userService)
+	phoneRegion := cfg.API.DefaultPhoneRegion
+	if phoneRegion != "" && !models.IsPhoneRegion(phoneRegion) {
+		log.Printf("Warning: unknown DEFAULT_PHONE_REGION %q, phone numbers will be stored as given", phoneRegion)
+		phoneRegion = ""
+	}
+
+	models.SetIDAsString(cfg.API.IDAsString)
 
-	// Set Gin mode
-	if os.Getenv("GIN_MODE") == "release" {
-		gin.SetMode(gin.ReleaseMode)
+	for _, field := range cfg.API.AdminOnlyFields {
+		if !models.IsUpdatableField(field) {
+			log.Printf("Warning: ADMIN_ONLY_FIELDS names unknown field %q", field)
+		}
+	}
+	for _, field := range cfg.API.ImmutableFields {
+		// id and created_at are never updatable, so listing them only documents the policy
+		if !models.IsUpdatableField(field) && field != "id" && field != "created_at" {
+			log.Printf("Warning: IMMUTABLE_FIELDS names unknown field %q", field)
+		}
 	}
 
+	// Initialize repository, service, and controller
+	userRepo := repository.NewUserRepository(database.GetDB())
+	auditWriter := audit.NewWriter(userRepo, cfg.Audit.BufferSize, audit.WithFlushInterval(cfg.Audit.FlushInterval))
+	userService := service.NewUserService(userRepo, userCache,
+		service.WithServeStale(cfg.Redis.ServeStale),
+		service.WithCacheTTL(cfg.Redis.CacheTTL),
+		service.WithCascadeDelete(cfg.Purge.OnUserDelete == config.OnDeleteCascade),
+		service.WithPhoneRegion(phoneRegion),
+		service.WithTimestampOverride(cfg.API.AllowTimestampOverride),
+		service.WithAdminOnlyFields(cfg.API.AdminOnlyFields),
+		service.WithImmutableFields(cfg.API.ImmutableFields),
+		service.WithDeletionGrace(cfg.Purge.DeletionGrace),
+		service.WithAuditor(auditWriter),
+		service.WithMinRegistrationAge(cfg.API.MinRegistrationAge),
+		service.WithAgeRequired(cfg.API.AgeRequired),
+	)
+	userController := controllers.NewUserController(userService,
+		controllers.WithMaxBulkSize(cfg.API.MaxBulkSize),
+		controllers.WithMaxSearchOffset(cfg.API.MaxSearchOffset),
+		controllers.WithRoleConfirmThreshold(cfg.API.RoleConfirmThreshold),
+		controllers.WithStrictJSON(cfg.API.StrictJSON),
+		controllers.WithSortDefaults(cfg.API.DefaultSortOrder, cfg.API.SortNulls),
+		controllers.WithMinRegistrationAge(cfg.API.MinRegistrationAge),
+		controllers.WithAgeRequired(cfg.API.AgeRequired),
+		controllers.WithETagStyle(cfg.API.ETagStyle),
+		controllers.WithServiceInfo(cfg.Server.ServiceName, cfg.Server.Version),
+	)
+
 	// Initialize Gin router
 	router := gin.New()
 
+	// Open the panic log, if configured
+	var panicLog io.Writer
+	if cfg.Server.PanicLogFile != "" {
+		file, err := os.OpenFile(cfg.Server.PanicLogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			log.Fatalf("Failed to open panic log: %v", err)
+		}
+		defer file.Close()
+		panicLog = file
+	}
+
 	// Add middleware
+	router.Use(middleware.RequestID())
+	router.Use(middleware.ResponseTime())
+	router.Use(middleware.CacheControl(cfg.API.CacheControlMaxAge))
 	router.Use(middleware.Logger())
-	router.Use(middleware.Recovery())
+	router.Use(middleware.RecoveryWithLog(panicLog))
+	if cfg.Server.ChaosLatency > 0 {
+		log.Printf("Warning: CHAOS_LATENCY is set, every request is delayed by %s", cfg.Server.ChaosLatency)
+		router.Use(middleware.InjectLatency(cfg.Server.ChaosLatency))
+	}
+	if cfg.Server.ForceHTTPS {
+		router.Use(middleware.HTTPSRedirect())
+	}
 	router.Use(middleware.CORS())
+	router.Use(middleware.CacheTTLHint(cfg.API.CacheTTLTrustedCIDRs))
+	// Identify the caller up front so the tenant can be checked against the
+	// token and the rate limiter can key on the account; routes still
+	// enforce authentication themselves
+	router.Use(middleware.OptionalAuth(cfg.Auth.JWTSecret))
+	router.Use(middleware.Tenant())
+	router.Use(middleware.RateLimit(redisClient, middleware.RateLimits{
+		PerIP:   cfg.RateLimit.PerIP,
+		PerUser: cfg.RateLimit.PerUser,
+		Window:  cfg.RateLimit.Window,
+	}))
+
+	if cfg.Auth.JWTSecret == "" {
+		log.Println("Warning: JWT_SECRET is not set, all tokens will be rejected and user writes will fail with 401")
+	}
 
 	// Setup routes
-	routes.SetupRoutes(router, userController)
+	routes.SetupRoutes(router, userController, cfg.Features, cfg.Auth)
+	routes.SetupCapabilityRoutes(router, cfg.Features, cfg.Auth, redisClient != nil)
+	routes.SetupAuthRoutes(router, controllers.NewAuthController(cfg.Auth.JWTSecret))
+	routes.SetupAdminRoutes(router, controllers.NewAdminController(), cfg.Features, cfg.Auth)
+	healthController := controllers.NewHealthController(
+		controllers.WithDependency("database", database.Ping),
+		controllers.WithDependency("redis", func(ctx context.Context) error {
+			return redisProbe.Ping(ctx).Err()
+		}),
+	)
+	routes.SetupHealthRoutes(router, healthController)
+
+	// Start background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	var jobsWG sync.WaitGroup
+	if cfg.Purge.Retention > 0 {
+		jobsWG.Add(1)
+		go func() {
+			defer jobsWG.Done()
+			jobs.PurgeSoftDeleted(jobsCtx, userRepo, cfg.Purge.Interval, cfg.Purge.Retention)
+		}()
+	}
+	jobsWG.Add(1)
+	go func() {
+		defer jobsWG.Done()
+		jobs.DeleteScheduledAccounts(jobsCtx, userService, cfg.Purge.Interval)
+	}()
 
 	// Create HTTP server
+	var handler http.Handler = router
+	if cfg.API.MethodOverride {
+		handler = middleware.MethodOverride(handler)
+	}
 	server := &http.Server{
 		Addr:    ":" + cfg.Server.Port,
-		Handler: router,
+		Handler: handler,
 	}
 
-	// Start server in a goroutine
+	// Start server in a goroutine; /health/ready reports 503 until warmup
+	// below has finished
 	go func() {
 		log.Printf("Starting server on port %s", cfg.Server.Port)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -109,11 +220,23 @@ func main() {
 		}
 	}()
 
+	// Warm the cache, then start accepting traffic
+	if redisClient != nil && cfg.Redis.WarmupUsers > 0 {
+		start := time.Now()
+		if err := userService.WarmCache(cfg.Redis.WarmupUsers); err != nil {
+			log.Printf("Warning: cache warmup failed: %v", err)
+		} else {
+			log.Printf("Cache warmed in %s", time.Since(start))
+		}
+	}
+	healthController.SetReady(true)
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	healthController.SetReady(false)
 
 	// Shutdown server with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -123,5 +246,11 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Stop background jobs and flush queued audit entries before the
+	// database is closed
+	stopJobs()
+	jobsWG.Wait()
+	auditWriter.Close()
+
 	log.Println("Server exited")
 }
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// APIVersionHeader names the API version a client asks for
+	APIVersionHeader = "Accept-Version"
+	// APIVersionKey is the gin context key holding the negotiated version
+	APIVersionKey = "api_version"
+)
+
+// APIVersion checks the Accept-Version header against the versions a route
+// group serves. Versions are compared case-insensitively with an optional
+// "v" prefix, so "1", "v1" and "V1" are the same. A request without the
+// header gets the first supported version, which is what its URL names; a
+// version the group does not serve is rejected with 400 listing the
+// supported ones. The negotiated version is stored under APIVersionKey and
+// echoed in the API-Version response header.
+func APIVersion(supported []string) gin.HandlerFunc {
+	versions := make([]string, 0, len(supported))
+	served := make(map[string]bool, len(supported))
+	for _, version := range supported {
+		version = normalizeAPIVersion(version)
+		versions = append(versions, version)
+		served[version] = true
+	}
+
+	return func(c *gin.Context) {
+		version := versions[0]
+		if requested := c.GetHeader(APIVersionHeader); strings.TrimSpace(requested) != "" {
+			version = normalizeAPIVersion(requested)
+			if !served[version] {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+					"error":     "Unsupported API version " + strings.TrimSpace(requested),
+					"code":      models.CodeUnsupportedVersion,
+					"supported": versions,
+				})
+				return
+			}
+		}
+
+		c.Set(APIVersionKey, version)
+		c.Header("API-Version", version)
+		c.Next()
+	}
+}
+
+// RequestAPIVersion returns the version APIVersion negotiated for the
+// request, or "" outside a versioned group
+func RequestAPIVersion(c *gin.Context) string {
+	return c.GetString(APIVersionKey)
+}
+
+// normalizeAPIVersion writes a version as "v" followed by its number
+func normalizeAPIVersion(version string) string {
+	version = strings.ToLower(strings.TrimSpace(version))
+	return "v" + strings.TrimPrefix(version, "v")
+}
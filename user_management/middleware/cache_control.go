@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authPathPrefix marks token endpoints, whose responses are never stored
+const authPathPrefix = "/api/v1/auth/"
+
+// CacheControl sets a default Cache-Control header on responses whose
+// handler did not choose one. Successful reads (GET and HEAD) may be kept
+// by the client for maxAge, or must be revalidated when maxAge is zero;
+// writes, error responses and auth endpoints get no-store. Responses stay
+// private since they depend on the caller's tenant and token.
+func CacheControl(maxAge time.Duration) gin.HandlerFunc {
+	read := "private, no-cache"
+	if seconds := int(maxAge.Seconds()); seconds > 0 {
+		read = "private, max-age=" + strconv.Itoa(seconds)
+	}
+
+	return func(c *gin.Context) {
+		cacheable := (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) &&
+			!strings.HasPrefix(c.Request.URL.Path, authPathPrefix)
+		w := &cacheControlWriter{ResponseWriter: c.Writer, cacheable: cacheable, read: read}
+		c.Writer = w
+
+		c.Next()
+
+		// Handlers that never write a body still get the header
+		w.setHeader()
+	}
+}
+
+// cacheControlWriter picks the header from the final status just before
+// headers are sent
+type cacheControlWriter struct {
+	gin.ResponseWriter
+	cacheable bool
+	read      string
+	done      bool
+}
+
+func (w *cacheControlWriter) setHeader() {
+	if w.done || w.ResponseWriter.Written() {
+		return
+	}
+	w.done = true
+
+	if w.Header().Get("Cache-Control") != "" {
+		return
+	}
+	if w.cacheable && w.Status() < http.StatusBadRequest {
+		w.Header().Set("Cache-Control", w.read)
+	} else {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+}
+
+func (w *cacheControlWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *cacheControlWriter) Write(data []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *cacheControlWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
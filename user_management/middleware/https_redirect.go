@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HTTPSRedirect redirects requests that a TLS-terminating proxy reports as
+// plain http (via X-Forwarded-Proto) to the same URL over https. GET and
+// HEAD get a 301; other methods get a 308 so clients resend the body
+// instead of switching to GET.
+//
+// Requests without the header pass through, as do health checks so load
+// balancers probing over http keep working. Only an explicit "http" causes
+// a redirect, so a proxy that terminates TLS and forwards "https" can never
+// loop.
+func HTTPSRedirect() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if forwardedProto(c.Request) != "http" || isHealthCheck(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		status := http.StatusMovedPermanently
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			status = http.StatusPermanentRedirect
+		}
+		c.Redirect(status, "https://"+c.Request.Host+c.Request.URL.RequestURI())
+		c.Abort()
+	}
+}
+
+// forwardedProto returns the scheme the client used as reported by the
+// nearest proxy, lowercased. Chained proxies append to the header, and the
+// first entry is the one facing the client.
+func forwardedProto(r *http.Request) string {
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+	return strings.ToLower(strings.TrimSpace(proto))
+}
+
+func isHealthCheck(path string) bool {
+	return path == "/health" || strings.HasPrefix(path, "/health/")
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InjectLatency delays every request by d before handling it, to exercise
+// client timeouts in chaos tests. The wait ends early if the client goes
+// away. A non-positive d returns a middleware that does nothing.
+func InjectLatency(d time.Duration) gin.HandlerFunc {
+	if d <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-c.Request.Context().Done():
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// MethodOverrideHeader names the method a POST should be treated as
+const MethodOverrideHeader = "X-HTTP-Method-Override"
+
+// overridableMethods lists the methods a POST may be rewritten to. Safe
+// methods are excluded so an override cannot turn a write into a cacheable
+// read, or smuggle in CONNECT or TRACE.
+var overridableMethods = map[string]bool{
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// MethodOverride lets clients behind proxies that only pass GET and POST
+// send a POST with X-HTTP-Method-Override: PUT, PATCH or DELETE. Any other
+// override value, or the header on a non-POST request, is ignored.
+//
+// Gin matches routes before running engine middleware, so this wraps the
+// engine rather than being installed with router.Use.
+func MethodOverride(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			method := strings.ToUpper(strings.TrimSpace(r.Header.Get(MethodOverrideHeader)))
+			if overridableMethods[method] {
+				r.Method = method
+				r.Header.Del(MethodOverrideHeader)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,10 +1,21 @@
 package middleware
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"log"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/IntouchOpec/user_management/auth"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/reqctx"
 	"github.com/gin-gonic/gin"
 )
 
@@ -27,20 +38,57 @@ func Logger() gin.HandlerFunc {
 
 // Recovery middleware recovers from panics
 func Recovery() gin.HandlerFunc {
+	return RecoveryWithLog(nil)
+}
+
+// RecoveryWithLog recovers from panics like Recovery and also appends an
+// entry with the stack trace, request line and request ID to panicLog for
+// postmortems. The client still only sees a bare 500.
+func RecoveryWithLog(panicLog io.Writer) gin.HandlerFunc {
+	var mu sync.Mutex
+
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
 		if err, ok := recovered.(string); ok {
 			log.Printf("Panic recovered: %s", err)
 		}
+
+		if panicLog != nil {
+			entry := panicEntry(c, recovered, debug.Stack())
+			mu.Lock()
+			if _, err := panicLog.Write(entry); err != nil {
+				log.Printf("Warning: failed to write panic log: %v", err)
+			}
+			mu.Unlock()
+		}
+
 		c.AbortWithStatus(500)
 	})
 }
 
+// panicEntry formats one panic log entry, ending with a blank line
+func panicEntry(c *gin.Context, recovered interface{}, stack []byte) []byte {
+	requestID, _ := reqctx.RequestID(c.Request.Context())
+	if requestID == "" {
+		requestID = "-"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "[%s] panic: %v\n", time.Now().UTC().Format(time.RFC3339), recovered)
+	fmt.Fprintf(&buf, "request: %s %s\n", c.Request.Method, c.Request.URL.RequestURI())
+	fmt.Fprintf(&buf, "request_id: %s\n", requestID)
+	fmt.Fprintf(&buf, "client: %s\n", c.ClientIP())
+	buf.Write(stack)
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
 // CORS middleware handles Cross-Origin Resource Sharing
 func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Tenant-ID, X-HTTP-Method-Override, X-Request-ID, Accept-Version")
+		c.Header("Access-Control-Expose-Headers", "X-Response-Time, X-Request-ID, API-Version")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -50,3 +98,220 @@ func CORS() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// ResponseTime middleware sets an X-Response-Time header with the handler
+// duration in milliseconds
+func ResponseTime() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &responseTimeWriter{ResponseWriter: c.Writer, start: time.Now()}
+		c.Writer = w
+
+		c.Next()
+
+		// Handlers that never write a body still get the header
+		w.setHeader()
+	}
+}
+
+// responseTimeWriter stamps the elapsed time just before headers are sent,
+// since they cannot be changed once the body has been written
+type responseTimeWriter struct {
+	gin.ResponseWriter
+	start time.Time
+	done  bool
+}
+
+func (w *responseTimeWriter) setHeader() {
+	if w.done || w.ResponseWriter.Written() {
+		return
+	}
+	w.done = true
+
+	elapsed := float64(time.Since(w.start).Microseconds()) / 1000
+	w.Header().Set("X-Response-Time", strconv.FormatFloat(elapsed, 'f', 3, 64))
+}
+
+func (w *responseTimeWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *responseTimeWriter) Write(data []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseTimeWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Bounds applied to X-Cache-TTL hints
+const (
+	MinCacheTTLHint = time.Second
+	MaxCacheTTLHint = time.Hour
+)
+
+// CacheTTLHint middleware lets trusted internal callers override the cache
+// TTL for users cached during the request via an X-Cache-TTL header
+// (seconds or a Go duration). The header is ignored unless the direct peer
+// address falls within one of the trusted CIDRs.
+func CacheTTLHint(trustedCIDRs []string) gin.HandlerFunc {
+	var trusted []*net.IPNet
+	for _, cidr := range trustedCIDRs {
+		if _, network, err := net.ParseCIDR(cidr); err == nil {
+			trusted = append(trusted, network)
+		} else {
+			log.Printf("Warning: ignoring invalid trusted CIDR %q: %v", cidr, err)
+		}
+	}
+
+	return func(c *gin.Context) {
+		header := c.GetHeader("X-Cache-TTL")
+		if header == "" || !ipInNetworks(c.RemoteIP(), trusted) {
+			c.Next()
+			return
+		}
+
+		ttl, err := parseCacheTTL(header)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		if ttl < MinCacheTTLHint {
+			ttl = MinCacheTTLHint
+		}
+		if ttl > MaxCacheTTLHint {
+			ttl = MaxCacheTTLHint
+		}
+
+		c.Request = c.Request.WithContext(reqctx.WithCacheTTL(c.Request.Context(), ttl))
+		c.Next()
+	}
+}
+
+// parseCacheTTL accepts either a number of seconds or a Go duration string
+func parseCacheTTL(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// ipInNetworks reports whether ip belongs to any of the networks
+func ipInNetworks(ip string, networks []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// TenantHeader is the request header that selects the tenant explicitly
+const TenantHeader = "X-Tenant-ID"
+
+// maxTenantIDLength matches the size of the users.tenant_id column
+const maxTenantIDLength = 64
+
+// Tenant resolves the tenant from the X-Tenant-ID header or, failing that,
+// the leftmost label of a subdomain host (acme.example.com -> acme) and
+// stores it in the request context for tenant-scoped queries. Requests
+// that name no tenant pass through unscoped; RequireTenant rejects them.
+//
+// A bearer token binds the request to the token's tenant_id claim, which
+// applies even when no tenant is named; naming another tenant is rejected
+// with 403. Tokens without the claim may only name a tenant when they carry
+// the admin role. Anonymous requests may name any tenant, which only
+// reaches the routes open without a token. It must run after OptionalAuth,
+// which verifies the token.
+func Tenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := strings.ToLower(strings.TrimSpace(c.GetHeader(TenantHeader)))
+		if tenantID == "" {
+			tenantID = subdomainTenant(c.Request.Host)
+		}
+
+		if tenantID != "" && !validTenantID(tenantID) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid tenant ID",
+				"code":  models.CodeInvalidTenant,
+			})
+			return
+		}
+
+		if value, ok := c.Get(ClaimsKey); ok {
+			claims := value.(*auth.Claims)
+			switch {
+			case claims.TenantID != "" && tenantID != "" && tenantID != claims.TenantID,
+				claims.TenantID == "" && tenantID != "" && !claims.IsAdmin():
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"error": "Token is not valid for this tenant",
+					"code":  models.CodeTenantMismatch,
+				})
+				return
+			case claims.TenantID != "":
+				tenantID = claims.TenantID
+			}
+		}
+
+		if tenantID != "" {
+			c.Request = c.Request.WithContext(reqctx.WithTenantID(c.Request.Context(), tenantID))
+		}
+
+		c.Next()
+	}
+}
+
+// RequireTenant rejects requests that Tenant resolved to no tenant with 403,
+// except for admins, so they are never run unscoped across every tenant.
+// It must run after OptionalAuth and Tenant.
+func RequireTenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if _, ok := reqctx.TenantID(ctx); !ok && !reqctx.IsAdmin(ctx) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "A tenant is required; send a token bound to a tenant",
+				"code":  models.CodeTenantRequired,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// subdomainTenant returns the leftmost label of host when it has at least
+// three labels and is not "www", or "" otherwise
+func subdomainTenant(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+
+	labels := strings.Split(strings.ToLower(host), ".")
+	if len(labels) < 3 || labels[0] == "www" {
+		return ""
+	}
+	return labels[0]
+}
+
+// validTenantID reports whether id is a non-empty slug of lowercase letters,
+// digits, hyphens and underscores that fits the tenant column
+func validTenantID(id string) bool {
+	if id == "" || len(id) > maxTenantIDLength {
+		return false
+	}
+	for _, r := range id {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/reqctx"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// RateLimits configures how many requests a client may make per window.
+// A non-positive limit disables limiting for that kind of client.
+type RateLimits struct {
+	PerIP   int
+	PerUser int
+	Window  time.Duration
+}
+
+// RateLimit middleware counts requests per fixed window in Redis. Requests
+// carrying an actor ID are limited per user (ratelimit:user:<id>) so an
+// account cannot spread its traffic across addresses; anonymous requests
+// are limited per peer address (ratelimit:ip:<ip>). X-Forwarded-For is
+// ignored, since any client could rotate it for a fresh bucket. It must run after any
+// middleware that authenticates the actor. Requests are let through when
+// Redis is unavailable.
+func RateLimit(client redis.Cmdable, limits RateLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if client == nil || limits.Window <= 0 {
+			c.Next()
+			return
+		}
+
+		key, limit := "ratelimit:ip:"+c.RemoteIP(), limits.PerIP
+		if actorID, ok := reqctx.ActorID(c.Request.Context()); ok {
+			key, limit = fmt.Sprintf("ratelimit:user:%d", actorID), limits.PerUser
+		}
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		count, err := countRequest(c.Request.Context(), client, key, limits.Window)
+		if err != nil {
+			log.Printf("Warning: rate limit check failed for %s: %v", key, err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(max(int64(limit)-count, 0), 10))
+
+		if count > int64(limit) {
+			retryAfter := limits.Window
+			if ttl, err := client.PTTL(c.Request.Context(), key).Result(); err == nil && ttl > 0 {
+				retryAfter = ttl
+			}
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": "Rate limit exceeded",
+				"code":  models.CodeRateLimited,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// countRequest increments the counter for key, starting its window on the
+// first request, and returns the number of requests made in the window
+func countRequest(ctx context.Context, client redis.Cmdable, key string, window time.Duration) (int64, error) {
+	count, err := client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := client.PExpire(ctx, key, window).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return count, nil
+}
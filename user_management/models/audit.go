@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// AuditAction names what was done to a user in an audit entry
+type AuditAction string
+
+// Audited actions
+const (
+	AuditUserCreated       AuditAction = "user.created"
+	AuditUserUpdated       AuditAction = "user.updated"
+	AuditUserStatusChanged AuditAction = "user.status_changed"
+	AuditUserDeleted       AuditAction = "user.deleted"
+	AuditUserRestored      AuditAction = "user.restored"
+
+	AuditUserDeletionScheduled AuditAction = "user.deletion_scheduled"
+	AuditUserDeletionCanceled  AuditAction = "user.deletion_canceled"
+)
+
+// AuditEntry records an action taken on a user and who took it.
+// RequestID is the X-Request-ID of the API call that made the change, for
+// tracing it back to the request log.
+type AuditEntry struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	Action    AuditAction `json:"action" gorm:"not null;size:64;index"`
+	UserID    uint        `json:"user_id" gorm:"not null;index"`
+	ActorID   *uint       `json:"actor_id,omitempty"`
+	RequestID string      `json:"request_id,omitempty" gorm:"size:128;index"`
+	TenantID  string      `json:"-" gorm:"size:64;index"`
+	CreatedAt time.Time   `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (AuditEntry) TableName() string {
+	return "audit_log"
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// AgeOn returns the age in whole years on day at of someone born on birth.
+// Both are compared as calendar dates in UTC, so a birthday counts from
+// its first moment; people born on 29 February age on 1 March in other
+// years.
+func AgeOn(birth, at time.Time) int {
+	birth, at = birth.UTC(), at.UTC()
+	age := at.Year() - birth.Year()
+	if at.Month() < birth.Month() || (at.Month() == birth.Month() && at.Day() < birth.Day()) {
+		age--
+	}
+	return age
+}
+
+// CheckBirthDate validates an optional birth date: it may not lie in the
+// future or imply an age above 150
+func CheckBirthDate(birth *time.Time, now time.Time) error {
+	if birth == nil {
+		return nil
+	}
+	switch {
+	case birth.After(now):
+		return &ValidationError{Message: "invalid birth date", Fields: map[string]string{"birth_date": "must not be in the future"}}
+	case AgeOn(*birth, now) > 150:
+		return &ValidationError{Message: "invalid birth date", Fields: map[string]string{"birth_date": "must be at most 150 years ago"}}
+	}
+	return nil
+}
+
+// CurrentAge returns the user's age computed from BirthDate when known,
+// falling back to the stored Age
+func (u *User) CurrentAge(now time.Time) int {
+	if u.BirthDate == nil {
+		return u.Age
+	}
+	return AgeOn(*u.BirthDate, now)
+}
+
+// CurrentAge returns the age the request describes: computed from
+// BirthDate when given, otherwise Age
+func (r UserRequest) CurrentAge(now time.Time) int {
+	if r.BirthDate == nil {
+		return r.Age
+	}
+	return AgeOn(*r.BirthDate, now)
+}
@@ -0,0 +1,18 @@
+package models
+
+// CalendarDay is the number of users who signed up on one day, as a UTC
+// date in YYYY-MM-DD form
+type CalendarDay struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+// SignupCalendar holds a month of daily signup counts for a calendar
+// heatmap. Days has one entry per day of the month, including days with no
+// signups.
+type SignupCalendar struct {
+	Year  int           `json:"year"`
+	Month int           `json:"month"`
+	Total int64         `json:"total"`
+	Days  []CalendarDay `json:"days"`
+}
@@ -0,0 +1,50 @@
+package models
+
+// comparedFields lists the fields CompareUsers diffs, in response order
+var comparedFields = []string{"name", "email", "age", "phone", "address", "birth_date", "status", "is_active"}
+
+// FieldComparison holds one field of two compared users
+type FieldComparison struct {
+	A    interface{} `json:"a"`
+	B    interface{} `json:"b"`
+	Same bool        `json:"same"`
+}
+
+// UserComparison is a field-by-field diff of two users, for reviewing
+// potential duplicates before merging them. Different lists the fields
+// that differ, in a fixed order.
+type UserComparison struct {
+	A         UserResponse               `json:"a"`
+	B         UserResponse               `json:"b"`
+	Fields    map[string]FieldComparison `json:"fields"`
+	Different []string                   `json:"different"`
+}
+
+// CompareUsers diffs the profile fields of a and b
+func CompareUsers(a, b *User) UserComparison {
+	values := func(u *User) map[string]interface{} {
+		return map[string]interface{}{
+			"name": u.Name, "email": u.Email, "age": u.Age, "phone": u.Phone,
+			"address": u.Address, "birth_date": u.BirthDate, "status": u.Status, "is_active": u.IsActive,
+		}
+	}
+	valuesA, valuesB := values(a), values(b)
+
+	comparison := UserComparison{
+		A:         a.ToResponse(),
+		B:         b.ToResponse(),
+		Fields:    make(map[string]FieldComparison, len(comparedFields)),
+		Different: []string{},
+	}
+	for _, field := range comparedFields {
+		same := valuesA[field] == valuesB[field]
+		if field == "birth_date" {
+			same = sameTime(a.BirthDate, b.BirthDate)
+		}
+		comparison.Fields[field] = FieldComparison{A: valuesA[field], B: valuesB[field], Same: same}
+		if !same {
+			comparison.Different = append(comparison.Different, field)
+		}
+	}
+	return comparison
+}
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Error codes returned in the "code" field of every error response. Codes
+// are stable identifiers clients can branch on; messages may change.
+const (
+	CodeUserNotFound       = "USER_NOT_FOUND"
+	CodeEmailExists        = "EMAIL_EXISTS"
+	CodeDuplicateValue     = "DUPLICATE_VALUE"
+	CodeValidationFailed   = "VALIDATION_FAILED"
+	CodeInvalidID          = "INVALID_ID"
+	CodeInvalidRequestBody = "INVALID_REQUEST_BODY"
+	CodeInvalidParameter   = "INVALID_PARAMETER"
+	CodeBulkLimitExceeded  = "BULK_LIMIT_EXCEEDED"
+	CodeSearchTooDeep      = "SEARCH_TOO_DEEP"
+	CodeInvalidTransition  = "INVALID_STATUS_TRANSITION"
+	CodeInvalidTenant      = "INVALID_TENANT"
+	CodeTenantMismatch     = "TENANT_MISMATCH"
+	CodeTenantRequired     = "TENANT_REQUIRED"
+	CodeUnsupportedVersion = "UNSUPPORTED_API_VERSION"
+	CodeFieldImmutable     = "FIELD_IMMUTABLE"
+	CodeConfirmRequired    = "CONFIRMATION_REQUIRED"
+	CodeUserNotDeleted     = "USER_NOT_DELETED"
+	CodeUnauthorized       = "UNAUTHORIZED"
+	CodeForbidden          = "FORBIDDEN"
+	CodeRateLimited        = "RATE_LIMITED"
+	CodeDBUnavailable      = "DATABASE_UNAVAILABLE"
+	CodeBadRequest         = "BAD_REQUEST"
+	CodeNotFound           = "NOT_FOUND"
+	CodeConflict           = "CONFLICT"
+	CodeInternal           = "INTERNAL_ERROR"
+)
+
+var (
+	// ErrUserNotFound is returned when no user matches a lookup
+	ErrUserNotFound = errors.New("user not found")
+
+	// ErrUserNotDeleted is returned when restoring a user that is not deleted
+	ErrUserNotDeleted = errors.New("user is not deleted")
+
+	// ErrForbidden is returned when the caller may not act on a user
+	ErrForbidden = errors.New("not allowed to access this user")
+
+	// ErrDatabaseUnavailable wraps errors caused by the database being unreachable
+	ErrDatabaseUnavailable = errors.New("database unavailable")
+
+	// ErrEmailExists matches any EmailExistsError via errors.Is
+	ErrEmailExists = errors.New("email already exists")
+)
+
+// EmailExistsError reports that an email address already belongs to a user.
+// Batch operations list every conflicting address in Emails, with the
+// first also in Email.
+type EmailExistsError struct {
+	Email  string
+	Emails []string
+}
+
+func (e *EmailExistsError) Error() string {
+	if len(e.Emails) > 1 {
+		return fmt.Sprintf("users with emails %s already exist", strings.Join(e.Emails, ", "))
+	}
+	if e.Email == "" {
+		return "user with this email already exists"
+	}
+	return fmt.Sprintf("user with email %s already exists", e.Email)
+}
+
+// Is makes errors.Is(err, ErrEmailExists) match
+func (e *EmailExistsError) Is(target error) bool {
+	return target == ErrEmailExists
+}
+
+// UniqueViolationError reports that a value must be unique but already
+// belongs to another user. Email conflicts are reported as
+// EmailExistsError instead.
+type UniqueViolationError struct {
+	Field string
+	// Message, when set, replaces the generic description
+	Message string
+}
+
+func (e *UniqueViolationError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.Field == "" {
+		return "a user with these details already exists"
+	}
+	return fmt.Sprintf("user with this %s already exists", e.Field)
+}
+
+// ValidationError reports input that was well-formed but unacceptable.
+// Fields, when set, maps each offending field to what is wrong with it.
+type ValidationError struct {
+	Message string
+	Fields  map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	problems := make([]string, 0, len(names))
+	for _, name := range names {
+		problems = append(problems, name+" "+e.Fields[name])
+	}
+	return e.Message + ": " + strings.Join(problems, "; ")
+}
+
+// MinimumAgeError reports a new user younger than the minimum registration
+// age
+type MinimumAgeError struct {
+	MinAge int
+}
+
+func (e *MinimumAgeError) Error() string {
+	return fmt.Sprintf("Users must be at least %d years old to register", e.MinAge)
+}
+
+// ConfirmationRequiredError reports a bulk change matching more users than
+// may be changed without confirmation
+type ConfirmationRequiredError struct {
+	Matched   int64
+	Threshold int
+}
+
+func (e *ConfirmationRequiredError) Error() string {
+	return fmt.Sprintf("Filter matches %d users, more than %d: set confirm=true to assign the role", e.Matched, e.Threshold)
+}
+
+// StatusTransitionError reports a status change the transition rules forbid
+type StatusTransitionError struct {
+	From UserStatus
+	To   UserStatus
+}
+
+func (e *StatusTransitionError) Error() string {
+	return fmt.Sprintf("cannot change status from %s to %s", e.From, e.To)
+}
+
+// FieldImmutableError reports an update to a field configured as immutable
+type FieldImmutableError struct {
+	Field string
+}
+
+func (e *FieldImmutableError) Error() string {
+	return fmt.Sprintf("%s cannot be changed", e.Field)
+}
+
+// FieldForbiddenError reports an update to a field only admins may change
+type FieldForbiddenError struct {
+	Field string
+}
+
+func (e *FieldForbiddenError) Error() string {
+	return fmt.Sprintf("only admins can change %s", e.Field)
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+)
+
+// idAsString makes UserResponse render its ID as a JSON string, for
+// JavaScript clients that lose precision on large integers
+var idAsString atomic.Bool
+
+// SetIDAsString switches UserResponse IDs between JSON numbers (the
+// default) and strings. It is meant to be called once at startup.
+func SetIDAsString(enabled bool) {
+	idAsString.Store(enabled)
+}
+
+// ID is a user ID in a request body, given either as a JSON number or as a
+// string holding one
+type ID uint
+
+// UnmarshalJSON accepts 42 as well as "42"
+func (id *ID) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	raw := data
+	if len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"' {
+		raw = raw[1 : len(raw)-1]
+	}
+	value, err := strconv.ParseUint(string(raw), 10, 0)
+	if err != nil {
+		return fmt.Errorf("invalid id %s", data)
+	}
+	*id = ID(value)
+	return nil
+}
+
+// UintIDs converts request IDs to plain uints
+func UintIDs(ids []ID) []uint {
+	converted := make([]uint, len(ids))
+	for i, id := range ids {
+		converted[i] = uint(id)
+	}
+	return converted
+}
+
+// MarshalJSON renders the user with its ID as a number or, after
+// SetIDAsString(true), as a string
+func (r UserResponse) MarshalJSON() ([]byte, error) {
+	type plain UserResponse
+	if !idAsString.Load() {
+		return json.Marshal(plain(r))
+	}
+	return json.Marshal(struct {
+		ID string `json:"id"`
+		plain
+	}{ID: strconv.FormatUint(uint64(r.ID), 10), plain: plain(r)})
+}
+
+// UnmarshalJSON reads a user whose ID is either a number or a string, so
+// users cached under either setting stay readable
+func (r *UserResponse) UnmarshalJSON(data []byte) error {
+	type plain UserResponse
+	decoded := struct {
+		ID ID `json:"id"`
+		*plain
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	r.ID = uint(decoded.ID)
+	return nil
+}
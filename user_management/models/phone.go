@@ -0,0 +1,40 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// IsPhoneRegion reports whether region is a supported ISO 3166-1 alpha-2
+// phone region such as "TH" or "US"
+func IsPhoneRegion(region string) bool {
+	return phonenumbers.GetCountryCodeForRegion(strings.ToUpper(region)) != 0
+}
+
+// NormalizePhone parses phone and returns it in E.164 form (+66812345678).
+// Numbers with a +country prefix are read as such; national numbers are
+// read as belonging to defaultRegion, and rejected when it is empty. An
+// empty phone is returned unchanged.
+func NormalizePhone(phone, defaultRegion string) (string, error) {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return "", nil
+	}
+
+	invalid := &ValidationError{Message: "invalid phone number", Fields: map[string]string{}}
+	number, err := phonenumbers.Parse(phone, strings.ToUpper(defaultRegion))
+	if err != nil {
+		if !strings.HasPrefix(phone, "+") && defaultRegion == "" {
+			invalid.Fields["phone"] = "must start with a +country code"
+		} else {
+			invalid.Fields["phone"] = "must be a valid phone number"
+		}
+		return "", invalid
+	}
+	if !phonenumbers.IsValidNumber(number) {
+		invalid.Fields["phone"] = "must be a valid phone number"
+		return "", invalid
+	}
+	return phonenumbers.Format(number, phonenumbers.E164), nil
+}
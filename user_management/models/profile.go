@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// UserProfile is a user together with stats derived from other tables,
+// so profile pages need a single request
+type UserProfile struct {
+	User  UserResponse `json:"user"`
+	Stats UserStats    `json:"stats"`
+}
+
+// UserStats summarises a user's account history
+type UserStats struct {
+	DaysSinceSignup  int   `json:"days_since_signup"`
+	EmailChangeCount int   `json:"email_change_count"`
+	AuditEventCount  int64 `json:"audit_event_count"`
+}
+
+// UserDataExport is everything stored about a user, for data portability
+// requests
+type UserDataExport struct {
+	User         UserResponse       `json:"user"`
+	EmailHistory []UserEmailHistory `json:"email_history"`
+	AuditLog     []AuditEntry       `json:"audit_log"`
+	ExportedAt   time.Time          `json:"exported_at"`
+}
+
+// DaysSince returns the number of whole days from t to now, never negative
+func DaysSince(t, now time.Time) int {
+	if now.Before(t) {
+		return 0
+	}
+	return int(now.Sub(t).Hours() / 24)
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONSchema is the subset of JSON Schema (draft 2020-12) that validator
+// tags can express
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 string                 `json:"type"`
+	Format               string                 `json:"format,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaFor describes the JSON body of struct v, such as UserRequest, from
+// its json and validate tags. Fields tagged json:"-" are left out; a field
+// is required when its validate tag says so. Unknown validator rules are
+// ignored rather than guessed at.
+func SchemaFor(v interface{}) *JSONSchema {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	schema := &JSONSchema{
+		Schema:     "https://json-schema.org/draft/2020-12/schema",
+		Title:      t.Name(),
+		Type:       "object",
+		Properties: make(map[string]*JSONSchema),
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := fieldSchema(field.Type)
+		if rules := field.Tag.Get("validate"); rules != "" {
+			if applyRules(property, rules) {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+		schema.Properties[name] = property
+	}
+	return schema
+}
+
+// fieldSchema returns the type of a field, with pointers standing for
+// optional values
+func fieldSchema(t reflect.Type) *JSONSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return &JSONSchema{Type: "array"}
+	case t.Kind() == reflect.Struct || t.Kind() == reflect.Map:
+		return &JSONSchema{Type: "object"}
+	default:
+		return &JSONSchema{Type: "string"}
+	}
+}
+
+// applyRules copies the constraints of a validate tag onto property and
+// reports whether the tag makes the field required. min and max bound the
+// length of strings and the value of numbers, as they do in the validator.
+func applyRules(property *JSONSchema, rules string) (required bool) {
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			property.Format = "email"
+		case "oneof":
+			property.Enum = strings.Fields(param)
+		case "min", "max":
+			value, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			if property.Type == "string" {
+				length := int(value)
+				if name == "min" {
+					property.MinLength = &length
+				} else {
+					property.MaxLength = &length
+				}
+			} else if name == "min" {
+				property.Minimum = &value
+			} else {
+				property.Maximum = &value
+			}
+		}
+	}
+	return required
+}
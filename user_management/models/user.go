@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -10,37 +12,327 @@ import (
 type User struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
 	Name      string         `json:"name" gorm:"not null;size:100" validate:"required,min=2,max=100"`
-	Email     string         `json:"email" gorm:"uniqueIndex;not null;size:100" validate:"required,email"`
+	Email     string         `json:"email" gorm:"not null;size:100" validate:"required,email"`
 	Age       int            `json:"age" gorm:"not null" validate:"required,min=0,max=150"`
 	Phone     string         `json:"phone" gorm:"size:20" validate:"omitempty,min=10,max=20"`
 	Address   string         `json:"address" gorm:"size:255" validate:"omitempty,max=255"`
-	IsActive  bool           `json:"is_active" gorm:"default:true"`
+	BirthDate *time.Time     `json:"birth_date,omitempty"`
+	Status    UserStatus     `json:"status" gorm:"size:20;not null;default:active;index"`
+	Role      string         `json:"role" gorm:"size:20;not null;default:user;index"`
+	IsActive  bool           `json:"is_active"`
+	UpdatedBy *uint          `json:"updated_by,omitempty" gorm:"index"`
+	TenantID  string         `json:"tenant_id,omitempty" gorm:"size:64;index"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// DeletionScheduledAt is when a requested account deletion takes
+	// effect; nil when none is pending
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty" gorm:"index"`
+
+	// previousEmail holds the email replaced by UpdateFromRequest until the
+	// change is saved and recorded in the email history
+	previousEmail string
+}
+
+// UserStatus is the lifecycle state of an account
+type UserStatus string
+
+const (
+	// StatusPending is an account that has not been activated yet
+	StatusPending UserStatus = "pending"
+	// StatusActive is an account in good standing
+	StatusActive UserStatus = "active"
+	// StatusSuspended is an account disabled temporarily
+	StatusSuspended UserStatus = "suspended"
+	// StatusBanned is an account disabled for abuse
+	StatusBanned UserStatus = "banned"
+)
+
+// statusTransitions lists the statuses each status may change to. Nothing
+// returns to pending once it has left it.
+var statusTransitions = map[UserStatus][]UserStatus{
+	StatusPending:   {StatusActive, StatusSuspended, StatusBanned},
+	StatusActive:    {StatusSuspended, StatusBanned},
+	StatusSuspended: {StatusActive, StatusBanned},
+	StatusBanned:    {StatusActive, StatusSuspended},
+}
+
+// ParseUserStatus validates a status name
+func ParseUserStatus(value string) (UserStatus, error) {
+	status := UserStatus(strings.ToLower(strings.TrimSpace(value)))
+	if _, ok := statusTransitions[status]; !ok {
+		return "", &ValidationError{Message: fmt.Sprintf("invalid status %q, expected pending, active, suspended or banned", value)}
+	}
+	return status, nil
+}
+
+// CanTransitionTo reports whether a user may change from s to status.
+// Keeping the current status is always allowed.
+func (s UserStatus) CanTransitionTo(status UserStatus) bool {
+	if s == status {
+		return true
+	}
+	for _, allowed := range statusTransitions[s] {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// RoleUser is the role of an ordinary account
+	RoleUser = "user"
+	// RoleAdmin is the role of an account with administrative access
+	RoleAdmin = "admin"
+)
+
+// ParseRole validates a role name
+func ParseRole(value string) (string, error) {
+	role := strings.ToLower(strings.TrimSpace(value))
+	if role != RoleUser && role != RoleAdmin {
+		return "", &ValidationError{Message: fmt.Sprintf("invalid role %q, expected user or admin", value)}
+	}
+	return role, nil
+}
+
+// UserEmailHistory records an email address a user had before changing it
+type UserEmailHistory struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	Email     string    `json:"email" gorm:"not null;size:100"`
+	ChangedBy *uint     `json:"changed_by,omitempty"`
+	TenantID  string    `json:"-" gorm:"size:64;index"`
+	ChangedAt time.Time `json:"changed_at" gorm:"autoCreateTime"`
+
+	// DeletedAt is set when the entry is deleted along with its user
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName specifies the table name for GORM
+func (UserEmailHistory) TableName() string {
+	return "user_email_history"
 }
 
 // UserRequest represents the request payload for creating/updating users
 type UserRequest struct {
 	Name     string `json:"name" validate:"required,min=2,max=100"`
 	Email    string `json:"email" validate:"required,email"`
-	Age      int    `json:"age" validate:"required,min=0,max=150"`
+	Age      int    `json:"age" validate:"min=0,max=150"`
 	Phone    string `json:"phone" validate:"omitempty,min=10,max=20"`
 	Address  string `json:"address" validate:"omitempty,max=255"`
 	IsActive *bool  `json:"is_active,omitempty"`
+
+	// Role, when given, changes the user's role on update; only admins may
+	// change it. New users always start as RoleUser.
+	Role string `json:"role,omitempty"`
+
+	// BirthDate, when given, determines the age: it is recomputed from it
+	// on every read and write, overriding Age
+	BirthDate *time.Time `json:"birth_date,omitempty"`
+
+	// CreatedAt is the original creation time of an imported user. It is
+	// only read from import files and only honored for admins when
+	// ALLOW_TIMESTAMP_OVERRIDE is on; other writes always stamp now.
+	CreatedAt *time.Time `json:"-"`
+
+	// AgeOmitted records that the body left out age, which the zero value
+	// cannot tell apart from an explicit 0. Binding sets it so AGE_REQUIRED
+	// can be enforced wherever the request is written.
+	AgeOmitted bool `json:"-"`
+}
+
+// StatusRequest represents the request payload for changing a user's status
+type StatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=pending active suspended banned"`
+}
+
+// BulkCreateRequest represents the request payload for creating users in bulk
+type BulkCreateRequest struct {
+	Users []UserRequest `json:"users"`
+}
+
+// BulkUpdateItem represents a single user update within a bulk request
+type BulkUpdateItem struct {
+	ID ID `json:"id" swaggertype:"integer"`
+	UserRequest
+}
+
+// BulkUpdateRequest represents the request payload for updating users in bulk
+type BulkUpdateRequest struct {
+	Users []BulkUpdateItem `json:"users"`
+}
+
+// BulkDeleteRequest represents the request payload for deleting users in bulk
+type BulkDeleteRequest struct {
+	IDs []ID `json:"ids" swaggertype:"array,integer"`
+}
+
+// DuplicateStrategy controls how an import handles rows whose email
+// already belongs to a user
+type DuplicateStrategy string
+
+const (
+	// DuplicateSkip leaves the existing user untouched and skips the row
+	DuplicateSkip DuplicateStrategy = "skip"
+	// DuplicateUpdate overwrites the existing user with the row's values
+	DuplicateUpdate DuplicateStrategy = "update"
+	// DuplicateError aborts the whole import without writing anything
+	DuplicateError DuplicateStrategy = "error"
+)
+
+// ParseDuplicateStrategy validates a duplicate strategy name, defaulting
+// to DuplicateSkip when empty
+func ParseDuplicateStrategy(value string) (DuplicateStrategy, error) {
+	switch strategy := DuplicateStrategy(value); strategy {
+	case "":
+		return DuplicateSkip, nil
+	case DuplicateSkip, DuplicateUpdate, DuplicateError:
+		return strategy, nil
+	default:
+		return "", &ValidationError{Message: fmt.Sprintf("invalid duplicate strategy %q, expected skip, update or error", value)}
+	}
+}
+
+// ImportResult summarizes the outcome of a user import
+type ImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+}
+
+// BulkSetActiveRequest represents the request payload for activating or
+// deactivating every user matching a filter
+type BulkSetActiveRequest struct {
+	Active *bool      `json:"active"`
+	Filter UserFilter `json:"filter"`
+}
+
+// BulkRoleRequest represents the request payload for assigning a role to
+// every user matching a filter
+type BulkRoleRequest struct {
+	Role   string     `json:"role"`
+	Filter UserFilter `json:"filter"`
 }
 
 // UserResponse represents the response payload for user operations
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Age       int       `json:"age"`
-	Phone     string    `json:"phone"`
-	Address   string    `json:"address"`
-	IsActive  bool      `json:"is_active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uint       `json:"id" xml:"id"`
+	Name      string     `json:"name" xml:"name"`
+	Email     string     `json:"email" xml:"email"`
+	Age       int        `json:"age" xml:"age"`
+	Phone     string     `json:"phone" xml:"phone"`
+	Address   string     `json:"address" xml:"address"`
+	BirthDate *time.Time `json:"birth_date,omitempty" xml:"birth_date,omitempty"`
+	Status    UserStatus `json:"status" xml:"status"`
+	Role      string     `json:"role" xml:"role"`
+	IsActive  bool       `json:"is_active" xml:"is_active"`
+	UpdatedBy *uint      `json:"updated_by,omitempty" xml:"updated_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at" xml:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" xml:"updated_at"`
+
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty" xml:"deletion_scheduled_at,omitempty"`
+
+	// Stale is set when the user was served from cache because the
+	// database was unreachable
+	Stale bool `json:"-" xml:"-"`
+}
+
+// SimilarUser is a potential duplicate of another user, with a score in
+// (0, 1] and the signals that matched
+type SimilarUser struct {
+	User    UserResponse `json:"user" xml:"user"`
+	Score   float64      `json:"score" xml:"score"`
+	Matches []string     `json:"matches" xml:"matches>match"`
+}
+
+// UserNeighbors are the users immediately before and after a user in ID
+// order, nil at either end
+type UserNeighbors struct {
+	Previous *UserResponse `json:"previous"`
+	Next     *UserResponse `json:"next"`
+}
+
+// CollectionVersion identifies the state of the users collection: it changes
+// whenever a user is added, removed or modified
+type CollectionVersion struct {
+	Count        int64
+	LastModified time.Time
+}
+
+// ETag returns an entity tag for the collection state in the given
+// representation (e.g. json or xml), strong or weak (W/"...") as requested.
+// Each representation gets its own tag, as their bodies differ.
+func (v CollectionVersion) ETag(strong bool, representation string) string {
+	tag := fmt.Sprintf(`"%x-%x-%s"`, v.Count, v.LastModified.UnixNano(), representation)
+	if strong {
+		return tag
+	}
+	return "W/" + tag
+}
+
+// sortableColumns lists the columns users can be ordered by, mapped to
+// whether the column is nullable
+var sortableColumns = map[string]bool{
+	"id":         false,
+	"name":       false,
+	"email":      false,
+	"age":        false,
+	"created_at": false,
+	"updated_at": false,
+	"updated_by": true,
+}
+
+// UserSort describes the ordering of a user list. The zero value leaves
+// rows in database order.
+type UserSort struct {
+	Column     string
+	Descending bool
+	NullsFirst bool
+}
+
+// IsSortableColumn reports whether users can be ordered by column
+func IsSortableColumn(column string) bool {
+	_, ok := sortableColumns[column]
+	return ok
+}
+
+// IsEmpty reports whether no ordering was requested
+func (s UserSort) IsEmpty() bool {
+	return s.Column == ""
+}
+
+// Nullable reports whether the sort column can hold NULLs, making the
+// null position significant
+func (s UserSort) Nullable() bool {
+	return sortableColumns[s.Column]
+}
+
+// UserFilter holds optional criteria for listing users. Name matches any
+// part of the name and Email the whole address, both ignoring case.
+type UserFilter struct {
+	UpdatedBy *uint      `form:"updated_by" json:"updated_by,omitempty"`
+	IsActive  *bool      `form:"is_active" json:"is_active,omitempty"`
+	Status    UserStatus `form:"status" json:"status,omitempty"`
+	MinAge    *int       `form:"min_age" json:"min_age,omitempty"`
+	MaxAge    *int       `form:"max_age" json:"max_age,omitempty"`
+	Name      string     `form:"name" json:"name,omitempty"`
+	Email     string     `form:"email" json:"email,omitempty"`
+	Search    string     `form:"search" json:"search,omitempty"`
+}
+
+// IsEmpty reports whether no filter criteria are set
+func (f UserFilter) IsEmpty() bool {
+	return f.UpdatedBy == nil && f.IsActive == nil && f.Status == "" && f.MinAge == nil && f.MaxAge == nil &&
+		strings.TrimSpace(f.Name) == "" && strings.TrimSpace(f.Email) == "" && strings.TrimSpace(f.Search) == ""
+}
+
+// PageOptions narrows and orders the rows returned by a page query. Rows
+// are ordered by id when no sort is given, so pages never overlap.
+type PageOptions struct {
+	Filter UserFilter
+	Sort   UserSort
 }
 
 // ToResponse converts User model to UserResponse
@@ -49,28 +341,206 @@ func (u *User) ToResponse() UserResponse {
 		ID:        u.ID,
 		Name:      u.Name,
 		Email:     u.Email,
-		Age:       u.Age,
+		Age:       u.CurrentAge(time.Now()),
 		Phone:     u.Phone,
 		Address:   u.Address,
+		BirthDate: u.BirthDate,
+		Status:    u.Status,
+		Role:      u.Role,
 		IsActive:  u.IsActive,
+		UpdatedBy: u.UpdatedBy,
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
+
+		DeletionScheduledAt: u.DeletionScheduledAt,
+	}
+}
+
+// ToVCard renders the user as a vCard 3.0 contact
+func (r UserResponse) ToVCard() string {
+	name := strings.TrimSpace(r.Name)
+	given, family := name, ""
+	if i := strings.LastIndex(name, " "); i >= 0 {
+		given, family = strings.TrimSpace(name[:i]), name[i+1:]
+	}
+
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		fmt.Fprintf(&b, format+"\r\n", args...)
 	}
+
+	line("BEGIN:VCARD")
+	line("VERSION:3.0")
+	line("FN:%s", escapeVCard(name))
+	line("N:%s;%s;;;", escapeVCard(family), escapeVCard(given))
+	line("EMAIL;TYPE=INTERNET:%s", escapeVCard(r.Email))
+	if r.Phone != "" {
+		line("TEL;TYPE=CELL:%s", escapeVCard(r.Phone))
+	}
+	if r.Address != "" {
+		line("ADR;TYPE=HOME:;;%s;;;;", escapeVCard(r.Address))
+	}
+	line("REV:%s", r.UpdatedAt.UTC().Format("20060102T150405Z"))
+	line("END:VCARD")
+	return b.String()
+}
+
+// vCardEscaper escapes the characters vCard text values reserve
+var vCardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeVCard(value string) string {
+	return vCardEscaper.Replace(value)
+}
+
+// updatableFields lists the field names UpdateFromRequest can report
+var updatableFields = map[string]bool{
+	"name": true, "email": true, "age": true, "phone": true,
+	"address": true, "birth_date": true, "status": true, "is_active": true,
+	"role": true,
 }
 
-// UpdateFromRequest updates user fields from request
-func (u *User) UpdateFromRequest(req UserRequest) {
+// IsUpdatableField reports whether name is a field UpdateFromRequest can
+// change
+func IsUpdatableField(name string) bool {
+	return updatableFields[name]
+}
+
+// UpdateFromRequest updates user fields from request and returns the JSON
+// names of the fields whose values changed, empty when the request matches
+// the user. An email change is recorded in the user's email history when
+// the user is next saved.
+func (u *User) UpdateFromRequest(req UserRequest) []string {
+	if req.Email != u.Email && u.previousEmail == "" {
+		u.previousEmail = u.Email
+	}
+
+	changed := []string{}
+	set := func(field string, differs bool) {
+		if differs {
+			changed = append(changed, field)
+		}
+	}
+	age := req.Age
+	if req.BirthDate != nil {
+		age = AgeOn(*req.BirthDate, time.Now())
+	}
+	set("name", u.Name != req.Name)
+	set("email", u.Email != req.Email)
+	set("age", u.Age != age)
+	set("phone", u.Phone != req.Phone)
+	set("address", u.Address != req.Address)
+	set("birth_date", !sameTime(u.BirthDate, req.BirthDate))
+
 	u.Name = req.Name
 	u.Email = req.Email
-	u.Age = req.Age
+	u.Age = age
 	u.Phone = req.Phone
 	u.Address = req.Address
+	u.BirthDate = req.BirthDate
+	if req.Role != "" {
+		set("role", u.Role != req.Role)
+		u.Role = req.Role
+	}
 	if req.IsActive != nil {
-		u.IsActive = *req.IsActive
+		status, active := u.Status, u.IsActive
+		u.SetActive(*req.IsActive)
+		set("status", u.Status != status)
+		set("is_active", u.IsActive != active)
 	}
+	return changed
+}
+
+// Reset clears the user's optional profile fields and reactivates the
+// account, leaving name, email and age alone. It returns the JSON names of
+// the fields whose values changed, like UpdateFromRequest.
+func (u *User) Reset() []string {
+	changed := []string{}
+	set := func(field string, differs bool) {
+		if differs {
+			changed = append(changed, field)
+		}
+	}
+	set("phone", u.Phone != "")
+	set("address", u.Address != "")
+	set("status", u.Status != StatusActive)
+	set("is_active", !u.IsActive)
+
+	u.Phone = ""
+	u.Address = ""
+	u.Status = StatusActive
+	u.IsActive = true
+	return changed
+}
+
+// SetActive applies the legacy is_active flag to the status: activating
+// makes the user active, while deactivating suspends an active user and
+// leaves pending and banned users as they are
+func (u *User) SetActive(active bool) {
+	switch {
+	case active:
+		u.Status = StatusActive
+	case u.Status == StatusActive || u.Status == "":
+		u.Status = StatusSuspended
+	}
+	u.IsActive = u.Status == StatusActive
+}
+
+// BeforeSave defaults new users to active and keeps is_active, which is
+// kept for compatibility, in step with the status
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	if u.Status == "" {
+		u.Status = StatusActive
+	}
+	// Keep the stored age current so age filters see birthdays
+	u.Age = u.CurrentAge(time.Now())
+	u.IsActive = u.Status == StatusActive
+	return nil
+}
+
+// AfterUpdate records the replaced email, if any, in the same transaction
+// as the update
+func (u *User) AfterUpdate(tx *gorm.DB) error {
+	if u.previousEmail == "" || u.previousEmail == u.Email {
+		u.previousEmail = ""
+		return nil
+	}
+
+	entry := UserEmailHistory{
+		UserID:    u.ID,
+		Email:     u.previousEmail,
+		ChangedBy: u.UpdatedBy,
+		TenantID:  u.TenantID,
+	}
+	if err := tx.Session(&gorm.Session{NewDB: true}).Create(&entry).Error; err != nil {
+		return err
+	}
+	u.previousEmail = ""
+	return nil
+}
+
+// NormalizeEmail returns the canonical form emails are stored and compared
+// in: trimmed and lowercased
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// EmailLocalPart returns the lowercased part of an email before the @,
+// without any +tag suffix
+func EmailLocalPart(email string) string {
+	local, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+	local, _, _ = strings.Cut(local, "+")
+	return local
 }
 
 // TableName specifies the table name for GORM
 func (User) TableName() string {
 	return "users"
 }
+
+// sameTime reports whether two optional times are both unset or equal
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
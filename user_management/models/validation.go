@@ -0,0 +1,84 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidationResult is the outcome of validating one item of a batch,
+// identified by its position in the request
+type ValidationResult struct {
+	Index  int               `json:"index"`
+	Valid  bool              `json:"valid"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// validate runs the validate tags on request payloads. Fields are reported
+// by their JSON names so errors match what clients sent.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
+
+// Validate checks v against its validate tags. It returns nil when v is
+// valid and otherwise a *ValidationError whose Fields say what is wrong with
+// each failing field.
+func Validate(v interface{}) error {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var failures validator.ValidationErrors
+	if !errors.As(err, &failures) {
+		return err
+	}
+
+	fields := make(map[string]string, len(failures))
+	for _, failure := range failures {
+		fields[failure.Field()] = fieldMessage(failure)
+	}
+	return &ValidationError{Message: "validation failed", Fields: fields}
+}
+
+// IsValidEmail reports whether email is a well-formed address, by the same
+// rule user requests are validated with
+func IsValidEmail(email string) bool {
+	return validate.Var(email, "required,email") == nil
+}
+
+// fieldMessage describes a failed rule in words
+func fieldMessage(failure validator.FieldError) string {
+	unit := ""
+	if failure.Kind() == reflect.String {
+		unit = " characters"
+	}
+
+	switch failure.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email"
+	case "min":
+		return fmt.Sprintf("must be at least %s%s", failure.Param(), unit)
+	case "max":
+		return fmt.Sprintf("must be at most %s%s", failure.Param(), unit)
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(failure.Param(), " ", ", ")
+	default:
+		return fmt.Sprintf("failed the %s rule", failure.Tag())
+	}
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/IntouchOpec/user_management/models"
+)
+
+// uniqueConstraint is the field a unique index protects and, optionally,
+// the message reported when it is violated
+type uniqueConstraint struct {
+	field   string
+	message string
+}
+
+var (
+	uniqueConstraintsMu sync.RWMutex
+
+	// uniqueConstraints maps index names, or table.column for SQLite
+	// column constraints, to the field they protect
+	uniqueConstraints = map[string]uniqueConstraint{
+		"idx_users_email_lower_active":        {field: "email"},
+		"idx_users_tenant_email_lower_active": {field: "email"},
+	}
+)
+
+// RegisterUniqueConstraint names the field a unique index protects and the
+// message reported when a write violates it; an empty message uses the
+// generic "user with this <field> already exists". Violations of
+// unregistered constraints name the field guessed from the index name.
+func RegisterUniqueConstraint(name, field, message string) {
+	uniqueConstraintsMu.Lock()
+	defer uniqueConstraintsMu.Unlock()
+	uniqueConstraints[name] = uniqueConstraint{field: field, message: message}
+}
+
+var (
+	// Postgres: duplicate key value violates unique constraint "idx_users_phone"
+	postgresUniqueConstraint = regexp.MustCompile(`unique constraint "([^"]+)"`)
+	// SQLite expression and named indexes: UNIQUE constraint failed: index 'idx_users_phone'
+	sqliteUniqueIndex = regexp.MustCompile(`UNIQUE constraint failed: index '([^']+)'`)
+	// SQLite column constraints: UNIQUE constraint failed: users.tenant_id, users.phone
+	sqliteUniqueColumns = regexp.MustCompile(`UNIQUE constraint failed: ([\w.]+(?:, [\w.]+)*)`)
+)
+
+// translateUniqueViolation turns a unique index violation into an
+// EmailExistsError for email conflicts or a UniqueViolationError naming
+// the field otherwise. Other errors are returned unchanged.
+func translateUniqueViolation(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var name string
+	message := err.Error()
+	if match := postgresUniqueConstraint.FindStringSubmatch(message); match != nil {
+		name = match[1]
+	} else if match := sqliteUniqueIndex.FindStringSubmatch(message); match != nil {
+		name = match[1]
+	} else if match := sqliteUniqueColumns.FindStringSubmatch(message); match != nil {
+		name = match[1]
+	} else {
+		return err
+	}
+
+	constraint := lookupUniqueConstraint(name)
+
+	if constraint.field == "email" && constraint.message == "" {
+		return &models.EmailExistsError{}
+	}
+	return &models.UniqueViolationError{Field: constraint.field, Message: constraint.message}
+}
+
+// lookupUniqueConstraint finds the registered constraint by name, falling
+// back to one registered for the field the name suggests, since SQLite
+// reports single-column indexes by column rather than by name
+func lookupUniqueConstraint(name string) uniqueConstraint {
+	uniqueConstraintsMu.RLock()
+	defer uniqueConstraintsMu.RUnlock()
+
+	if constraint, ok := uniqueConstraints[name]; ok {
+		return constraint
+	}
+	field := guessConstraintField(name)
+	for _, constraint := range uniqueConstraints {
+		if constraint.field == field && constraint.message != "" {
+			return constraint
+		}
+	}
+	return uniqueConstraint{field: field}
+}
+
+// guessConstraintField derives a field name from the naming conventions of
+// GORM (idx_users_phone, uni_users_phone), Postgres (users_phone_key) and
+// SQLite (users.phone). For multi-column SQLite constraints the last
+// column is taken, as leading columns scope the value (tenant_id, phone).
+func guessConstraintField(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	for _, prefix := range []string{"idx_users_", "uni_users_", "users_"} {
+		if rest, ok := strings.CutPrefix(name, prefix); ok {
+			return strings.TrimSuffix(rest, "_key")
+		}
+	}
+	return ""
+}
@@ -1,21 +1,56 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/reqctx"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserRepository interface defines user data access methods
 type UserRepository interface {
+	WithContext(ctx context.Context) UserRepository
+	WithTx(tx *gorm.DB) UserRepository
 	Create(user *models.User) error
 	GetByID(id uint) (*models.User, error)
+	GetByIDIncludingDeleted(id uint) (*models.User, error)
 	GetByEmail(email string) (*models.User, error)
+	GetByIDs(ids []uint) ([]models.User, error)
+	GetByEmails(emails []string) ([]models.User, error)
+	GetRandom() (*models.User, error)
+	GetRecent(limit int) ([]models.User, error)
+	CountFiltered(filter models.UserFilter) (int64, error)
+	CountCreatedByDay(from, to time.Time) (map[string]int64, error)
 	GetAll(offset, limit int) ([]models.User, error)
+	GetAllCursor(afterID uint, limit int) ([]models.User, error)
+	Search(filter models.UserFilter, sort models.UserSort, offset, limit int) ([]models.User, int64, error)
+	GetPage(page, size int, opts models.PageOptions) ([]models.User, bool, error)
+	FindInBatches(filter models.UserFilter, batchSize int, fn func([]models.User) error) error
 	Update(user *models.User) error
 	Delete(id uint) error
+	DeleteWithDependents(id uint) error
+	Restore(id uint) error
 	Count() (int64, error)
+	CreateBatch(users []*models.User) error
+	UpdateBatch(users []*models.User) error
+	DeleteByIDs(ids []uint, deletedBy *uint) ([]uint, error)
+	DeleteWhere(batchSize int, query interface{}, args ...interface{}) ([]uint, error)
+	PurgeSoftDeletedBefore(t time.Time) (int64, error)
+	SetActiveByFilter(filter models.UserFilter, active bool, updatedBy *uint) ([]uint, error)
+	SetRoleByFilter(filter models.UserFilter, role string, updatedBy *uint, maxAffected int) ([]uint, error)
+	FindSimilar(user *models.User, limit int) ([]models.User, error)
+	FindNeighbors(id uint) (prev, next *models.User, err error)
+	CollectionVersion() (models.CollectionVersion, error)
+	GetEmailHistory(userID uint) ([]models.UserEmailHistory, error)
+	CountAuditEntries(userID uint) (int64, error)
+	GetAuditEntries(userID uint) ([]models.AuditEntry, error)
+	DeleteScheduledBefore(t time.Time) ([]models.User, error)
+	CreateAuditEntries(entries []models.AuditEntry) error
 }
 
 // userRepository implements UserRepository interface
@@ -28,15 +63,20 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
+// WithContext returns a copy of the repository whose queries run with ctx,
+// which also scopes them to the request's tenant
+func (r *userRepository) WithContext(ctx context.Context) UserRepository {
+	return &userRepository{db: r.db.WithContext(ctx)}
+}
+
+// WithTx returns a copy of the repository whose queries run inside tx
+func (r *userRepository) WithTx(tx *gorm.DB) UserRepository {
+	return &userRepository{db: tx}
+}
+
 // Create creates a new user
 func (r *userRepository) Create(user *models.User) error {
-	if err := r.db.Create(user).Error; err != nil {
-		if errors.Is(err, gorm.ErrDuplicatedKey) {
-			return errors.New("user with this email already exists")
-		}
-		return err
-	}
-	return nil
+	return translateUniqueViolation(r.db.Create(user).Error)
 }
 
 // GetByID retrieves a user by ID
@@ -45,26 +85,125 @@ func (r *userRepository) GetByID(id uint) (*models.User, error) {
 	err := r.db.First(&user, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, models.ErrUserNotFound
 		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-// GetByEmail retrieves a user by email
+// GetByIDIncludingDeleted retrieves a user by ID whether or not it has been
+// soft deleted
+func (r *userRepository) GetByIDIncludingDeleted(id uint) (*models.User, error) {
+	var user models.User
+	err := r.db.Unscoped().First(&user, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByEmail retrieves a user by email, ignoring case the way the unique
+// email index does
 func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.Where("LOWER(email) = LOWER(?)", email).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, models.ErrUserNotFound
 		}
 		return nil, err
 	}
 	return &user, nil
 }
 
+// GetByIDs retrieves the users with the given IDs in a single query. IDs
+// without a user are silently omitted, so callers detect missing users by
+// comparing the result against the request.
+func (r *userRepository) GetByIDs(ids []uint) ([]models.User, error) {
+	var users []models.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+// GetByEmails retrieves the users owning any of the given emails in a
+// single query, ignoring case
+func (r *userRepository) GetByEmails(emails []string) ([]models.User, error) {
+	var users []models.User
+	if len(emails) == 0 {
+		return users, nil
+	}
+	lowered := make([]string, len(emails))
+	for i, email := range emails {
+		lowered[i] = strings.ToLower(email)
+	}
+	err := r.db.Where("LOWER(email) IN ?", lowered).Find(&users).Error
+	return users, err
+}
+
+// GetRandom retrieves a user picked at random, or ErrUserNotFound when
+// there are none. It sorts the whole table, which is fine for demos and
+// spot checks but not for hot paths.
+func (r *userRepository) GetRandom() (*models.User, error) {
+	random := "RANDOM()"
+	if r.db.Dialector.Name() == "mysql" {
+		random = "RAND()"
+	}
+
+	var users []models.User
+	if err := r.db.Order(random).Limit(1).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, models.ErrUserNotFound
+	}
+	return &users[0], nil
+}
+
+// CountCreatedByDay counts the users created in [from, to), keyed by UTC
+// creation date in YYYY-MM-DD form. Days without signups are omitted.
+func (r *userRepository) CountCreatedByDay(from, to time.Time) (map[string]int64, error) {
+	day := "strftime('%Y-%m-%d', created_at)"
+	switch r.db.Dialector.Name() {
+	case "postgres":
+		day = "TO_CHAR(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD')"
+	case "mysql":
+		day = "DATE_FORMAT(created_at, '%Y-%m-%d')"
+	}
+
+	var rows []struct {
+		Day   string
+		Count int64
+	}
+	err := r.db.Model(&models.User{}).
+		Select(day+" AS day, COUNT(*) AS count").
+		Where("created_at >= ? AND created_at < ?", from.UTC(), to.UTC()).
+		Group(day).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Day] = row.Count
+	}
+	return counts, nil
+}
+
+// GetRecent retrieves the limit most recently created users, newest first
+func (r *userRepository) GetRecent(limit int) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Order("created_at DESC").Order("id DESC").Limit(limit).Find(&users).Error
+	return users, err
+}
+
 // GetAll retrieves all users with pagination
 func (r *userRepository) GetAll(offset, limit int) ([]models.User, error) {
 	var users []models.User
@@ -72,16 +211,193 @@ func (r *userRepository) GetAll(offset, limit int) ([]models.User, error) {
 	return users, err
 }
 
-// Update updates a user
-func (r *userRepository) Update(user *models.User) error {
-	err := r.db.Save(user).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrDuplicatedKey) {
-			return errors.New("user with this email already exists")
+// GetAllCursor retrieves up to limit users with IDs above afterID, in ID
+// order. Unlike offset pages, rows inserted between calls never shift the
+// next page.
+func (r *userRepository) GetAllCursor(afterID uint, limit int) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("id > ?", afterID).Order("id").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+// Search retrieves users matching the filter with pagination, along with
+// the total number of matching users
+func (r *userRepository) Search(filter models.UserFilter, sort models.UserSort, offset, limit int) ([]models.User, int64, error) {
+	var total int64
+	if err := applyFilter(r.db.Model(&models.User{}), filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	// Pages past the last match are empty without scanning to the offset
+	if int64(offset) >= total {
+		return []models.User{}, total, nil
+	}
+
+	query := applyFilter(r.db, filter)
+	if sort.IsEmpty() {
+		query = applySearchRank(query, filter.Search)
+	} else {
+		query = applySort(query, sort)
+	}
+
+	var users []models.User
+	err := query.Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
+}
+
+// applySearchRank orders text search results by how well they match, for
+// autocomplete: an exact email first, then email prefixes, then name
+// prefixes, then any other substring match, with ties broken by id. It
+// leaves the query unordered when there is no search term.
+func applySearchRank(db *gorm.DB, search string) *gorm.DB {
+	search = strings.ToLower(strings.TrimSpace(search))
+	if search == "" {
+		return db
+	}
+
+	prefix := likeEscaper.Replace(search) + "%"
+	return db.Clauses(clause.OrderBy{Expression: clause.Expr{
+		SQL: "CASE WHEN LOWER(email) = ? THEN 0" +
+			` WHEN LOWER(email) LIKE ? ESCAPE '\' THEN 1` +
+			` WHEN LOWER(name) LIKE ? ESCAPE '\' THEN 2` +
+			" ELSE 3 END, id",
+		Vars:               []interface{}{search, prefix, prefix},
+		WithoutParentheses: true,
+	}})
+}
+
+// applySort orders the query by a whitelisted column, falling back to id
+// for unknown columns. Nullable columns get an explicit NULLS FIRST/LAST;
+// ties are broken by id so pages are stable.
+func applySort(db *gorm.DB, sort models.UserSort) *gorm.DB {
+	if sort.IsEmpty() {
+		return db
+	}
+
+	column := sort.Column
+	if !models.IsSortableColumn(column) {
+		column = "id"
+	}
+
+	direction := "ASC"
+	if sort.Descending {
+		direction = "DESC"
+	}
+
+	order := column + " " + direction
+	if sort.Nullable() {
+		if sort.NullsFirst {
+			order += " NULLS FIRST"
+		} else {
+			order += " NULLS LAST"
 		}
-		return err
 	}
-	return nil
+
+	db = db.Order(order)
+	if column != "id" {
+		db = db.Order("id")
+	}
+	return db
+}
+
+// GetPage retrieves a 1-based page of users and whether another page
+// follows. It fetches one row beyond the page instead of counting, so
+// callers that do not need totals avoid the separate COUNT query.
+func (r *userRepository) GetPage(page, size int, opts models.PageOptions) ([]models.User, bool, error) {
+	query := applyFilter(r.db, opts.Filter)
+	if opts.Sort.IsEmpty() && strings.TrimSpace(opts.Filter.Search) != "" {
+		query = applySearchRank(query, opts.Filter.Search)
+	} else if opts.Sort.IsEmpty() {
+		query = query.Order("id")
+	} else {
+		query = applySort(query, opts.Sort)
+	}
+
+	var users []models.User
+	if err := query.Offset((page - 1) * size).Limit(size + 1).Find(&users).Error; err != nil {
+		return nil, false, err
+	}
+
+	hasNext := len(users) > size
+	if hasNext {
+		users = users[:size]
+	}
+	return users, hasNext, nil
+}
+
+// GetEmailHistory retrieves the previous emails of a user, oldest first
+func (r *userRepository) GetEmailHistory(userID uint) ([]models.UserEmailHistory, error) {
+	var history []models.UserEmailHistory
+	err := r.db.Where("user_id = ?", userID).Order("changed_at, id").Find(&history).Error
+	return history, err
+}
+
+// CountAuditEntries counts the audit log entries recorded for a user
+func (r *userRepository) CountAuditEntries(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.AuditEntry{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// CreateAuditEntries inserts audit entries in one statement
+func (r *userRepository) CreateAuditEntries(entries []models.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.Create(&entries).Error
+}
+
+// GetAuditEntries retrieves the audit log entries for a user, oldest first
+func (r *userRepository) GetAuditEntries(userID uint) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	err := r.db.Where("user_id = ?", userID).Order("created_at, id").Find(&entries).Error
+	return entries, err
+}
+
+// applyFilter adds a WHERE clause for each criterion set on the filter
+func applyFilter(db *gorm.DB, filter models.UserFilter) *gorm.DB {
+	if filter.UpdatedBy != nil {
+		db = db.Where("updated_by = ?", *filter.UpdatedBy)
+	}
+	if filter.IsActive != nil {
+		db = db.Where("is_active = ?", *filter.IsActive)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if filter.MinAge != nil {
+		db = db.Where("age >= ?", *filter.MinAge)
+	}
+	if filter.MaxAge != nil {
+		db = db.Where("age <= ?", *filter.MaxAge)
+	}
+	if name := strings.ToLower(strings.TrimSpace(filter.Name)); name != "" {
+		db = db.Where(`LOWER(name) LIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(name)+"%")
+	}
+	if email := models.NormalizeEmail(filter.Email); email != "" {
+		db = db.Where("LOWER(email) = ?", email)
+	}
+	if search := strings.ToLower(strings.TrimSpace(filter.Search)); search != "" {
+		pattern := "%" + likeEscaper.Replace(search) + "%"
+		db = db.Where(`(LOWER(name) LIKE ? ESCAPE '\' OR LOWER(email) LIKE ? ESCAPE '\')`, pattern, pattern)
+	}
+	return db
+}
+
+// likeEscaper escapes the LIKE wildcards so search terms match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// FindInBatches walks every user matching the filter in id order, handing
+// them to fn batchSize at a time. An error from fn stops the walk.
+func (r *userRepository) FindInBatches(filter models.UserFilter, batchSize int, fn func([]models.User) error) error {
+	var batch []models.User
+	return applyFilter(r.db, filter).FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+// Update updates a user
+func (r *userRepository) Update(user *models.User) error {
+	return translateUniqueViolation(r.db.Save(user).Error)
 }
 
 // Delete soft deletes a user
@@ -91,14 +407,314 @@ func (r *userRepository) Delete(id uint) error {
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
-		return errors.New("user not found")
+		return models.ErrUserNotFound
 	}
 	return nil
 }
 
+// DeleteWithDependents soft deletes a user and its email history in one
+// transaction. Audit entries are always kept.
+func (r *userRepository) DeleteWithDependents(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.User{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrUserNotFound
+		}
+		return tx.Where("user_id = ?", id).Delete(&models.UserEmailHistory{}).Error
+	})
+}
+
+// Restore undoes the soft delete of a user, and of any email history
+// deleted with it. The email index only covers users that are not deleted,
+// so restoring a user whose address has been taken since fails with an
+// EmailExistsError.
+func (r *userRepository) Restore(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&models.User{}).Where("id = ?", id).Update("deleted_at", nil)
+		if result.Error != nil {
+			return translateUniqueViolation(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrUserNotFound
+		}
+		return tx.Unscoped().Model(&models.UserEmailHistory{}).
+			Where("user_id = ? AND deleted_at IS NOT NULL", id).
+			Update("deleted_at", nil).Error
+	})
+}
+
 // Count returns the total number of users
 func (r *userRepository) Count() (int64, error) {
 	var count int64
 	err := r.db.Model(&models.User{}).Count(&count).Error
 	return count, err
 }
+
+// CountFiltered counts the users matching the filter
+func (r *userRepository) CountFiltered(filter models.UserFilter) (int64, error) {
+	var count int64
+	err := applyFilter(r.db.Model(&models.User{}), filter).Count(&count).Error
+	return count, err
+}
+
+// CreateBatch creates multiple users in a single transaction
+func (r *userRepository) CreateBatch(users []*models.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+	return translateUniqueViolation(r.db.Create(&users).Error)
+}
+
+// UpdateBatch updates multiple users in a single transaction
+func (r *userRepository) UpdateBatch(users []*models.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, user := range users {
+			if err := tx.Save(user).Error; err != nil {
+				return translateUniqueViolation(err)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteByIDs soft deletes the users with the given IDs and records an
+// audit entry by deletedBy for each, all in one transaction, returning the
+// IDs that were removed. Nothing is deleted if any audit entry fails.
+func (r *userRepository) DeleteByIDs(ids []uint, deletedBy *uint) ([]uint, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	deleted, err := r.deleteAudited(func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("id IN ?", ids)
+	}, deletedBy)
+	if err != nil {
+		return nil, err
+	}
+	removed := make([]uint, len(deleted))
+	for i, user := range deleted {
+		removed[i] = user.ID
+	}
+	return removed, nil
+}
+
+// DeleteScheduledBefore soft deletes every user whose deletion was
+// scheduled for t or earlier, auditing each as DeleteByIDs does with no
+// actor. It returns the deleted users with only ID and TenantID loaded, as
+// the job runs across tenants.
+func (r *userRepository) DeleteScheduledBefore(t time.Time) ([]models.User, error) {
+	return r.deleteAudited(func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", t)
+	}, nil)
+}
+
+// deleteAudited soft deletes the users matched by where and records an
+// audit entry for each in the same transaction, returning the users
+// deleted with their ID and TenantID
+func (r *userRepository) deleteAudited(where func(*gorm.DB) *gorm.DB, deletedBy *uint) ([]models.User, error) {
+	var deleted []models.User
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := where(tx.Model(&models.User{})).Select("id", "tenant_id").Order("id").Find(&deleted).Error; err != nil {
+			return err
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(deleted))
+		for i, user := range deleted {
+			ids[i] = user.ID
+		}
+		if err := tx.Where("id IN ?", ids).Delete(&models.User{}).Error; err != nil {
+			return err
+		}
+		requestID, _ := reqctx.RequestID(tx.Statement.Context)
+		entries := make([]models.AuditEntry, len(deleted))
+		for i, user := range deleted {
+			entries[i] = models.AuditEntry{Action: models.AuditUserDeleted, UserID: user.ID, ActorID: deletedBy, RequestID: requestID, TenantID: user.TenantID}
+		}
+		return tx.Create(&entries).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+// PurgeSoftDeletedBefore permanently deletes users soft deleted before t,
+// along with their email history, and returns the number of users removed
+func (r *userRepository) PurgeSoftDeletedBefore(t time.Time) (int64, error) {
+	var purged int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Unscoped().Model(&models.User{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", t).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := tx.Unscoped().Where("user_id IN ?", ids).Delete(&models.UserEmailHistory{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&models.User{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
+
+// DeleteWhere soft deletes users matching the condition in batches of
+// batchSize and returns the IDs that were removed
+func (r *userRepository) DeleteWhere(batchSize int, query interface{}, args ...interface{}) ([]uint, error) {
+	var deleted []uint
+	for {
+		var ids []uint
+		err := r.db.Model(&models.User{}).
+			Where(query, args...).
+			Order("id").
+			Limit(batchSize).
+			Pluck("id", &ids).Error
+		if err != nil {
+			return deleted, err
+		}
+		if len(ids) == 0 {
+			return deleted, nil
+		}
+
+		if err := r.db.Where("id IN ?", ids).Delete(&models.User{}).Error; err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, ids...)
+
+		if len(ids) < batchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// SetActiveByFilter activates or deactivates every user matching the filter
+// in a single UPDATE and returns the IDs of the affected users. As with
+// User.SetActive, deactivating suspends active users and leaves pending and
+// banned users in their status.
+func (r *userRepository) SetActiveByFilter(filter models.UserFilter, active bool, updatedBy *uint) ([]uint, error) {
+	updates := map[string]interface{}{"is_active": active, "status": models.StatusActive}
+	if !active {
+		updates["status"] = gorm.Expr("CASE WHEN status = ? THEN ? ELSE status END", models.StatusActive, models.StatusSuspended)
+	}
+	if updatedBy != nil {
+		updates["updated_by"] = *updatedBy
+	}
+
+	var users []models.User
+	err := applyFilter(r.db.Model(&users), filter).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Updates(updates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids, nil
+}
+
+// SetRoleByFilter assigns role to every user matching the filter in a
+// single UPDATE and returns the IDs of the affected users. When maxAffected
+// is positive and the update touches more users, it is rolled back with a
+// *models.ConfirmationRequiredError; counting the updated rows rather than
+// counting beforehand means users added in between cannot slip past.
+func (r *userRepository) SetRoleByFilter(filter models.UserFilter, role string, updatedBy *uint, maxAffected int) ([]uint, error) {
+	updates := map[string]interface{}{"role": role}
+	if updatedBy != nil {
+		updates["updated_by"] = *updatedBy
+	}
+
+	var users []models.User
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := applyFilter(tx.Model(&users), filter).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+			Updates(updates).Error
+		if err != nil {
+			return err
+		}
+		if maxAffected > 0 && len(users) > maxAffected {
+			return &models.ConfirmationRequiredError{Matched: int64(len(users)), Threshold: maxAffected}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids, nil
+}
+
+// FindSimilar returns other users sharing the user's normalized name, phone
+// or email local part. Matching is deliberately broad; callers are expected
+// to score the candidates.
+func (r *userRepository) FindSimilar(user *models.User, limit int) ([]models.User, error) {
+	conditions := r.db.Where("LOWER(TRIM(name)) = ?", strings.ToLower(strings.TrimSpace(user.Name)))
+	if phone := strings.TrimSpace(user.Phone); phone != "" {
+		conditions = conditions.Or("TRIM(phone) = ?", phone)
+	}
+	if local := models.EmailLocalPart(user.Email); local != "" {
+		local = likeEscaper.Replace(local)
+		conditions = conditions.Or(`LOWER(email) LIKE ? ESCAPE '\'`, local+"@%").Or(`LOWER(email) LIKE ? ESCAPE '\'`, local+"+%")
+	}
+
+	var users []models.User
+	err := r.db.Where("id <> ?", user.ID).
+		Where(conditions).
+		Order("id").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// FindNeighbors returns the users immediately before and after id in ID
+// order, or nil at either end of the table
+func (r *userRepository) FindNeighbors(id uint) (prev, next *models.User, err error) {
+	neighbor := func(condition, order string) (*models.User, error) {
+		var users []models.User
+		if err := r.db.Where(condition, id).Order(order).Limit(1).Find(&users).Error; err != nil {
+			return nil, err
+		}
+		if len(users) == 0 {
+			return nil, nil
+		}
+		return &users[0], nil
+	}
+
+	if prev, err = neighbor("id < ?", "id DESC"); err != nil {
+		return nil, nil, err
+	}
+	if next, err = neighbor("id > ?", "id ASC"); err != nil {
+		return nil, nil, err
+	}
+	return prev, next, nil
+}
+
+// CollectionVersion returns the number of users and the most recent
+// updated_at among them
+func (r *userRepository) CollectionVersion() (models.CollectionVersion, error) {
+	var version models.CollectionVersion
+	if err := r.db.Model(&models.User{}).Count(&version.Count).Error; err != nil {
+		return version, err
+	}
+
+	var latest models.User
+	err := r.db.Select("updated_at").Order("updated_at DESC").Limit(1).Find(&latest).Error
+	version.LastModified = latest.UpdatedAt
+	return version, err
+}
@@ -0,0 +1,73 @@
+package reqctx
+
+import (
+	"context"
+	"time"
+)
+
+// contextKey is an unexported type for request-scoped values to avoid
+// collisions with keys defined in other packages
+type contextKey int
+
+const (
+	cacheTTLKey contextKey = iota
+	actorIDKey
+	tenantIDKey
+	requestIDKey
+	adminKey
+)
+
+// WithCacheTTL returns a copy of ctx carrying a cache TTL override
+func WithCacheTTL(ctx context.Context, ttl time.Duration) context.Context {
+	return context.WithValue(ctx, cacheTTLKey, ttl)
+}
+
+// CacheTTL returns the cache TTL override stored in ctx, if any
+func CacheTTL(ctx context.Context) (time.Duration, bool) {
+	ttl, ok := ctx.Value(cacheTTLKey).(time.Duration)
+	return ttl, ok
+}
+
+// WithActorID returns a copy of ctx carrying the ID of the user performing the request
+func WithActorID(ctx context.Context, id uint) context.Context {
+	return context.WithValue(ctx, actorIDKey, id)
+}
+
+// ActorID returns the ID of the user performing the request, if known
+func ActorID(ctx context.Context) (uint, bool) {
+	id, ok := ctx.Value(actorIDKey).(uint)
+	return id, ok
+}
+
+// WithTenantID returns a copy of ctx carrying the tenant the request belongs to
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantIDKey, tenantID)
+}
+
+// TenantID returns the tenant the request belongs to, if any
+func TenantID(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantIDKey).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// WithRequestID returns a copy of ctx carrying the ID correlating the request's logs
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the ID correlating the request's logs, if any
+func RequestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok && id != ""
+}
+
+// WithAdmin returns a copy of ctx marking the request as made by an admin
+func WithAdmin(ctx context.Context) context.Context {
+	return context.WithValue(ctx, adminKey, true)
+}
+
+// IsAdmin reports whether the request was made by an admin
+func IsAdmin(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminKey).(bool)
+	return admin
+}
@@ -1,31 +1,132 @@
 package routes
 
 import (
+	"github.com/IntouchOpec/user_management/config"
 	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/middleware"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-// SetupRoutes configures all application routes
-func SetupRoutes(router *gin.Engine, userController *controllers.UserController) {
+// apiV1 checks that requests under /api/v1 ask for no other API version
+var apiV1 = middleware.APIVersion([]string{"v1"})
+
+// SetupRoutes configures all application routes. Optional endpoints are
+// only registered when their feature is enabled. User routes that write
+// require a valid bearer token; reads do too when
+// authConfig.RequireAuthForReads is set. With authConfig.RequireTenant,
+// user routes reject non-admin requests that resolve to no tenant.
+func SetupRoutes(router *gin.Engine, userController *controllers.UserController, features config.Features, authConfig config.AuthConfig) {
+	jwtAuth := middleware.JWTAuth(authConfig.JWTSecret)
+	requireAdmin := middleware.RequireAdmin(authConfig.JWTSecret)
+	requireAuth := middleware.RequireAuth(authConfig.JWTSecret)
+
 	// Health check endpoint
 	router.GET("/health", userController.HealthCheck)
 
 	// Swagger documentation
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	var docsPath string
+	if features.IsEnabled(config.FeatureSwagger) {
+		router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+		docsPath = "/swagger/index.html"
+	}
+
+	// Service information at the base URL
+	router.GET("/", userController.Root(docsPath))
 
 	// API v1 routes
-	v1 := router.Group("/api/v1")
+	v1 := router.Group("/api/v1", apiV1)
 	{
 		// User routes
 		users := v1.Group("/users")
+		if authConfig.RequireTenant {
+			users.Use(middleware.RequireTenant())
+		}
+		if authConfig.RequireAuthForReads {
+			users.Use(jwtAuth)
+		}
 		{
-			users.POST("", userController.CreateUser)
+			users.POST("", jwtAuth, userController.CreateUser)
 			users.GET("", userController.GetUsers)
+			users.DELETE("", jwtAuth, userController.BulkDeleteUsers)
+			users.POST("/bulk", jwtAuth, userController.BulkCreateUsers)
+			// Validation is a dry run that writes nothing
+			users.POST("/validate", userController.ValidateUsers)
+			users.PUT("/bulk", jwtAuth, userController.BulkUpdateUsers)
+			users.POST("/bulk-set-active", jwtAuth, userController.BulkSetActive)
+			users.POST("/bulk-role", requireAdmin, userController.BulkSetRole)
+			if features.IsEnabled(config.FeatureImport) {
+				users.POST("/import", jwtAuth, userController.ImportUsers)
+			}
+			users.DELETE("/inactive", requireAdmin, userController.DeleteInactiveUsers)
+			if features.IsEnabled(config.FeatureExport) {
+				users.GET("/export", requireAdmin, userController.ExportUsers)
+			}
+			users.GET("/random", userController.GetRandomUser)
+			users.GET("/recent", userController.GetRecentUsers)
+			users.GET("/count-status", userController.GetCountStatus)
+			users.GET("/compare", userController.CompareUsers)
+			users.GET("/calendar", userController.GetSignupCalendar)
+			users.GET("/by-email", userController.GetUserByEmail)
+			users.GET("/schema", userController.GetUserSchema)
 			users.GET("/:id", userController.GetUser)
-			users.PUT("/:id", userController.UpdateUser)
-			users.DELETE("/:id", userController.DeleteUser)
+			if features.IsEnabled(config.FeatureSimilar) {
+				users.GET("/:id/similar", userController.GetSimilarUsers)
+			}
+			users.GET("/:id/neighbors", userController.GetUserNeighbors)
+			users.GET("/:id/email-history", requireAuth, userController.GetEmailHistory)
+			users.GET("/:id/profile", userController.GetUserProfile)
+			users.GET("/:id/export", requireAuth, userController.ExportUserData)
+			users.PUT("/:id", jwtAuth, userController.UpdateUser)
+			users.PUT("/:id/status", jwtAuth, userController.SetUserStatus)
+			users.POST("/:id/reset", requireAdmin, userController.ResetUser)
+			users.POST("/:id/delete-request", jwtAuth, userController.RequestDeletion)
+			users.POST("/:id/cancel-deletion", jwtAuth, userController.CancelDeletion)
+			users.DELETE("/:id", jwtAuth, userController.DeleteUser)
+			users.POST("/:id/restore", jwtAuth, userController.RestoreUser)
 		}
 	}
 }
+
+// SetupHealthRoutes registers the readiness probe
+func SetupHealthRoutes(router *gin.Engine, healthController *controllers.HealthController) {
+	router.GET("/health/ready", healthController.Ready)
+}
+
+// SetupCapabilityRoutes registers the endpoint clients use to discover
+// which optional behavior this deployment offers. caching reports whether
+// Redis was reachable at startup.
+func SetupCapabilityRoutes(router *gin.Engine, features config.Features, authConfig config.AuthConfig, caching bool) {
+	capabilities := controllers.Capabilities{
+		Search:   true,
+		Auth:     authConfig.JWTSecret != "",
+		Caching:  caching,
+		Export:   features.IsEnabled(config.FeatureExport),
+		Features: features.States(),
+	}
+	router.GET("/api/v1/capabilities", apiV1, controllers.NewCapabilitiesController(capabilities).GetCapabilities)
+}
+
+// SetupAuthRoutes registers the token endpoints
+func SetupAuthRoutes(router *gin.Engine, authController *controllers.AuthController) {
+	authGroup := router.Group("/api/v1/auth", apiV1)
+	{
+		authGroup.POST("/introspect", authController.Introspect)
+		authGroup.GET("/whoami", authController.WhoAmI)
+	}
+}
+
+// SetupAdminRoutes registers the operator debugging endpoints, which are
+// only exposed when the debug feature is enabled
+func SetupAdminRoutes(router *gin.Engine, adminController *controllers.AdminController, features config.Features, authConfig config.AuthConfig) {
+	if !features.IsEnabled(config.FeatureDebug) {
+		return
+	}
+
+	admin := router.Group("/api/v1/admin", apiV1)
+	{
+		admin.GET("/schema", adminController.GetSchema)
+		admin.GET("/duplicates/emails", middleware.RequireAdmin(authConfig.JWTSecret), adminController.GetDuplicateEmails)
+	}
+}
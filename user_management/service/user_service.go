@@ -2,67 +2,354 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/IntouchOpec/user_management/cache"
 	"github.com/IntouchOpec/user_management/models"
 	"github.com/IntouchOpec/user_management/repository"
-	"github.com/go-redis/redis/v8"
+	"github.com/IntouchOpec/user_management/reqctx"
 )
 
 // UserService interface defines user business logic methods
 type UserService interface {
+	WithContext(ctx context.Context) UserService
 	CreateUser(req models.UserRequest) (*models.UserResponse, error)
 	GetUserByID(id uint) (*models.UserResponse, error)
+	GetUserByEmail(email string) (*models.UserResponse, error)
 	GetAllUsers(page, pageSize int) ([]models.UserResponse, int64, error)
-	UpdateUser(id uint, req models.UserRequest) (*models.UserResponse, error)
+	SearchUsers(filter models.UserFilter, sort models.UserSort, page, pageSize int) ([]models.UserResponse, int64, error)
+	GetUsersPage(filter models.UserFilter, sort models.UserSort, page, pageSize int) ([]models.UserResponse, bool, error)
+	GetUsersAfter(afterID uint, limit int) ([]models.UserResponse, *uint, error)
+	ExportUsers(filter models.UserFilter, fn func([]models.UserResponse) error) error
+	CountUsers() (int64, error)
+	UsersVersion() (models.CollectionVersion, error)
+	UpdateUser(id uint, req models.UserRequest) (*models.UserResponse, []string, error)
+	SetUserStatus(id uint, status models.UserStatus) (*models.UserResponse, error)
+	ResetUser(id uint) (*models.UserResponse, error)
 	DeleteUser(id uint) error
+	RestoreUser(id uint) (*models.UserResponse, error)
+	BulkCreateUsers(reqs []models.UserRequest) ([]models.UserResponse, error)
+	BulkUpdateUsers(items []models.BulkUpdateItem) ([]models.UserResponse, error)
+	BulkDeleteUsers(ids []uint) (int64, error)
+	DeleteInactiveUsers(before time.Time) (int64, error)
+	BulkSetActive(filter models.UserFilter, active bool) (int64, error)
+	BulkSetRole(filter models.UserFilter, role string, maxAffected int) (int64, error)
+	ImportUsers(reqs []models.UserRequest, strategy models.DuplicateStrategy) (*models.ImportResult, error)
+	FindSimilarUsers(id uint) ([]models.SimilarUser, error)
+	GetUserNeighbors(id uint) (*models.UserNeighbors, error)
+	CompareUsers(a, b uint) (*models.UserComparison, error)
+	SignupCalendar(year, month int) (*models.SignupCalendar, error)
+	GetRandomUser() (*models.UserResponse, error)
+	GetRecentUsers(limit int) ([]models.UserResponse, error)
+	CountFilteredUsers(filter models.UserFilter) (int64, error)
+	GetEmailHistory(id uint) ([]models.UserEmailHistory, error)
+	GetUserProfile(id uint) (*models.UserProfile, error)
+	ExportUserData(id uint) (*models.UserDataExport, error)
+	ScheduleDeletion(id uint) (*models.UserResponse, error)
+	CancelDeletion(id uint) (*models.UserResponse, error)
+	DeleteDueAccounts(now time.Time) (int64, error)
+	PreloadCache(ids []uint) error
+	WarmCache(limit int) error
+}
+
+const (
+	// cleanupBatchSize is the number of rows removed per statement by cleanup jobs
+	cleanupBatchSize = 500
+
+	// exportBatchSize is the number of users loaded per query during an export
+	exportBatchSize = 500
+
+	// defaultCacheTTL is how long cached users live unless configured with
+	// WithCacheTTL or overridden per request
+	defaultCacheTTL = 15 * time.Minute
+
+	// staleCacheTTL is how long fallback copies are kept for serving during
+	// database outages
+	staleCacheTTL = 24 * time.Hour
+
+	// countCacheTTL bounds how stale the cached total user count can get
+	countCacheTTL = time.Minute
+
+	// recentCacheTTL bounds how stale the cached newest users can get
+	recentCacheTTL = 30 * time.Second
+
+	// listCacheTTL is how long a cached list page lives. Writes orphan
+	// pages right away; the TTL only bounds staleness when the cache
+	// missed a version bump.
+	listCacheTTL = 30 * time.Second
+
+	// defaultDeletionGrace is how long a requested account deletion waits
+	// unless overridden
+	defaultDeletionGrace = 30 * 24 * time.Hour
+
+	// maxSimilarCandidates caps how many potential duplicates are scored
+	maxSimilarCandidates = 50
+)
+
+// similarityWeights scores each signal used to detect duplicate accounts
+var similarityWeights = []struct {
+	signal string
+	weight float64
+	match  func(a, b *models.User) bool
+}{
+	{signal: "name", weight: 0.4, match: func(a, b *models.User) bool {
+		return strings.EqualFold(strings.TrimSpace(a.Name), strings.TrimSpace(b.Name))
+	}},
+	{signal: "phone", weight: 0.4, match: func(a, b *models.User) bool {
+		phone := strings.TrimSpace(a.Phone)
+		return phone != "" && phone == strings.TrimSpace(b.Phone)
+	}},
+	{signal: "email", weight: 0.2, match: func(a, b *models.User) bool {
+		local := models.EmailLocalPart(a.Email)
+		return local != "" && local == models.EmailLocalPart(b.Email)
+	}},
 }
 
 // userService implements UserService interface
 type userService struct {
 	userRepo    repository.UserRepository
-	redisClient *redis.Client
+	cache       cache.Cache
 	ctx         context.Context
+	serveStale  bool
+	phoneRegion string
+	userTTL     time.Duration
+	cascade     bool
+
+	allowTimestampOverride bool
+	adminOnlyFields        map[string]bool
+	immutableFields        map[string]bool
+	deletionGrace          time.Duration
+	auditor                Auditor
+	minAge                 int
+	ageRequired            bool
+}
+
+// Auditor records audit entries, typically without blocking the caller
+type Auditor interface {
+	Record(entry models.AuditEntry)
+}
+
+// Option configures optional userService behavior
+type Option func(*userService)
+
+// WithServeStale keeps a long-lived copy of every cached user and serves it,
+// marked stale, when a lookup fails because the database is unreachable
+func WithServeStale(enabled bool) Option {
+	return func(s *userService) {
+		s.serveStale = enabled
+	}
+}
+
+// WithCacheTTL sets how long cached users live; values that are not
+// positive keep the 15 minute default
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(s *userService) {
+		if ttl > 0 {
+			s.userTTL = ttl
+		}
+	}
+}
+
+// WithPhoneRegion validates phone numbers on every write and stores them in
+// E.164 form, reading national-format numbers as belonging to region (an
+// ISO 3166-1 code such as TH). Numbers with a +country prefix keep their
+// own country. When region is empty phone numbers are stored as given.
+func WithPhoneRegion(region string) Option {
+	return func(s *userService) {
+		s.phoneRegion = strings.ToUpper(region)
+	}
+}
+
+// WithTimestampOverride lets admin imports keep the created_at supplied in
+// the file, so migrated users retain their original dates
+func WithTimestampOverride(enabled bool) Option {
+	return func(s *userService) {
+		s.allowTimestampOverride = enabled
+	}
+}
+
+// WithAdminOnlyFields restricts changes to the named fields (as reported
+// in an update's changed list, e.g. email or is_active) to admin callers,
+// on top of role, which always is. Other callers get a
+// *models.FieldForbiddenError naming the field.
+func WithAdminOnlyFields(fields []string) Option {
+	return func(s *userService) {
+		s.adminOnlyFields = make(map[string]bool, len(fields))
+		for _, field := range fields {
+			s.adminOnlyFields[field] = true
+		}
+	}
+}
+
+// WithImmutableFields forbids every change to the named fields (as
+// reported in an update's changed list), whoever the caller is. Updates
+// changing one get a *models.FieldImmutableError naming the field.
+func WithImmutableFields(fields []string) Option {
+	return func(s *userService) {
+		s.immutableFields = make(map[string]bool, len(fields))
+		for _, field := range fields {
+			s.immutableFields[field] = true
+		}
+	}
+}
+
+// WithDeletionGrace sets how long after a deletion request an account is
+// deleted; requests can be cancelled until then
+func WithDeletionGrace(grace time.Duration) Option {
+	return func(s *userService) {
+		s.deletionGrace = grace
+	}
+}
+
+// WithCascadeDelete makes DeleteUser soft delete the user's email history
+// along with the user, in the same transaction, instead of keeping it
+func WithCascadeDelete(enabled bool) Option {
+	return func(s *userService) {
+		s.cascade = enabled
+	}
+}
+
+// WithMinRegistrationAge rejects new users younger than age with a
+// *models.MinimumAgeError, on every create path: single and bulk creates
+// and imported rows. Updates are not checked.
+func WithMinRegistrationAge(age int) Option {
+	return func(s *userService) {
+		if age > 0 {
+			s.minAge = age
+		}
+	}
+}
+
+// WithAgeRequired rejects writes of requests whose AgeOmitted is set.
+// Without it a missing age is stored as 0; an explicit 0 is always valid.
+func WithAgeRequired(required bool) Option {
+	return func(s *userService) {
+		s.ageRequired = required
+	}
+}
+
+// WithAuditor records an audit entry for every user a write touches,
+// including one per row for bulk writes and imports. Bulk and scheduled
+// deletes are audited in their own transaction regardless.
+func WithAuditor(auditor Auditor) Option {
+	return func(s *userService) {
+		s.auditor = auditor
+	}
+}
+
+// NewUserService creates a new user service instance. A nil userCache
+// disables caching.
+func NewUserService(userRepo repository.UserRepository, userCache cache.Cache, opts ...Option) UserService {
+	s := &userService{
+		userRepo: userRepo,
+		cache:    userCache,
+		ctx:      context.Background(),
+
+		userTTL:       defaultCacheTTL,
+		deletionGrace: defaultDeletionGrace,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithContext returns a copy of the service bound to the given request context
+func (s *userService) WithContext(ctx context.Context) UserService {
+	scoped := *s
+	scoped.ctx = ctx
+	scoped.userRepo = s.userRepo.WithContext(ctx)
+	return &scoped
+}
+
+// normalizeRequest lowercases the request's email, checks its birth date,
+// rewrites its phone in E.164 form when a phone region is configured, and
+// then runs the request's validate tags. Binding only checks the JSON
+// shape, so this is where field rules are enforced for every write.
+func (s *userService) normalizeRequest(req *models.UserRequest) error {
+	if s.ageRequired && req.AgeOmitted {
+		return &models.ValidationError{Message: "validation failed", Fields: map[string]string{"age": "is required"}}
+	}
+	req.Email = models.NormalizeEmail(req.Email)
+	if err := models.CheckBirthDate(req.BirthDate, time.Now()); err != nil {
+		return err
+	}
+	if req.Role != "" {
+		role, err := models.ParseRole(req.Role)
+		if err != nil {
+			return err
+		}
+		req.Role = role
+	}
+	if s.phoneRegion != "" {
+		phone, err := models.NormalizePhone(req.Phone, s.phoneRegion)
+		if err != nil {
+			return err
+		}
+		req.Phone = phone
+	}
+	return models.Validate(req)
 }
 
-// NewUserService creates a new user service instance
-func NewUserService(userRepo repository.UserRepository, redisClient *redis.Client) UserService {
-	return &userService{
-		userRepo:    userRepo,
-		redisClient: redisClient,
-		ctx:         context.Background(),
+// checkRegistrationAge rejects a new user below the minimum registration
+// age, judged by the age the request would store
+func (s *userService) checkRegistrationAge(req models.UserRequest) error {
+	if req.CurrentAge(time.Now()) < s.minAge {
+		return &models.MinimumAgeError{MinAge: s.minAge}
 	}
+	return nil
 }
 
 // CreateUser creates a new user
 func (s *userService) CreateUser(req models.UserRequest) (*models.UserResponse, error) {
+	if err := s.normalizeRequest(&req); err != nil {
+		return nil, err
+	}
+	if err := s.checkRegistrationAge(req); err != nil {
+		return nil, err
+	}
+
 	// Check if user with email already exists
 	existingUser, _ := s.userRepo.GetByEmail(req.Email)
 	if existingUser != nil {
-		return nil, fmt.Errorf("user with email %s already exists", req.Email)
+		return nil, &models.EmailExistsError{Email: req.Email}
 	}
 
 	user := &models.User{
-		Name:     req.Name,
-		Email:    req.Email,
-		Age:      req.Age,
-		Phone:    req.Phone,
-		Address:  req.Address,
-		IsActive: true,
+		Name:      req.Name,
+		Email:     req.Email,
+		Age:       req.Age,
+		Phone:     req.Phone,
+		Address:   req.Address,
+		BirthDate: req.BirthDate,
+		Status:    models.StatusActive,
+		IsActive:  true,
 	}
 
 	if req.IsActive != nil {
-		user.IsActive = *req.IsActive
+		user.SetActive(*req.IsActive)
 	}
+	s.stampActor(user)
 
 	if err := s.userRepo.Create(user); err != nil {
-		return nil, fmt.Errorf("failed to create user: %v", err)
+		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
 	// Cache the user
 	s.cacheUser(user)
+	s.invalidateCount()
+	s.invalidateLists()
+	s.audit(models.AuditUserCreated, user)
 
 	response := user.ToResponse()
 	return &response, nil
@@ -78,6 +365,13 @@ func (s *userService) GetUserByID(id uint) (*models.UserResponse, error) {
 
 	user, err := s.userRepo.GetByID(id)
 	if err != nil {
+		if errors.Is(err, models.ErrDatabaseUnavailable) {
+			if staleUser := s.getStaleUser(id); staleUser != nil {
+				response := staleUser.ToResponse()
+				response.Stale = true
+				return &response, nil
+			}
+		}
 		return nil, err
 	}
 
@@ -88,6 +382,69 @@ func (s *userService) GetUserByID(id uint) (*models.UserResponse, error) {
 	return &response, nil
 }
 
+// GetUserByEmail retrieves a user by email, ignoring case. The user is
+// cached like GetUserByID does, so later lookups by ID or email hit the
+// cache.
+func (s *userService) GetUserByEmail(email string) (*models.UserResponse, error) {
+	email = models.NormalizeEmail(email)
+	if cachedUser := s.getCachedUserByEmail(email); cachedUser != nil {
+		response := cachedUser.ToResponse()
+		return &response, nil
+	}
+
+	user, err := s.userRepo.GetByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheUser(user)
+
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// GetRandomUser retrieves a user picked at random. The pick is not cached.
+func (s *userService) GetRandomUser() (*models.UserResponse, error) {
+	user, err := s.userRepo.GetRandom()
+	if err != nil {
+		return nil, err
+	}
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// GetRecentUsers returns the limit most recently created users, newest
+// first. Results are cached for recentCacheTTL, so a new signup can take
+// that long to appear.
+func (s *userService) GetRecentUsers(limit int) ([]models.UserResponse, error) {
+	key := s.recentCacheKey(limit)
+	if s.cache != nil {
+		if cached, err := s.cache.Get(s.ctx, key); err == nil {
+			var responses []models.UserResponse
+			if err := json.Unmarshal(cached, &responses); err == nil {
+				return responses, nil
+			}
+		}
+	}
+
+	users, err := s.userRepo.GetRecent(limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent users: %w", err)
+	}
+
+	responses := make([]models.UserResponse, len(users))
+	for i, user := range users {
+		responses[i] = user.ToResponse()
+	}
+
+	if s.cache != nil {
+		if data, err := json.Marshal(responses); err == nil {
+			s.cache.Set(s.ctx, key, data, recentCacheTTL)
+		}
+	}
+	return responses, nil
+}
+
 // GetAllUsers retrieves all users with pagination
 func (s *userService) GetAllUsers(page, pageSize int) ([]models.UserResponse, int64, error) {
 	if page < 1 {
@@ -99,14 +456,26 @@ func (s *userService) GetAllUsers(page, pageSize int) ([]models.UserResponse, in
 
 	offset := (page - 1) * pageSize
 
-	users, err := s.userRepo.GetAll(offset, pageSize)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get users: %v", err)
+	key, cacheable := s.listCacheKey("all", page, pageSize)
+	if cacheable {
+		if cached := s.getCachedPage(key); cached != nil {
+			return cached.Users, cached.Total, nil
+		}
 	}
 
 	total, err := s.userRepo.Count()
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count users: %v", err)
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	// Past the last page there is nothing to fetch, and a deep offset
+	// would still make the database skip every earlier row
+	if int64(offset) >= total {
+		return []models.UserResponse{}, total, nil
+	}
+
+	users, err := s.userRepo.GetAll(offset, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get users: %w", err)
 	}
 
 	var responses []models.UserResponse
@@ -115,91 +484,1362 @@ func (s *userService) GetAllUsers(page, pageSize int) ([]models.UserResponse, in
 		// Cache each user
 		s.cacheUser(&user)
 	}
+	if cacheable {
+		s.cachePage(key, responses, total)
+	}
+
+	return responses, total, nil
+}
+
+// SearchUsers retrieves users matching the filter in the given order with pagination
+func (s *userService) SearchUsers(filter models.UserFilter, sort models.UserSort, page, pageSize int) ([]models.UserResponse, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	offset := (page - 1) * pageSize
+
+	key, cacheable := s.listCacheKey(listQueryHash(filter, sort), page, pageSize)
+	if cacheable {
+		if cached := s.getCachedPage(key); cached != nil {
+			return cached.Users, cached.Total, nil
+		}
+	}
+
+	users, total, err := s.userRepo.Search(filter, sort, offset, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search users: %w", err)
+	}
+
+	responses := make([]models.UserResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, user.ToResponse())
+		s.cacheUser(&user)
+	}
+	if cacheable {
+		s.cachePage(key, responses, total)
+	}
 
 	return responses, total, nil
 }
 
-// UpdateUser updates an existing user
-func (s *userService) UpdateUser(id uint, req models.UserRequest) (*models.UserResponse, error) {
+// GetUsersAfter retrieves up to limit users following the user with ID
+// afterID, along with the cursor of the next page, nil on the last page
+func (s *userService) GetUsersAfter(afterID uint, limit int) ([]models.UserResponse, *uint, error) {
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	// One extra row tells whether another page follows
+	users, err := s.userRepo.GetAllCursor(afterID, limit+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	var next *uint
+	if len(users) > limit {
+		users = users[:limit]
+		next = &users[limit-1].ID
+	}
+
+	responses := make([]models.UserResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, user.ToResponse())
+		s.cacheUser(&user)
+	}
+
+	return responses, next, nil
+}
+
+// GetUsersPage retrieves a page of users matching the filter and whether a
+// next page exists, without counting the matches
+func (s *userService) GetUsersPage(filter models.UserFilter, sort models.UserSort, page, pageSize int) ([]models.UserResponse, bool, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	users, hasNext, err := s.userRepo.GetPage(page, pageSize, models.PageOptions{Filter: filter, Sort: sort})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get users page: %w", err)
+	}
+
+	responses := make([]models.UserResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, user.ToResponse())
+		s.cacheUser(&user)
+	}
+
+	return responses, hasNext, nil
+}
+
+// ExportUsers streams every user matching the filter to fn in id order, a
+// batch at a time. Exported users are not cached, since an export touches
+// far more users than are likely to be read again.
+func (s *userService) ExportUsers(filter models.UserFilter, fn func([]models.UserResponse) error) error {
+	return s.userRepo.FindInBatches(filter, exportBatchSize, func(users []models.User) error {
+		responses := make([]models.UserResponse, 0, len(users))
+		for _, user := range users {
+			responses = append(responses, user.ToResponse())
+		}
+		return fn(responses)
+	})
+}
+
+// GetEmailHistory retrieves the emails a user had before, oldest first.
+// Only the user themselves or an admin may read it; anyone else gets
+// models.ErrForbidden.
+func (s *userService) GetEmailHistory(id uint) ([]models.UserEmailHistory, error) {
+	if err := s.authorizeSelf(id); err != nil {
+		return nil, err
+	}
+
+	// Resolve the user first so missing and other tenants' users are 404s
+	if _, err := s.userRepo.GetByID(id); err != nil {
+		return nil, err
+	}
+
+	history, err := s.userRepo.GetEmailHistory(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email history: %w", err)
+	}
+	return history, nil
+}
+
+// GetUserProfile returns a user with their account stats. The user, email
+// history and audit count are fetched concurrently; the stats are only
+// used once the user lookup succeeds, so missing users are still 404s.
+func (s *userService) GetUserProfile(id uint) (*models.UserProfile, error) {
+	var (
+		wg         sync.WaitGroup
+		user       *models.UserResponse
+		userErr    error
+		history    []models.UserEmailHistory
+		historyErr error
+		audits     int64
+		auditsErr  error
+	)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		user, userErr = s.GetUserByID(id)
+	}()
+	go func() {
+		defer wg.Done()
+		history, historyErr = s.userRepo.GetEmailHistory(id)
+	}()
+	go func() {
+		defer wg.Done()
+		audits, auditsErr = s.userRepo.CountAuditEntries(id)
+	}()
+	wg.Wait()
+
+	if userErr != nil {
+		return nil, userErr
+	}
+	if historyErr != nil {
+		return nil, fmt.Errorf("failed to get email history: %w", historyErr)
+	}
+	if auditsErr != nil {
+		return nil, fmt.Errorf("failed to count audit entries: %w", auditsErr)
+	}
+
+	return &models.UserProfile{
+		User: *user,
+		Stats: models.UserStats{
+			DaysSinceSignup:  models.DaysSince(user.CreatedAt, time.Now()),
+			EmailChangeCount: len(history),
+			AuditEventCount:  audits,
+		},
+	}, nil
+}
+
+// ExportUserData collects everything stored about a user. Only the user
+// themselves or an admin may export it; anyone else gets
+// models.ErrForbidden.
+func (s *userService) ExportUserData(id uint) (*models.UserDataExport, error) {
+	if err := s.authorizeSelf(id); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
+	history, err := s.userRepo.GetEmailHistory(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email history: %w", err)
+	}
+	audit, err := s.userRepo.GetAuditEntries(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit log: %w", err)
+	}
 
-	// Check if email is being changed and if it already exists
-	if user.Email != req.Email {
-		existingUser, _ := s.userRepo.GetByEmail(req.Email)
-		if existingUser != nil && existingUser.ID != id {
-			return nil, fmt.Errorf("user with email %s already exists", req.Email)
+	return &models.UserDataExport{
+		User:         user.ToResponse(),
+		EmailHistory: history,
+		AuditLog:     audit,
+		ExportedAt:   time.Now().UTC(),
+	}, nil
+}
+
+// ScheduleDeletion marks the account for deletion once the grace period
+// has passed. Only the user themselves or an admin may request it, and
+// repeating the request keeps the original date.
+func (s *userService) ScheduleDeletion(id uint) (*models.UserResponse, error) {
+	if err := s.authorizeSelf(id); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletionScheduledAt == nil {
+		scheduled := time.Now().Add(s.deletionGrace).UTC()
+		user.DeletionScheduledAt = &scheduled
+		s.stampActor(user)
+		if err := s.userRepo.Update(user); err != nil {
+			return nil, fmt.Errorf("failed to schedule deletion: %w", err)
 		}
+		s.cacheUser(user)
+		s.invalidateLists()
+		s.audit(models.AuditUserDeletionScheduled, user)
 	}
 
-	user.UpdateFromRequest(req)
+	response := user.ToResponse()
+	return &response, nil
+}
 
-	if err := s.userRepo.Update(user); err != nil {
-		return nil, fmt.Errorf("failed to update user: %v", err)
+// CancelDeletion aborts a pending deletion request; accounts without one
+// are returned unchanged
+func (s *userService) CancelDeletion(id uint) (*models.UserResponse, error) {
+	if err := s.authorizeSelf(id); err != nil {
+		return nil, err
 	}
 
-	// Update cache
-	s.cacheUser(user)
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletionScheduledAt != nil {
+		user.DeletionScheduledAt = nil
+		s.stampActor(user)
+		if err := s.userRepo.Update(user); err != nil {
+			return nil, fmt.Errorf("failed to cancel deletion: %w", err)
+		}
+		s.cacheUser(user)
+		s.invalidateLists()
+		s.audit(models.AuditUserDeletionCanceled, user)
+	}
 
 	response := user.ToResponse()
 	return &response, nil
 }
 
-// DeleteUser deletes a user
-func (s *userService) DeleteUser(id uint) error {
-	if err := s.userRepo.Delete(id); err != nil {
-		return fmt.Errorf("failed to delete user: %v", err)
+// DeleteDueAccounts deletes every account whose scheduled deletion is due
+// at now and returns how many were removed
+func (s *userService) DeleteDueAccounts(now time.Time) (int64, error) {
+	deleted, err := s.userRepo.DeleteScheduledBefore(now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete scheduled accounts: %w", err)
 	}
 
-	// Remove from cache
-	s.removeCachedUser(id)
-
-	return nil
+	// The job runs without a tenant, so each tenant's keys are evicted
+	// under a context carrying that tenant
+	byTenant := make(map[string][]uint)
+	for _, user := range deleted {
+		byTenant[user.TenantID] = append(byTenant[user.TenantID], user.ID)
+	}
+	for tenantID, ids := range byTenant {
+		scoped := *s
+		scoped.ctx = reqctx.WithTenantID(s.ctx, tenantID)
+		scoped.removeCachedUsers(ids)
+		scoped.invalidateCount()
+		scoped.invalidateLists()
+	}
+	return int64(len(deleted)), nil
 }
 
-// cacheUser caches a user in Redis
-func (s *userService) cacheUser(user *models.User) {
-	if s.redisClient == nil {
-		return
+// CountUsers returns the total number of users, served from cache when possible
+func (s *userService) CountUsers() (int64, error) {
+	if s.cache != nil {
+		if cached, err := s.cache.Get(s.ctx, s.countCacheKey()); err == nil {
+			if total, err := strconv.ParseInt(string(cached), 10, 64); err == nil {
+				return total, nil
+			}
+		}
 	}
 
-	userJSON, err := json.Marshal(user)
+	total, err := s.userRepo.Count()
 	if err != nil {
-		return
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	if s.cache != nil {
+		s.cache.Set(s.ctx, s.countCacheKey(), []byte(strconv.FormatInt(total, 10)), countCacheTTL)
 	}
 
-	key := fmt.Sprintf("user:%d", user.ID)
-	s.redisClient.Set(s.ctx, key, userJSON, 15*time.Minute)
+	return total, nil
 }
 
-// getCachedUser retrieves a user from Redis cache
-func (s *userService) getCachedUser(id uint) *models.User {
-	if s.redisClient == nil {
-		return nil
+// CountFilteredUsers counts the users matching the filter. Without
+// criteria this is the cached total from CountUsers.
+func (s *userService) CountFilteredUsers(filter models.UserFilter) (int64, error) {
+	if filter.IsEmpty() {
+		return s.CountUsers()
 	}
 
-	key := fmt.Sprintf("user:%d", id)
-	userJSON, err := s.redisClient.Get(s.ctx, key).Result()
+	count, err := s.userRepo.CountFiltered(filter)
 	if err != nil {
-		return nil
+		return 0, fmt.Errorf("failed to count users: %w", err)
 	}
+	return count, nil
+}
 
-	var user models.User
-	if err := json.Unmarshal([]byte(userJSON), &user); err != nil {
-		return nil
+// UsersVersion returns the current state of the users collection, used to
+// answer conditional list requests
+func (s *userService) UsersVersion() (models.CollectionVersion, error) {
+	version, err := s.userRepo.CollectionVersion()
+	if err != nil {
+		return version, fmt.Errorf("failed to get collection version: %w", err)
 	}
-
-	return &user
+	return version, nil
 }
 
-// removeCachedUser removes a user from Redis cache
-func (s *userService) removeCachedUser(id uint) {
-	if s.redisClient == nil {
-		return
+// UpdateUser updates an existing user and returns the fields that changed.
+// A request matching the stored user writes nothing, so updated_at is left
+// alone.
+func (s *userService) UpdateUser(id uint, req models.UserRequest) (*models.UserResponse, []string, error) {
+	if err := s.normalizeRequest(&req); err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	key := fmt.Sprintf("user:%d", id)
-	s.redisClient.Del(s.ctx, key)
+	// Check if email is being changed and if it already exists
+	if user.Email != req.Email {
+		existingUser, _ := s.userRepo.GetByEmail(req.Email)
+		if existingUser != nil && existingUser.ID != id {
+			return nil, nil, &models.EmailExistsError{Email: req.Email}
+		}
+	}
+
+	previousEmail := user.Email
+	changed := user.UpdateFromRequest(req)
+	if err := s.authorizeChanges(changed); err != nil {
+		return nil, nil, err
+	}
+	if len(changed) == 0 {
+		response := user.ToResponse()
+		return &response, changed, nil
+	}
+	s.stampActor(user)
+
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	// Update cache
+	s.cacheUser(user)
+	s.invalidateLists()
+	if user.Email != previousEmail {
+		s.removeCachedEmails(previousEmail)
+	}
+	s.audit(models.AuditUserUpdated, user)
+
+	response := user.ToResponse()
+	return &response, changed, nil
+}
+
+// ResetUser clears the user's optional fields and reactivates the account
+// in a single write, whatever its current status. The fields it clears are
+// subject to the same field policy as an update.
+func (s *userService) ResetUser(id uint) (*models.UserResponse, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorizeChanges(user.Reset()); err != nil {
+		return nil, err
+	}
+	s.stampActor(user)
+
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, fmt.Errorf("failed to reset user: %w", err)
+	}
+	s.cacheUser(user)
+	s.invalidateLists()
+	s.audit(models.AuditUserUpdated, user)
+
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// SetUserStatus moves a user to a new status, rejecting changes the
+// transition rules forbid with a *models.StatusTransitionError
+func (s *userService) SetUserStatus(id uint, status models.UserStatus) (*models.UserResponse, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.Status.CanTransitionTo(status) {
+		return nil, &models.StatusTransitionError{From: user.Status, To: status}
+	}
+
+	if user.Status != status {
+		// Status drives is_active, so restricting either restricts both
+		if err := s.authorizeChanges([]string{"status", "is_active"}); err != nil {
+			return nil, err
+		}
+		user.Status = status
+		s.stampActor(user)
+		if err := s.userRepo.Update(user); err != nil {
+			return nil, fmt.Errorf("failed to update user status: %w", err)
+		}
+		s.cacheUser(user)
+		s.invalidateLists()
+		s.audit(models.AuditUserStatusChanged, user)
+	}
+
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// DeleteUser deletes a user, along with its email history under
+// WithCascadeDelete
+func (s *userService) DeleteUser(id uint) error {
+	deleteUser := s.userRepo.Delete
+	if s.cascade {
+		deleteUser = s.userRepo.DeleteWithDependents
+	}
+	if err := deleteUser(id); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	// Remove from cache
+	s.removeCachedUser(id)
+	s.invalidateCount()
+	s.invalidateLists()
+	s.auditID(models.AuditUserDeleted, id)
+
+	return nil
+}
+
+// RestoreUser brings back a soft-deleted user
+func (s *userService) RestoreUser(id uint) (*models.UserResponse, error) {
+	user, err := s.userRepo.GetByIDIncludingDeleted(id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.DeletedAt.Valid {
+		return nil, models.ErrUserNotDeleted
+	}
+
+	if err := s.userRepo.Restore(id); err != nil {
+		return nil, fmt.Errorf("failed to restore user: %w", err)
+	}
+	user.DeletedAt.Valid = false
+
+	s.cacheUser(user)
+	s.invalidateCount()
+	s.invalidateLists()
+	s.audit(models.AuditUserRestored, user)
+
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// BulkCreateUsers creates multiple users atomically
+func (s *userService) BulkCreateUsers(reqs []models.UserRequest) ([]models.UserResponse, error) {
+	seen := make(map[string]bool, len(reqs))
+	emails := make([]string, 0, len(reqs))
+	for i := range reqs {
+		if err := s.normalizeRequest(&reqs[i]); err != nil {
+			return nil, fmt.Errorf("users[%d]: %w", i, err)
+		}
+		if err := s.checkRegistrationAge(reqs[i]); err != nil {
+			return nil, fmt.Errorf("users[%d]: %w", i, err)
+		}
+		req := reqs[i]
+		if seen[req.Email] {
+			return nil, &models.ValidationError{Message: fmt.Sprintf("duplicate email %s in request", req.Email)}
+		}
+		seen[req.Email] = true
+		emails = append(emails, req.Email)
+	}
+
+	// Check every email for an existing owner in one query, reporting all
+	// conflicts at once
+	existing, err := s.userRepo.GetByEmails(emails)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email conflicts: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil, emailConflicts(emails, existing)
+	}
+
+	users := make([]*models.User, 0, len(reqs))
+	for _, req := range reqs {
+		user := &models.User{
+			Name:      req.Name,
+			Email:     req.Email,
+			Age:       req.Age,
+			Phone:     req.Phone,
+			Address:   req.Address,
+			BirthDate: req.BirthDate,
+			Status:    models.StatusActive,
+			IsActive:  true,
+		}
+		if req.IsActive != nil {
+			user.SetActive(*req.IsActive)
+		}
+		s.stampActor(user)
+		users = append(users, user)
+	}
+
+	if err := s.userRepo.CreateBatch(users); err != nil {
+		return nil, fmt.Errorf("failed to create users: %w", err)
+	}
+	s.invalidateCount()
+	s.invalidateLists()
+
+	responses := make([]models.UserResponse, 0, len(users))
+	for _, user := range users {
+		s.cacheUser(user)
+		s.audit(models.AuditUserCreated, user)
+		responses = append(responses, user.ToResponse())
+	}
+
+	return responses, nil
+}
+
+// BulkUpdateUsers updates multiple users atomically
+func (s *userService) BulkUpdateUsers(items []models.BulkUpdateItem) ([]models.UserResponse, error) {
+	seen := make(map[string]bool, len(items))
+	ids := make([]uint, 0, len(items))
+	for i := range items {
+		item := &items[i]
+		if err := s.normalizeRequest(&item.UserRequest); err != nil {
+			return nil, fmt.Errorf("user %d: %w", item.ID, err)
+		}
+		if seen[item.Email] {
+			return nil, &models.ValidationError{Message: fmt.Sprintf("duplicate email %s in request", item.Email)}
+		}
+		seen[item.Email] = true
+		ids = append(ids, uint(item.ID))
+	}
+
+	// Fetch every target in one query rather than one per item
+	found, err := s.userRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load users: %w", err)
+	}
+	existing := make(map[uint]*models.User, len(found))
+	for i := range found {
+		existing[found[i].ID] = &found[i]
+	}
+
+	var changedEmails, previousEmails []string
+	for _, item := range items {
+		user, ok := existing[uint(item.ID)]
+		if !ok {
+			return nil, fmt.Errorf("user %d: %w", item.ID, models.ErrUserNotFound)
+		}
+		if user.Email != item.Email {
+			changedEmails = append(changedEmails, item.Email)
+			previousEmails = append(previousEmails, user.Email)
+		}
+	}
+
+	// Check all new emails for conflicts in one query as well
+	owners := make(map[string]uint)
+	if len(changedEmails) > 0 {
+		conflicts, err := s.userRepo.GetByEmails(changedEmails)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check email conflicts: %w", err)
+		}
+		for _, owner := range conflicts {
+			owners[models.NormalizeEmail(owner.Email)] = owner.ID
+		}
+	}
+
+	users := make([]*models.User, 0, len(items))
+	for _, item := range items {
+		user := existing[uint(item.ID)]
+		if ownerID, taken := owners[item.Email]; taken && ownerID != uint(item.ID) {
+			return nil, &models.EmailExistsError{Email: item.Email}
+		}
+
+		if err := s.authorizeChanges(user.UpdateFromRequest(item.UserRequest)); err != nil {
+			return nil, fmt.Errorf("user %d: %w", item.ID, err)
+		}
+		s.stampActor(user)
+		users = append(users, user)
+	}
+
+	if err := s.userRepo.UpdateBatch(users); err != nil {
+		return nil, fmt.Errorf("failed to update users: %w", err)
+	}
+
+	responses := make([]models.UserResponse, 0, len(users))
+	for _, user := range users {
+		s.cacheUser(user)
+		s.audit(models.AuditUserUpdated, user)
+		responses = append(responses, user.ToResponse())
+	}
+	s.removeCachedEmails(previousEmails...)
+	s.invalidateLists()
+
+	return responses, nil
+}
+
+// BulkDeleteUsers deletes multiple users and returns the number removed
+func (s *userService) BulkDeleteUsers(ids []uint) (int64, error) {
+	var deletedBy *uint
+	if actorID, ok := reqctx.ActorID(s.ctx); ok {
+		deletedBy = &actorID
+	}
+
+	// The cache is only touched once the delete has committed, so a rolled
+	// back batch leaves it intact
+	deleted, err := s.userRepo.DeleteByIDs(ids, deletedBy)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete users: %w", err)
+	}
+
+	s.removeCachedUsers(deleted)
+	s.invalidateCount()
+	s.invalidateLists()
+
+	return int64(len(deleted)), nil
+}
+
+// DeleteInactiveUsers soft deletes inactive users last updated before the
+// cutoff and returns the number removed
+func (s *userService) DeleteInactiveUsers(before time.Time) (int64, error) {
+	ids, err := s.userRepo.DeleteWhere(cleanupBatchSize, "is_active = ? AND updated_at < ?", false, before)
+	for _, id := range ids {
+		s.removeCachedUser(id)
+		s.auditID(models.AuditUserDeleted, id)
+	}
+	if len(ids) > 0 {
+		s.invalidateCount()
+		s.invalidateLists()
+	}
+	if err != nil {
+		return int64(len(ids)), fmt.Errorf("failed to delete inactive users: %w", err)
+	}
+
+	return int64(len(ids)), nil
+}
+
+// BulkSetActive activates or deactivates every user matching the filter and
+// returns the number of users affected
+func (s *userService) BulkSetActive(filter models.UserFilter, active bool) (int64, error) {
+	if err := s.authorizeChanges([]string{"is_active"}); err != nil {
+		return 0, err
+	}
+
+	var updatedBy *uint
+	if actorID, ok := reqctx.ActorID(s.ctx); ok {
+		updatedBy = &actorID
+	}
+
+	ids, err := s.userRepo.SetActiveByFilter(filter, active, updatedBy)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update users: %w", err)
+	}
+
+	for _, id := range ids {
+		s.removeCachedUser(id)
+		s.auditID(models.AuditUserUpdated, id)
+	}
+	if len(ids) > 0 {
+		s.invalidateLists()
+	}
+
+	return int64(len(ids)), nil
+}
+
+// BulkSetRole assigns role to every user matching the filter and returns
+// the number of users affected. It is rejected outright when role is an
+// immutable field, and undone with a *models.ConfirmationRequiredError
+// when it affects more than a positive maxAffected users.
+func (s *userService) BulkSetRole(filter models.UserFilter, role string, maxAffected int) (int64, error) {
+	if err := s.authorizeChanges([]string{"role"}); err != nil {
+		return 0, err
+	}
+
+	var updatedBy *uint
+	if actorID, ok := reqctx.ActorID(s.ctx); ok {
+		updatedBy = &actorID
+	}
+
+	ids, err := s.userRepo.SetRoleByFilter(filter, role, updatedBy, maxAffected)
+	var unconfirmed *models.ConfirmationRequiredError
+	if errors.As(err, &unconfirmed) {
+		return 0, err
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to update users: %w", err)
+	}
+
+	for _, id := range ids {
+		s.removeCachedUser(id)
+		s.auditID(models.AuditUserUpdated, id)
+	}
+	if len(ids) > 0 {
+		s.invalidateLists()
+	}
+
+	return int64(len(ids)), nil
+}
+
+// ImportUsers creates users from imported rows, resolving rows whose email
+// already exists (in the database or earlier in the import) by strategy
+func (s *userService) ImportUsers(reqs []models.UserRequest, strategy models.DuplicateStrategy) (*models.ImportResult, error) {
+	result := &models.ImportResult{}
+	seen := make(map[string]*models.User, len(reqs))
+	var creates, updates []*models.User
+
+	emails := make([]string, 0, len(reqs))
+	for i := range reqs {
+		if err := s.normalizeRequest(&reqs[i]); err != nil {
+			return nil, fmt.Errorf("users[%d]: %w", i, err)
+		}
+		emails = append(emails, reqs[i].Email)
+	}
+	found, err := s.userRepo.GetByEmails(emails)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email conflicts: %w", err)
+	}
+	if strategy == models.DuplicateError && len(found) > 0 {
+		return nil, emailConflicts(emails, found)
+	}
+	existing := make(map[string]*models.User, len(found))
+	for i := range found {
+		existing[models.NormalizeEmail(found[i].Email)] = &found[i]
+	}
+	keepCreatedAt := s.allowTimestampOverride && reqctx.IsAdmin(s.ctx)
+
+	for i, req := range reqs {
+		key := strings.ToLower(req.Email)
+		user, pending := seen[key]
+		if !pending {
+			user = existing[req.Email]
+		}
+
+		if user == nil {
+			if err := s.checkRegistrationAge(req); err != nil {
+				return nil, fmt.Errorf("users[%d]: %w", i, err)
+			}
+			user = &models.User{
+				Name:      req.Name,
+				Email:     req.Email,
+				Age:       req.Age,
+				Phone:     req.Phone,
+				Address:   req.Address,
+				BirthDate: req.BirthDate,
+				Status:    models.StatusActive,
+				IsActive:  true,
+			}
+			if req.IsActive != nil {
+				user.SetActive(*req.IsActive)
+			}
+			if keepCreatedAt && req.CreatedAt != nil {
+				user.CreatedAt = *req.CreatedAt
+			}
+			s.stampActor(user)
+			seen[key] = user
+			creates = append(creates, user)
+			continue
+		}
+
+		switch strategy {
+		case models.DuplicateUpdate:
+			if err := s.authorizeChanges(user.UpdateFromRequest(req)); err != nil {
+				return nil, fmt.Errorf("users[%d]: %w", i, err)
+			}
+			s.stampActor(user)
+			if !pending {
+				seen[key] = user
+				updates = append(updates, user)
+			}
+		default:
+			result.Skipped++
+		}
+	}
+
+	if len(creates) > 0 {
+		if err := s.userRepo.CreateBatch(creates); err != nil {
+			return nil, fmt.Errorf("failed to create users: %w", err)
+		}
+		s.invalidateCount()
+	}
+	if len(updates) > 0 {
+		if err := s.userRepo.UpdateBatch(updates); err != nil {
+			return nil, fmt.Errorf("failed to update users: %w", err)
+		}
+	}
+	if len(creates) > 0 || len(updates) > 0 {
+		s.invalidateLists()
+	}
+
+	for _, user := range creates {
+		s.cacheUser(user)
+		s.audit(models.AuditUserCreated, user)
+	}
+	for _, user := range updates {
+		s.cacheUser(user)
+		s.audit(models.AuditUserUpdated, user)
+	}
+
+	result.Created = len(creates)
+	result.Updated = len(updates)
+	return result, nil
+}
+
+// emailConflicts builds the error for requested emails that already belong
+// to users, listing them in request order
+func emailConflicts(requested []string, owners []models.User) *models.EmailExistsError {
+	taken := make(map[string]bool, len(owners))
+	for _, owner := range owners {
+		taken[models.NormalizeEmail(owner.Email)] = true
+	}
+
+	conflict := &models.EmailExistsError{}
+	for _, email := range requested {
+		if taken[email] {
+			conflict.Emails = append(conflict.Emails, email)
+			taken[email] = false
+		}
+	}
+	if len(conflict.Emails) > 0 {
+		conflict.Email = conflict.Emails[0]
+	}
+	return conflict
+}
+
+// FindSimilarUsers returns potential duplicates of the user, highest score first
+func (s *userService) FindSimilarUsers(id uint) ([]models.SimilarUser, error) {
+	user, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.userRepo.FindSimilar(user, maxSimilarCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find similar users: %w", err)
+	}
+
+	similar := make([]models.SimilarUser, 0, len(candidates))
+	for i := range candidates {
+		match := models.SimilarUser{User: candidates[i].ToResponse(), Matches: []string{}}
+		for _, w := range similarityWeights {
+			if w.match(user, &candidates[i]) {
+				match.Score += w.weight
+				match.Matches = append(match.Matches, w.signal)
+			}
+		}
+		if match.Score > 0 {
+			match.Score = math.Round(match.Score*100) / 100
+			similar = append(similar, match)
+		}
+	}
+
+	sort.SliceStable(similar, func(i, j int) bool {
+		return similar[i].Score > similar[j].Score
+	})
+
+	return similar, nil
+}
+
+// GetUserNeighbors returns the users before and after the user in ID order
+func (s *userService) GetUserNeighbors(id uint) (*models.UserNeighbors, error) {
+	if _, err := s.userRepo.GetByID(id); err != nil {
+		return nil, err
+	}
+
+	prev, next, err := s.userRepo.FindNeighbors(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find neighboring users: %w", err)
+	}
+
+	neighbors := &models.UserNeighbors{}
+	if prev != nil {
+		response := prev.ToResponse()
+		neighbors.Previous = &response
+	}
+	if next != nil {
+		response := next.ToResponse()
+		neighbors.Next = &response
+	}
+	return neighbors, nil
+}
+
+// CompareUsers diffs two users field by field, loading both in one query
+func (s *userService) CompareUsers(a, b uint) (*models.UserComparison, error) {
+	users, err := s.userRepo.GetByIDs([]uint{a, b})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	byID := make(map[uint]*models.User, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+	for _, id := range []uint{a, b} {
+		if byID[id] == nil {
+			return nil, fmt.Errorf("user %d: %w", id, models.ErrUserNotFound)
+		}
+	}
+
+	comparison := models.CompareUsers(byID[a], byID[b])
+	return &comparison, nil
+}
+
+// SignupCalendar counts signups per UTC day of the given month, with an
+// entry for every day of the month
+func (s *userService) SignupCalendar(year, month int) (*models.SignupCalendar, error) {
+	from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	counts, err := s.userRepo.CountCreatedByDay(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count signups: %w", err)
+	}
+
+	calendar := &models.SignupCalendar{Year: year, Month: month, Days: []models.CalendarDay{}}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		calendar.Days = append(calendar.Days, models.CalendarDay{Date: date, Count: counts[date]})
+		calendar.Total += counts[date]
+	}
+	return calendar, nil
+}
+
+// PreloadCache warms the cache for users a caller expects to need soon.
+// IDs already cached are skipped; the rest are loaded in one query and
+// cached in one batch. Unknown IDs are ignored, and without a cache this
+// does nothing.
+func (s *userService) PreloadCache(ids []uint) error {
+	if s.cache == nil || len(ids) == 0 {
+		return nil
+	}
+
+	seen := make(map[uint]bool, len(ids))
+	var unique []uint
+	var keys []string
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+			keys = append(keys, s.cacheKey(id))
+		}
+	}
+
+	// A failed lookup just means every ID is treated as uncached
+	cached, _ := s.cache.GetMany(s.ctx, keys...)
+	var missing []uint
+	for i, id := range unique {
+		if i >= len(cached) || cached[i] == nil {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	users, err := s.userRepo.GetByIDs(missing)
+	if err != nil {
+		return fmt.Errorf("failed to load users: %w", err)
+	}
+	return s.cacheUsers(users)
+}
+
+// WarmCache caches the limit most recently created users, who are the
+// likeliest to be read right after a deploy. Without a cache this does
+// nothing.
+func (s *userService) WarmCache(limit int) error {
+	if s.cache == nil || limit <= 0 {
+		return nil
+	}
+
+	users, err := s.userRepo.GetRecent(limit)
+	if err != nil {
+		return fmt.Errorf("failed to load users: %w", err)
+	}
+	return s.cacheUsers(users)
+}
+
+// cacheUsers caches several users in one batch
+func (s *userService) cacheUsers(users []models.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	fresh := make(map[string][]byte, len(users))
+	stale := make(map[string][]byte)
+	for i := range users {
+		userJSON, err := json.Marshal(&users[i])
+		if err != nil {
+			continue
+		}
+		fresh[s.cacheKey(users[i].ID)] = userJSON
+		fresh[s.emailCacheKey(users[i].Email)] = []byte(strconv.FormatUint(uint64(users[i].ID), 10))
+		if s.serveStale {
+			stale[s.staleCacheKey(users[i].ID)] = userJSON
+		}
+	}
+
+	if err := s.cache.SetMany(s.ctx, fresh, s.cacheTTL()); err != nil {
+		return fmt.Errorf("failed to cache users: %w", err)
+	}
+	if err := s.cache.SetMany(s.ctx, stale, staleCacheTTL); err != nil {
+		return fmt.Errorf("failed to cache users: %w", err)
+	}
+	return nil
+}
+
+// audit records action on user
+func (s *userService) audit(action models.AuditAction, user *models.User) {
+	s.record(models.AuditEntry{Action: action, UserID: user.ID, TenantID: user.TenantID})
+}
+
+// auditID records action on the user with id, for writes that only return
+// IDs. Those writes are scoped to the request's tenant.
+func (s *userService) auditID(action models.AuditAction, id uint) {
+	tenantID, _ := reqctx.TenantID(s.ctx)
+	s.record(models.AuditEntry{Action: action, UserID: id, TenantID: tenantID})
+}
+
+// record hands entry to the auditor, attributed to the requesting user when
+// known and tagged with the request ID
+func (s *userService) record(entry models.AuditEntry) {
+	if s.auditor == nil {
+		return
+	}
+	if actorID, ok := reqctx.ActorID(s.ctx); ok {
+		entry.ActorID = &actorID
+	}
+	entry.RequestID, _ = reqctx.RequestID(s.ctx)
+	s.auditor.Record(entry)
+}
+
+// authorizeSelf allows admins and the user identified by id, rejecting
+// everyone else with models.ErrForbidden
+func (s *userService) authorizeSelf(id uint) error {
+	if reqctx.IsAdmin(s.ctx) {
+		return nil
+	}
+	if actorID, ok := reqctx.ActorID(s.ctx); ok && actorID == id {
+		return nil
+	}
+	return models.ErrForbidden
+}
+
+// authorizeChanges rejects changes to immutable fields, and to admin-only
+// fields unless the caller is an admin. Role is always admin-only, so no
+// caller can grant themselves admin. Every update path runs its changed
+// fields through here, so this is the one place the policy is applied.
+func (s *userService) authorizeChanges(changed []string) error {
+	for _, field := range changed {
+		if s.immutableFields[field] {
+			return &models.FieldImmutableError{Field: field}
+		}
+	}
+	if reqctx.IsAdmin(s.ctx) {
+		return nil
+	}
+	for _, field := range changed {
+		if field == "role" || s.adminOnlyFields[field] {
+			return &models.FieldForbiddenError{Field: field}
+		}
+	}
+	return nil
+}
+
+// stampActor records the requesting user, when known, as the last modifier
+func (s *userService) stampActor(user *models.User) {
+	if actorID, ok := reqctx.ActorID(s.ctx); ok {
+		user.UpdatedBy = &actorID
+	}
+}
+
+// cacheUser caches a user under its ID, and its ID under its email
+func (s *userService) cacheUser(user *models.User) {
+	if s.cache == nil {
+		return
+	}
+
+	userJSON, err := json.Marshal(user)
+	if err != nil {
+		return
+	}
+
+	s.cache.SetMany(s.ctx, map[string][]byte{
+		s.cacheKey(user.ID):         userJSON,
+		s.emailCacheKey(user.Email): []byte(strconv.FormatUint(uint64(user.ID), 10)),
+	}, s.cacheTTL())
+	if s.serveStale {
+		s.cache.Set(s.ctx, s.staleCacheKey(user.ID), userJSON, staleCacheTTL)
+	}
+}
+
+// cacheKey returns the cache key for a user, namespaced by tenant so cached
+// entries never leak across tenants
+func (s *userService) cacheKey(id uint) string {
+	if tenantID, ok := reqctx.TenantID(s.ctx); ok {
+		return fmt.Sprintf("user:%s:%d", tenantID, id)
+	}
+	return fmt.Sprintf("user:%d", id)
+}
+
+// emailCacheKey returns the cache key holding the ID of the user with email,
+// namespaced by tenant like cacheKey
+func (s *userService) emailCacheKey(email string) string {
+	if tenantID, ok := reqctx.TenantID(s.ctx); ok {
+		return fmt.Sprintf("user:%s:email:%s", tenantID, email)
+	}
+	return "user:email:" + email
+}
+
+// staleCacheKey returns the cache key for the long-lived fallback copy of a user
+func (s *userService) staleCacheKey(id uint) string {
+	return "stale:" + s.cacheKey(id)
+}
+
+// countCacheKey returns the cache key for the cached total user count
+func (s *userService) countCacheKey() string {
+	if tenantID, ok := reqctx.TenantID(s.ctx); ok {
+		return fmt.Sprintf("users:count:%s", tenantID)
+	}
+	return "users:count"
+}
+
+// recentCacheKey returns the cache key for the newest limit users
+func (s *userService) recentCacheKey(limit int) string {
+	if tenantID, ok := reqctx.TenantID(s.ctx); ok {
+		return fmt.Sprintf("users:recent:%s:%d", tenantID, limit)
+	}
+	return fmt.Sprintf("users:recent:%d", limit)
+}
+
+// listVersionKey returns the cache key of the counter that versions every
+// cached list page
+func (s *userService) listVersionKey() string {
+	if tenantID, ok := reqctx.TenantID(s.ctx); ok {
+		return fmt.Sprintf("users:list:version:%s", tenantID)
+	}
+	return "users:list:version"
+}
+
+// listCacheKey returns the cache key for a page of the list identified by
+// query, at the current list version. It reports false when there is no
+// cache or the version cannot be read, in which case the page must not be
+// cached.
+func (s *userService) listCacheKey(query string, page, pageSize int) (string, bool) {
+	if s.cache == nil {
+		return "", false
+	}
+
+	var version int64
+	value, err := s.cache.Get(s.ctx, s.listVersionKey())
+	switch {
+	case err == nil:
+		if version, err = strconv.ParseInt(string(value), 10, 64); err != nil {
+			return "", false
+		}
+	case !errors.Is(err, cache.ErrMiss):
+		return "", false
+	}
+
+	prefix := "users"
+	if tenantID, ok := reqctx.TenantID(s.ctx); ok {
+		prefix = "users:" + tenantID
+	}
+	return fmt.Sprintf("%s:page:%d:size:%d:%s:v%d", prefix, page, pageSize, query, version), true
+}
+
+// listQueryHash identifies a filtered and sorted list in list cache keys
+func listQueryHash(filter models.UserFilter, sort models.UserSort) string {
+	data, _ := json.Marshal(struct {
+		Filter models.UserFilter
+		Sort   models.UserSort
+	}{filter, sort})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// cachedPage is a list page as cached, with the total it reported
+type cachedPage struct {
+	Users []models.UserResponse `json:"users"`
+	Total int64                 `json:"total"`
+}
+
+// getCachedPage retrieves a list page from the cache
+func (s *userService) getCachedPage(key string) *cachedPage {
+	data, err := s.cache.Get(s.ctx, key)
+	if err != nil {
+		return nil
+	}
+
+	var page cachedPage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil
+	}
+	return &page
+}
+
+// cachePage caches a list page for listCacheTTL
+func (s *userService) cachePage(key string, users []models.UserResponse, total int64) {
+	data, err := json.Marshal(cachedPage{Users: users, Total: total})
+	if err != nil {
+		return
+	}
+	s.cache.Set(s.ctx, key, data, listCacheTTL)
+}
+
+// invalidateLists bumps the list version after users are added, removed or
+// modified, so no page cached before the write is served again
+func (s *userService) invalidateLists() {
+	if s.cache == nil {
+		return
+	}
+
+	s.cache.Incr(s.ctx, s.listVersionKey())
+}
+
+// invalidateCount drops the cached total user count after rows are added or removed
+func (s *userService) invalidateCount() {
+	if s.cache == nil {
+		return
+	}
+
+	s.cache.Del(s.ctx, s.countCacheKey())
+}
+
+// cacheTTL returns the TTL for cache writes, honoring any per-request override
+func (s *userService) cacheTTL() time.Duration {
+	if ttl, ok := reqctx.CacheTTL(s.ctx); ok {
+		return ttl
+	}
+	return s.userTTL
+}
+
+// getCachedUser retrieves a user from the cache
+func (s *userService) getCachedUser(id uint) *models.User {
+	if s.cache == nil {
+		return nil
+	}
+
+	userJSON, err := s.cache.Get(s.ctx, s.cacheKey(id))
+	if err != nil {
+		return nil
+	}
+
+	var user models.User
+	if err := json.Unmarshal(userJSON, &user); err != nil {
+		return nil
+	}
+
+	return &user
+}
+
+// getCachedUserByEmail retrieves a user from the cache through its email
+// entry. An entry left pointing at a user whose email has since changed is
+// treated as a miss.
+func (s *userService) getCachedUserByEmail(email string) *models.User {
+	if s.cache == nil {
+		return nil
+	}
+
+	idValue, err := s.cache.Get(s.ctx, s.emailCacheKey(email))
+	if err != nil {
+		return nil
+	}
+	id, err := strconv.ParseUint(string(idValue), 10, 0)
+	if err != nil {
+		return nil
+	}
+
+	user := s.getCachedUser(uint(id))
+	if user == nil || models.NormalizeEmail(user.Email) != email {
+		return nil
+	}
+	return user
+}
+
+// getStaleUser retrieves the fallback copy of a user kept for database outages
+func (s *userService) getStaleUser(id uint) *models.User {
+	if s.cache == nil || !s.serveStale {
+		return nil
+	}
+
+	userJSON, err := s.cache.Get(s.ctx, s.staleCacheKey(id))
+	if err != nil {
+		return nil
+	}
+
+	var user models.User
+	if err := json.Unmarshal(userJSON, &user); err != nil {
+		return nil
+	}
+
+	return &user
+}
+
+// removeCachedUsers removes several users from the cache in one call,
+// along with the email entries of those that were cached
+func (s *userService) removeCachedUsers(ids []uint) {
+	if s.cache == nil || len(ids) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, 3*len(ids))
+	for _, id := range ids {
+		keys = append(keys, s.cacheKey(id))
+	}
+	// A failed lookup only leaves email entries behind, and those are
+	// checked against the user they point at before being trusted
+	cached, _ := s.cache.GetMany(s.ctx, keys...)
+	for _, userJSON := range cached {
+		var user models.User
+		if userJSON != nil && json.Unmarshal(userJSON, &user) == nil {
+			keys = append(keys, s.emailCacheKey(user.Email))
+		}
+	}
+	for _, id := range ids {
+		keys = append(keys, s.staleCacheKey(id))
+	}
+	s.cache.Del(s.ctx, keys...)
+}
+
+// removeCachedUser removes a user from the cache
+func (s *userService) removeCachedUser(id uint) {
+	s.removeCachedUsers([]uint{id})
+}
+
+// removeCachedEmails drops the email entries of addresses users no longer have
+func (s *userService) removeCachedEmails(emails ...string) {
+	if s.cache == nil || len(emails) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(emails))
+	for _, email := range emails {
+		keys = append(keys, s.emailCacheKey(email))
+	}
+	s.cache.Del(s.ctx, keys...)
 }
@@ -3,6 +3,8 @@
 # API Testing Script
 
 BASE_URL="http://localhost:8080"
+# Writes need a bearer token signed with the server's JWT_SECRET
+AUTH_HEADER="Authorization: Bearer ${TOKEN}"
 
 echo "🚀 User Management API Test Suite"
 echo "=================================="
@@ -15,6 +17,7 @@ echo ""
 # Test 2: Create User
 echo "👤 Creating a new user..."
 USER_RESPONSE=$(curl -s -X POST "${BASE_URL}/api/v1/users" \
+  -H "${AUTH_HEADER}" \
   -H "Content-Type: application/json" \
   -d '{
     "name": "John Doe",
@@ -45,6 +48,7 @@ if [ "$USER_ID" != "null" ] && [ "$USER_ID" != "" ]; then
   # Test 5: Update User
   echo "✏️ Updating user..."
   curl -s -X PUT "${BASE_URL}/api/v1/users/${USER_ID}" \
+    -H "${AUTH_HEADER}" \
     -H "Content-Type: application/json" \
     -d '{
       "name": "John Updated",
@@ -57,7 +61,7 @@ if [ "$USER_ID" != "null" ] && [ "$USER_ID" != "" ]; then
 
   # Test 6: Delete User
   echo "🗑️ Deleting user..."
-  curl -s -X DELETE "${BASE_URL}/api/v1/users/${USER_ID}" | jq . || echo "❌ Delete user failed"
+  curl -s -X DELETE "${BASE_URL}/api/v1/users/${USER_ID}" -H "${AUTH_HEADER}" | jq . || echo "❌ Delete user failed"
   echo ""
 fi
 
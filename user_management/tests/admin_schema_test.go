@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/config"
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/database"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/routes"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminController_GetSchema(t *testing.T) {
+	setupTestDB(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.SetupAdminRoutes(router, controllers.NewAdminController(), config.Features{config.FeatureDebug: true}, config.AuthConfig{})
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/admin/schema", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Table   string            `json:"table"`
+		Columns []database.Column `json:"columns"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "users", response.Table)
+
+	columns := make(map[string]database.Column)
+	for _, column := range response.Columns {
+		columns[column.Name] = column
+	}
+	for _, name := range []string{"id", "name", "email", "age", "phone", "address", "is_active", "updated_by", "tenant_id", "created_at", "updated_at", "deleted_at"} {
+		assert.Contains(t, columns, name)
+	}
+	assert.NotEmpty(t, columns["email"].Type)
+	if pk := columns["id"].PrimaryKey; assert.NotNil(t, pk) {
+		assert.True(t, *pk)
+	}
+}
+
+func TestSetupAdminRoutes_RequiresDebugFeature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.SetupAdminRoutes(router, controllers.NewAdminController(), nil, config.AuthConfig{})
+
+	assert.Empty(t, router.Routes())
+
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/admin/schema", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAdminController_GetDuplicateEmails(t *testing.T) {
+	db := setupTestDB(t)
+
+	// A soft-deleted account's address may be reused
+	reused := &models.User{Name: "Old Account", Email: "reused@example.com", Age: 30}
+	require.NoError(t, db.Create(reused).Error)
+	require.NoError(t, db.Delete(reused).Error)
+	require.NoError(t, db.Create(&models.User{Name: "New Account", Email: "Reused@Example.com", Age: 31}).Error)
+	require.NoError(t, db.Create(&models.User{Name: "Unique", Email: "unique@example.com", Age: 32}).Error)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.SetupAdminRoutes(router, controllers.NewAdminController(), config.Features{config.FeatureDebug: true}, config.AuthConfig{JWTSecret: testJWTSecret})
+
+	get := func(authorization string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/admin/duplicates/emails", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("Bearer " + signTestToken(t, "1", "admin", time.Hour))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data  []database.DuplicateEmail `json:"data"`
+		Total int                       `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Total)
+	assert.Equal(t, []database.DuplicateEmail{{Email: "reused@example.com", Count: 2, Active: 1, Deleted: 1}}, response.Data)
+
+	assert.Equal(t, http.StatusUnauthorized, get("").Code)
+	assert.Equal(t, http.StatusForbidden, get("Bearer "+signTestToken(t, "2", "user", time.Hour)).Code)
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserController_AgeRequired(t *testing.T) {
+	for _, required := range []bool{true, false} {
+		t.Run(fmt.Sprintf("required=%t", required), func(t *testing.T) {
+			db := setupTestDB(t)
+			userService := service.NewUserService(repository.NewUserRepository(db), nil, service.WithAgeRequired(required))
+			controller := controllers.NewUserController(userService, controllers.WithAgeRequired(required))
+			router := setupTestRouter()
+			router.POST("/users", controller.CreateUser)
+			router.POST("/users/bulk", controller.BulkCreateUsers)
+			router.PUT("/users/:id", controller.UpdateUser)
+
+			send := func(method, path, body string) (int, map[string]interface{}) {
+				req, _ := http.NewRequest(method, path, strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				var response map[string]interface{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				return w.Code, response
+			}
+
+			// An infant's age of 0 is an age, not a missing one
+			status, response := send(http.MethodPost, "/users", `{"name": "Baby Doe", "email": "baby@example.com", "age": 0}`)
+			require.Equal(t, http.StatusCreated, status, response)
+			data := response["data"].(map[string]interface{})
+			assert.Equal(t, float64(0), data["age"])
+			path := fmt.Sprintf("/users/%v", data["id"])
+
+			status, _ = send(http.MethodPut, path, `{"name": "Baby Doe", "email": "baby@example.com", "age": 0}`)
+			assert.Equal(t, http.StatusOK, status)
+
+			creates, creation := send(http.MethodPost, "/users", `{"name": "John Doe", "email": "john@example.com"}`)
+			updates, update := send(http.MethodPut, path, `{"name": "Baby Doe", "email": "baby@example.com"}`)
+			bulkCreates, bulkCreation := send(http.MethodPost, "/users/bulk", `{"users": [{"name": "Jane Doe", "email": "jane@example.com"}]}`)
+			if !required {
+				assert.Equal(t, http.StatusCreated, creates)
+				assert.Equal(t, float64(0), creation["data"].(map[string]interface{})["age"], "a missing age is stored as 0")
+				assert.Equal(t, http.StatusOK, updates)
+				assert.Equal(t, http.StatusCreated, bulkCreates)
+				return
+			}
+
+			for _, response := range []map[string]interface{}{creation, update, bulkCreation} {
+				assert.Equal(t, models.CodeValidationFailed, response["code"])
+				assert.Equal(t, map[string]interface{}{"age": "is required"}, response["fields"])
+			}
+			assert.Equal(t, http.StatusBadRequest, creates)
+			assert.Equal(t, http.StatusBadRequest, updates)
+			assert.Equal(t, http.StatusBadRequest, bulkCreates)
+			var count int64
+			require.NoError(t, db.Model(&models.User{}).Where("email = ?", "john@example.com").Count(&count).Error)
+			assert.Zero(t, count)
+		})
+	}
+}
+
+func TestUserController_ValidateUsers_AgeRequired(t *testing.T) {
+	body := `[
+		{"name": "Baby Doe", "email": "baby@example.com", "age": 0},
+		{"name": "John Doe", "email": "john@example.com"}
+	]`
+
+	_, response := postValidate(t, setupValidateRouter(new(MockUserService), controllers.WithAgeRequired(true)), body)
+	require.Len(t, response.Data, 2)
+	assert.True(t, response.Data[0].Valid, "age 0 is valid")
+	assert.Equal(t, map[string]string{"age": "is required"}, response.Data[1].Fields)
+
+	_, response = postValidate(t, setupValidateRouter(new(MockUserService)), body)
+	assert.Equal(t, 2, response.Meta.Valid, "without AGE_REQUIRED a missing age is 0")
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAsyncCountRouter(controller *controllers.UserController) *gin.Engine {
+	router := setupTestRouter()
+	router.GET("/users", controller.GetUsers)
+	router.GET("/users/count-status", controller.GetCountStatus)
+	return router
+}
+
+func getJSON(t *testing.T, router *gin.Engine, path string) (int, map[string]interface{}) {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+func TestUserController_GetUsers_AsyncCountLifecycle(t *testing.T) {
+	mockService := new(MockUserService)
+	controller := controllers.NewUserController(mockService)
+	router := setupAsyncCountRouter(controller)
+
+	release := make(chan struct{})
+	mockService.On("UsersVersion").Return(models.CollectionVersion{Count: 42}, nil)
+	mockService.On("GetUsersPage", models.UserFilter{}, models.UserSort{}, 1, 10).
+		Return([]models.UserResponse{{ID: 1, Name: "John Doe"}}, true, nil)
+	mockService.On("CountFilteredUsers", models.UserFilter{}).
+		Run(func(mock.Arguments) { <-release }).
+		Return(int64(42), nil)
+
+	status, response := getJSON(t, router, "/users?async_count=true")
+	require.Equal(t, http.StatusOK, status)
+	meta := response["meta"].(map[string]interface{})
+	assert.Contains(t, meta, "filtered_count")
+	assert.Nil(t, meta["filtered_count"], "the page does not wait for the count")
+	assert.Nil(t, meta["total_pages"])
+	assert.Equal(t, true, meta["has_next"])
+	token, _ := meta["count_token"].(string)
+	require.NotEmpty(t, token)
+
+	status, response = getJSON(t, router, "/users/count-status?token="+token)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"status": "pending"}, response)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		_, response = getJSON(t, router, "/users/count-status?token="+token)
+		return response["status"] == "ready"
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, float64(42), response["filtered_count"])
+}
+
+func TestUserController_GetCountStatus_Failures(t *testing.T) {
+	mockService := new(MockUserService)
+	controller := controllers.NewUserController(mockService)
+	router := setupAsyncCountRouter(controller)
+
+	mockService.On("UsersVersion").Return(models.CollectionVersion{}, nil)
+	mockService.On("GetUsersPage", mock.Anything, mock.Anything, 1, 10).Return([]models.UserResponse{}, false, nil)
+	mockService.On("CountFilteredUsers", mock.Anything).Return(int64(0), errors.New("timeout"))
+
+	_, response := getJSON(t, router, "/users?async_count=true")
+	token := response["meta"].(map[string]interface{})["count_token"].(string)
+	assert.Eventually(t, func() bool {
+		_, response = getJSON(t, router, "/users/count-status?token="+token)
+		return response["status"] == "failed"
+	}, time.Second, 5*time.Millisecond)
+
+	status, response := getJSON(t, router, "/users/count-status?token=unknown")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, models.CodeNotFound, response["code"])
+
+	status, _ = getJSON(t, router, "/users/count-status")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = getJSON(t, router, "/users?async_count=maybe")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestUserController_GetUsers_AsyncCountFiltered(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	for _, user := range []models.User{
+		{Name: "Young", Email: "young@example.com", Age: 20},
+		{Name: "Old", Email: "old@example.com", Age: 60},
+		{Name: "Older", Email: "older@example.com", Age: 70},
+	} {
+		require.NoError(t, repo.Create(&user))
+	}
+	router := setupAsyncCountRouter(controllers.NewUserController(service.NewUserService(repo, nil)))
+
+	status, response := getJSON(t, router, "/users?async_count=true&min_age=50&page_size=1")
+	require.Equal(t, http.StatusOK, status)
+	assert.Len(t, response["data"], 1)
+	token := response["meta"].(map[string]interface{})["count_token"].(string)
+
+	assert.Eventually(t, func() bool {
+		_, response = getJSON(t, router, "/users/count-status?token="+token)
+		return response["status"] == "ready"
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, float64(2), response["filtered_count"])
+}
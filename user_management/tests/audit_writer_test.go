@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/audit"
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/middleware"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/reqctx"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingAuditStore keeps every batch it is asked to write. When gate is
+// set, the first write blocks until it is closed.
+type recordingAuditStore struct {
+	mu      sync.Mutex
+	batches [][]models.AuditEntry
+	gate    chan struct{}
+	started chan struct{}
+	once    sync.Once
+}
+
+func (s *recordingAuditStore) CreateAuditEntries(entries []models.AuditEntry) error {
+	first := false
+	s.once.Do(func() { first = true })
+	if first && s.gate != nil {
+		close(s.started)
+		<-s.gate
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, append([]models.AuditEntry(nil), entries...))
+	return nil
+}
+
+func (s *recordingAuditStore) userIDs() [][]uint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([][]uint, len(s.batches))
+	for i, batch := range s.batches {
+		for _, entry := range batch {
+			ids[i] = append(ids[i], entry.UserID)
+		}
+	}
+	return ids
+}
+
+func TestAuditWriter_FlushesOnClose(t *testing.T) {
+	store := &recordingAuditStore{}
+	writer := audit.NewWriter(store, 10, audit.WithBatchSize(2), audit.WithFlushInterval(time.Hour))
+
+	for id := uint(1); id <= 5; id++ {
+		writer.Record(models.AuditEntry{Action: models.AuditUserUpdated, UserID: id})
+	}
+	writer.Close()
+
+	assert.Equal(t, [][]uint{{1, 2}, {3, 4}, {5}}, store.userIDs(), "full batches are written as they fill and the rest on close")
+	for _, batch := range store.batches {
+		for _, entry := range batch {
+			assert.False(t, entry.CreatedAt.IsZero(), "entries are stamped when recorded")
+		}
+	}
+
+	// Entries recorded after shutdown are still written
+	writer.Record(models.AuditEntry{Action: models.AuditUserUpdated, UserID: 6})
+	assert.Equal(t, []uint{6}, store.userIDs()[3])
+	writer.Close()
+}
+
+func TestAuditWriter_FlushesOnInterval(t *testing.T) {
+	store := &recordingAuditStore{}
+	writer := audit.NewWriter(store, 10, audit.WithFlushInterval(10*time.Millisecond))
+	defer writer.Close()
+
+	writer.Record(models.AuditEntry{Action: models.AuditUserCreated, UserID: 1})
+
+	assert.Eventually(t, func() bool { return len(store.userIDs()) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestAuditWriter_FullBufferWritesSynchronously(t *testing.T) {
+	store := &recordingAuditStore{gate: make(chan struct{}), started: make(chan struct{})}
+	writer := audit.NewWriter(store, 1, audit.WithBatchSize(1), audit.WithFlushInterval(time.Hour))
+
+	// The first entry ties up the background writer
+	writer.Record(models.AuditEntry{UserID: 1})
+	<-store.started
+	// The second fills the buffer, so the third cannot be queued
+	writer.Record(models.AuditEntry{UserID: 2})
+	writer.Record(models.AuditEntry{UserID: 3})
+
+	assert.Equal(t, [][]uint{{3}}, store.userIDs(), "the overflowing entry is written by the caller")
+
+	close(store.gate)
+	writer.Close()
+	assert.ElementsMatch(t, [][]uint{{1}, {2}, {3}}, store.userIDs())
+}
+
+func TestUserService_AuditsMutations(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	writer := audit.NewWriter(repo, 10, audit.WithFlushInterval(time.Hour))
+	userService := service.NewUserService(repo, nil, service.WithAuditor(writer)).
+		WithContext(reqctx.WithActorID(context.Background(), 42))
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	_, _, err = userService.UpdateUser(user.ID, models.UserRequest{Name: "Johnny", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	_, _, err = userService.UpdateUser(user.ID, models.UserRequest{Name: "Johnny", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	_, err = userService.SetUserStatus(user.ID, models.StatusSuspended)
+	require.NoError(t, err)
+
+	writer.Close()
+
+	entries, err := repo.GetAuditEntries(user.ID)
+	require.NoError(t, err)
+	var actions []models.AuditAction
+	for _, entry := range entries {
+		actions = append(actions, entry.Action)
+		require.NotNil(t, entry.ActorID)
+		assert.Equal(t, uint(42), *entry.ActorID)
+	}
+	assert.Equal(t, []models.AuditAction{models.AuditUserCreated, models.AuditUserUpdated, models.AuditUserStatusChanged}, actions,
+		"updates that change nothing are not audited")
+}
+
+func TestAuditEntries_CarryRequestID(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	writer := audit.NewWriter(repo, 10, audit.WithFlushInterval(time.Hour))
+	userService := service.NewUserService(repo, nil, service.WithAuditor(writer))
+	controller := controllers.NewUserController(userService)
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+
+	router := setupTestRouter()
+	router.Use(middleware.RequestID())
+	router.PUT("/users/:id", controller.UpdateUser)
+	router.DELETE("/users", controller.BulkDeleteUsers)
+	send := func(method, path, requestID string, body interface{}) string {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		if requestID != "" {
+			req.Header.Set(middleware.RequestIDHeader, requestID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		return w.Header().Get(middleware.RequestIDHeader)
+	}
+
+	updateID := send(http.MethodPut, fmt.Sprintf("/users/%d", user.ID), "update-request-1",
+		models.UserRequest{Name: "Johnny", Email: "john@example.com", Age: 30})
+	deleteID := send(http.MethodDelete, "/users", "", models.BulkDeleteRequest{IDs: []models.ID{models.ID(user.ID)}})
+	require.NotEmpty(t, deleteID, "the middleware generates an ID when none is sent")
+	writer.Close()
+
+	entries, err := repo.GetAuditEntries(user.ID)
+	require.NoError(t, err)
+	requestIDs := map[models.AuditAction]string{}
+	for _, entry := range entries {
+		requestIDs[entry.Action] = entry.RequestID
+	}
+	assert.Equal(t, map[models.AuditAction]string{
+		models.AuditUserCreated: "",
+		models.AuditUserUpdated: updateID,
+		models.AuditUserDeleted: deleteID,
+	}, requestIDs, "entries made outside a request carry no ID")
+	assert.Equal(t, "update-request-1", updateID)
+}
@@ -0,0 +1,219 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/auth"
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJWTSecret = "test-secret"
+
+// signTestToken issues a token for subject with the given role, expiring
+// after ttl (negative for an already expired token)
+func signTestToken(t *testing.T, subject, role string, ttl time.Duration) string {
+	t.Helper()
+
+	now := time.Now()
+	token, err := auth.Sign(testJWTSecret, auth.Claims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+	require.NoError(t, err)
+	return token
+}
+
+func setupAuthRouter() *gin.Engine {
+	router := setupTestRouter()
+	router.POST("/auth/introspect", controllers.NewAuthController(testJWTSecret).Introspect)
+	return router
+}
+
+func introspect(t *testing.T, router *gin.Engine, token string) (int, map[string]interface{}) {
+	t.Helper()
+
+	body, _ := json.Marshal(map[string]string{"token": token})
+	req, _ := http.NewRequest(http.MethodPost, "/auth/introspect", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+func TestIntrospect_ValidToken(t *testing.T) {
+	router := setupAuthRouter()
+	token := signTestToken(t, "42", "admin", time.Hour)
+
+	status, response := introspect(t, router, token)
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, true, response["active"])
+	assert.Equal(t, "42", response["sub"])
+	assert.Equal(t, "admin", response["role"])
+	assert.NotNil(t, response["exp"])
+	assert.InDelta(t, time.Hour.Seconds(), response["expires_in"], 5)
+	assert.NotContains(t, toJSON(t, response), testJWTSecret)
+}
+
+func TestIntrospect_ExpiredToken(t *testing.T) {
+	router := setupAuthRouter()
+	token := signTestToken(t, "42", "admin", -time.Minute)
+
+	status, response := introspect(t, router, token)
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"active": false}, response)
+}
+
+func TestIntrospect_TamperedToken(t *testing.T) {
+	router := setupAuthRouter()
+	token := signTestToken(t, "42", "user", time.Hour)
+
+	// Swap in a payload claiming the admin role, keeping the old signature
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+	claims, _ := json.Marshal(map[string]interface{}{
+		"sub":  "42",
+		"role": "admin",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	})
+	parts[1] = base64.RawURLEncoding.EncodeToString(claims)
+
+	status, response := introspect(t, router, strings.Join(parts, "."))
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"active": false}, response)
+}
+
+func TestIntrospect_RejectsOtherSecretsAndAlgorithms(t *testing.T) {
+	router := setupAuthRouter()
+	exp := jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	otherSecret, err := auth.Sign("another-secret", auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "42", ExpiresAt: exp}})
+	require.NoError(t, err)
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.RegisteredClaims{Subject: "42", ExpiresAt: exp}).
+		SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	noExpiry, err := auth.Sign(testJWTSecret, auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "42"}})
+	require.NoError(t, err)
+
+	for name, token := range map[string]string{"other secret": otherSecret, "alg none": unsigned, "no exp": noExpiry, "garbage": "not-a-jwt"} {
+		_, response := introspect(t, router, token)
+		assert.Equal(t, false, response["active"], name)
+	}
+}
+
+func TestIntrospect_FormEncoded(t *testing.T) {
+	router := setupAuthRouter()
+	token := signTestToken(t, "7", "user", time.Hour)
+
+	req, _ := http.NewRequest(http.MethodPost, "/auth/introspect", strings.NewReader(url.Values{"token": {token}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"active":true`)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+}
+
+func TestIntrospect_MissingToken(t *testing.T) {
+	router := setupAuthRouter()
+
+	status, response := introspect(t, router, "")
+
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "VALIDATION_FAILED", response["code"])
+}
+
+func TestAuthParse_EmptySecretRejectsEverything(t *testing.T) {
+	token := signTestToken(t, "42", "admin", time.Hour)
+
+	_, err := auth.Parse("", token)
+
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+}
+
+func whoami(t *testing.T, authorization string) (int, map[string]interface{}) {
+	t.Helper()
+
+	router := setupTestRouter()
+	router.GET("/auth/whoami", controllers.NewAuthController(testJWTSecret).WhoAmI)
+
+	req, _ := http.NewRequest(http.MethodGet, "/auth/whoami", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+func TestWhoAmI_Roles(t *testing.T) {
+	for role, isAdmin := range map[string]bool{"admin": true, "user": false} {
+		t.Run(role, func(t *testing.T) {
+			token, err := auth.Sign(testJWTSecret, auth.Claims{
+				Email: role + "@example.com",
+				Role:  role,
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject:   "42",
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+				},
+			})
+			require.NoError(t, err)
+
+			status, response := whoami(t, "Bearer "+token)
+
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, float64(42), response["id"])
+			assert.Equal(t, role+"@example.com", response["email"])
+			assert.Equal(t, role, response["role"])
+			assert.Equal(t, isAdmin, response["is_admin"])
+		})
+	}
+}
+
+func TestWhoAmI_Unauthenticated(t *testing.T) {
+	expired := signTestToken(t, "42", "admin", -time.Minute)
+	notAUser := signTestToken(t, "service-account", "admin", time.Hour)
+
+	for name, header := range map[string]string{
+		"missing":      "",
+		"wrong scheme": "Basic dXNlcjpwYXNz",
+		"expired":      "Bearer " + expired,
+		"non-user sub": "Bearer " + notAUser,
+	} {
+		status, response := whoami(t, header)
+		assert.Equal(t, http.StatusUnauthorized, status, name)
+		assert.Equal(t, "UNAUTHORIZED", response["code"], name)
+	}
+}
+
+func toJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgeOn(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		birth, at time.Time
+		expected  int
+	}{
+		{name: "day before birthday", birth: date(1990, 6, 15), at: date(2020, 6, 14), expected: 29},
+		{name: "on birthday", birth: date(1990, 6, 15), at: date(2020, 6, 15), expected: 30},
+		{name: "later in the year", birth: date(1990, 6, 15), at: date(2020, 12, 1), expected: 30},
+		{name: "earlier month", birth: date(1990, 6, 15), at: date(2021, 1, 1), expected: 30},
+		{name: "leap day in a common year", birth: date(2000, 2, 29), at: date(2021, 2, 28), expected: 20},
+		{name: "leap day the day after", birth: date(2000, 2, 29), at: date(2021, 3, 1), expected: 21},
+		{name: "newborn", birth: date(2024, 1, 1), at: date(2024, 1, 1), expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, models.AgeOn(tt.birth, tt.at))
+		})
+	}
+}
+
+func TestUser_CurrentAge_FallsBackToStoredAge(t *testing.T) {
+	user := models.User{Age: 42}
+	assert.Equal(t, 42, user.CurrentAge(time.Now()))
+
+	birth := time.Now().AddDate(-30, 0, -1)
+	user.BirthDate = &birth
+	assert.Equal(t, 30, user.CurrentAge(time.Now()))
+}
+
+func TestUserService_BirthDateComputesAge(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+	controller := controllers.NewUserController(userService)
+	router := setupTestRouter()
+	router.POST("/users", controller.CreateUser)
+	router.GET("/users/:id", controller.GetUser)
+
+	birth := time.Now().UTC().AddDate(-25, 0, -3).Truncate(24 * time.Hour)
+	body, _ := json.Marshal(map[string]interface{}{
+		"name": "John Doe", "email": "john@example.com", "age": 99, "birth_date": birth,
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var created struct {
+		Data models.UserResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, 25, created.Data.Age, "the birth date wins over the supplied age")
+	require.NotNil(t, created.Data.BirthDate)
+	assert.True(t, birth.Equal(*created.Data.BirthDate))
+
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/users/%d", created.Data.ID), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"age":25`)
+	assert.Contains(t, w.Body.String(), `"birth_date":"`)
+
+	stored, err := repo.GetByID(created.Data.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 25, stored.Age, "the stored age is kept in step for age filters")
+}
+
+func TestUserService_BirthDateWithoutBirthDateKeepsAge(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "Jane Doe", Email: "jane@example.com", Age: 33})
+	require.NoError(t, err)
+	assert.Equal(t, 33, user.Age)
+	assert.Nil(t, user.BirthDate)
+	assert.NotContains(t, toJSON(t, user), "birth_date")
+}
+
+func TestUserService_RejectsFutureBirthDate(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+
+	future := time.Now().AddDate(0, 0, 1)
+	_, err := userService.CreateUser(models.UserRequest{Name: "Jane Doe", Email: "jane@example.com", Age: 33, BirthDate: &future})
+
+	var validation *models.ValidationError
+	require.True(t, errors.As(err, &validation))
+	assert.Equal(t, "must not be in the future", validation.Fields["birth_date"])
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/cache"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/reqctx"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_BulkDeleteUsers_AuditsAndEvicts(t *testing.T) {
+	db := setupTestDB(t)
+	mr, client := newMiniRedis(t)
+	repo := repository.NewUserRepository(db)
+	ctx := reqctx.WithActorID(context.Background(), 7)
+	userService := service.NewUserService(repo, cache.NewRedis(client)).WithContext(ctx)
+
+	var ids []uint
+	for i := 0; i < 3; i++ {
+		user, err := userService.CreateUser(models.UserRequest{Name: "User", Email: fmt.Sprintf("user%d@example.com", i), Age: 30})
+		require.NoError(t, err)
+		ids = append(ids, user.ID)
+		require.True(t, mr.Exists(fmt.Sprintf("user:%d", user.ID)))
+	}
+
+	deleted, err := userService.BulkDeleteUsers([]uint{ids[0], ids[1], 999})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted, "unknown IDs are not counted")
+
+	var entries []models.AuditEntry
+	require.NoError(t, db.Order("user_id").Find(&entries).Error)
+	require.Len(t, entries, 2)
+	for i, entry := range entries {
+		assert.Equal(t, models.AuditUserDeleted, entry.Action)
+		assert.Equal(t, ids[i], entry.UserID)
+		require.NotNil(t, entry.ActorID)
+		assert.Equal(t, uint(7), *entry.ActorID)
+	}
+
+	assert.False(t, mr.Exists(fmt.Sprintf("user:%d", ids[0])))
+	assert.False(t, mr.Exists(fmt.Sprintf("user:%d", ids[1])))
+	assert.True(t, mr.Exists(fmt.Sprintf("user:%d", ids[2])), "users outside the batch stay cached")
+}
+
+func TestUserService_BulkDeleteUsers_RollbackKeepsCache(t *testing.T) {
+	db := setupTestDB(t)
+	mr, client := newMiniRedis(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, cache.NewRedis(client))
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	key := fmt.Sprintf("user:%d", user.ID)
+	cached, err := mr.Get(key)
+	require.NoError(t, err)
+
+	// Make the audit insert fail so the transaction rolls back
+	require.NoError(t, db.Migrator().DropTable(&models.AuditEntry{}))
+
+	_, err = userService.BulkDeleteUsers([]uint{user.ID})
+	require.Error(t, err)
+
+	_, err = repo.GetByID(user.ID)
+	assert.NoError(t, err, "the soft delete was rolled back")
+	stored, err := mr.Get(key)
+	require.NoError(t, err, "the cache entry survives a rolled back delete")
+	assert.Equal(t, cached, stored)
+}
@@ -0,0 +1,283 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/cache"
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserController_BulkOperations_ExceedsMaxBulkSize(t *testing.T) {
+	users := make([]models.UserRequest, 3)
+	for i := range users {
+		users[i] = models.UserRequest{Name: "User", Email: fmt.Sprintf("user%d@example.com", i), Age: 20}
+	}
+	items := make([]models.BulkUpdateItem, 3)
+	for i := range items {
+		items[i] = models.BulkUpdateItem{ID: models.ID(i + 1), UserRequest: users[i]}
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+	}{
+		{name: "bulk create", method: http.MethodPost, path: "/users/bulk", body: models.BulkCreateRequest{Users: users}},
+		{name: "bulk update", method: http.MethodPut, path: "/users/bulk", body: models.BulkUpdateRequest{Users: items}},
+		{name: "bulk delete", method: http.MethodDelete, path: "/users", body: models.BulkDeleteRequest{IDs: []models.ID{1, 2, 3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockService := new(MockUserService)
+			controller := controllers.NewUserController(mockService, controllers.WithMaxBulkSize(2))
+			router := setupTestRouter()
+			router.POST("/users/bulk", controller.BulkCreateUsers)
+			router.PUT("/users/bulk", controller.BulkUpdateUsers)
+			router.DELETE("/users", controller.BulkDeleteUsers)
+
+			// Create request
+			body, _ := json.Marshal(tt.body)
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			// Perform request
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// Assertions
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, float64(2), response["max_bulk_size"])
+			assert.Contains(t, response["error"], "maximum of 2 items")
+
+			// The service (and therefore the database) must never be reached
+			mockService.AssertNotCalled(t, "BulkCreateUsers", mock.Anything)
+			mockService.AssertNotCalled(t, "BulkUpdateUsers", mock.Anything)
+			mockService.AssertNotCalled(t, "BulkDeleteUsers", mock.Anything)
+		})
+	}
+}
+
+func TestUserController_BulkCreateUsers(t *testing.T) {
+	// Setup
+	mockService := new(MockUserService)
+	controller := controllers.NewUserController(mockService, controllers.WithMaxBulkSize(2))
+	router := setupTestRouter()
+	router.POST("/users/bulk", controller.BulkCreateUsers)
+
+	users := []models.UserRequest{
+		{Name: "John Doe", Email: "john@example.com", Age: 30},
+		{Name: "Jane Doe", Email: "jane@example.com", Age: 25},
+	}
+	created := []models.UserResponse{
+		{ID: 1, Name: "John Doe", Email: "john@example.com", Age: 30, IsActive: true},
+		{ID: 2, Name: "Jane Doe", Email: "jane@example.com", Age: 25, IsActive: true},
+	}
+	mockService.On("BulkCreateUsers", users).Return(created, nil)
+
+	// Create request
+	body, _ := json.Marshal(models.BulkCreateRequest{Users: users})
+	req, _ := http.NewRequest(http.MethodPost, "/users/bulk", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	// Perform request
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response["data"], 2)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestUserController_BulkDeleteUsers_EmptyRequest(t *testing.T) {
+	// Setup
+	mockService := new(MockUserService)
+	controller := controllers.NewUserController(mockService)
+	router := setupTestRouter()
+	router.DELETE("/users", controller.BulkDeleteUsers)
+
+	// Create request
+	req, _ := http.NewRequest(http.MethodDelete, "/users", bytes.NewBufferString(`{"ids":[]}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	// Perform request
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "BulkDeleteUsers", mock.Anything)
+}
+
+func TestUserService_BulkCreateUsers(t *testing.T) {
+	t.Run("creates all users", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		userService := service.NewUserService(mockRepo, nil)
+
+		reqs := []models.UserRequest{
+			{Name: "John Doe", Email: "john@example.com", Age: 30},
+			{Name: "Jane Doe", Email: "jane@example.com", Age: 25, IsActive: boolPtr(false)},
+		}
+		mockRepo.On("GetByEmails", []string{"john@example.com", "jane@example.com"}).Return([]models.User{}, nil)
+		mockRepo.On("CreateBatch", mock.AnythingOfType("[]*models.User")).Return(nil)
+
+		result, err := userService.BulkCreateUsers(reqs)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.True(t, result[0].IsActive)
+		assert.False(t, result[1].IsActive)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects duplicate emails within the request", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		userService := service.NewUserService(mockRepo, nil)
+
+		reqs := []models.UserRequest{
+			{Name: "John Doe", Email: "john@example.com", Age: 30},
+			{Name: "John Again", Email: "john@example.com", Age: 31},
+		}
+
+		result, err := userService.BulkCreateUsers(reqs)
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+		assert.Contains(t, err.Error(), "duplicate email john@example.com")
+		mockRepo.AssertNotCalled(t, "CreateBatch", mock.Anything)
+	})
+
+	t.Run("rejects an existing email", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		userService := service.NewUserService(mockRepo, nil)
+
+		reqs := []models.UserRequest{{Name: "John Doe", Email: "existing@example.com", Age: 30}}
+		mockRepo.On("GetByEmails", []string{"existing@example.com"}).Return([]models.User{{ID: 7, Email: "existing@example.com"}}, nil)
+
+		_, err := userService.BulkCreateUsers(reqs)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "user with email existing@example.com already exists")
+		mockRepo.AssertNotCalled(t, "CreateBatch", mock.Anything)
+	})
+}
+
+func TestUserService_BulkDeleteUsers(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := service.NewUserService(mockRepo, nil)
+
+	mockRepo.On("DeleteByIDs", []uint{1, 2}, (*uint)(nil)).Return([]uint{1, 2}, nil)
+
+	deleted, err := userService.BulkDeleteUsers([]uint{1, 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserController_BulkSetActive(t *testing.T) {
+	active := false
+	admin := uint(100)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockUpdated    int64
+		expectedStatus int
+	}{
+		{name: "updates matching users", body: `{"active": false, "filter": {"updated_by": 100}}`, mockUpdated: 2, expectedStatus: http.StatusOK},
+		{name: "missing active", body: `{"filter": {"updated_by": 100}}`, expectedStatus: http.StatusBadRequest},
+		{name: "empty filter", body: `{"active": false, "filter": {}}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockService := new(MockUserService)
+			controller := controllers.NewUserController(mockService)
+			router := setupTestRouter()
+			router.POST("/users/bulk-set-active", controller.BulkSetActive)
+
+			if tt.expectedStatus == http.StatusOK {
+				mockService.On("BulkSetActive", models.UserFilter{UpdatedBy: &admin}, active).Return(tt.mockUpdated, nil)
+			}
+
+			// Create request
+			req, _ := http.NewRequest(http.MethodPost, "/users/bulk-set-active", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			// Perform request
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// Assertions
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response map[string]interface{}
+				json.Unmarshal(w.Body.Bytes(), &response)
+				assert.Equal(t, float64(tt.mockUpdated), response["updated"])
+				mockService.AssertExpectations(t)
+			} else {
+				mockService.AssertNotCalled(t, "BulkSetActive", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestUserService_BulkSetActive(t *testing.T) {
+	mr, client := newMiniRedis(t)
+	mockRepo := new(MockUserRepository)
+	userService := service.NewUserService(mockRepo, cache.NewRedis(client))
+
+	active := true
+	filter := models.UserFilter{IsActive: &active}
+	mr.Set("user:1", "{}")
+	mr.Set("user:2", "{}")
+	mr.Set("user:3", "{}")
+	mockRepo.On("SetActiveByFilter", filter, false, (*uint)(nil)).Return([]uint{1, 2}, nil)
+
+	updated, err := userService.BulkSetActive(filter, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), updated)
+	assert.False(t, mr.Exists("user:1"))
+	assert.False(t, mr.Exists("user:2"))
+	assert.True(t, mr.Exists("user:3"), "unaffected users stay cached")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_BulkSetActive_Error(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := service.NewUserService(mockRepo, nil)
+
+	active := true
+	filter := models.UserFilter{IsActive: &active}
+	mockRepo.On("SetActiveByFilter", filter, false, (*uint)(nil)).Return(nil, errors.New("database error"))
+
+	updated, err := userService.BulkSetActive(filter, false)
+
+	assert.Error(t, err)
+	assert.Equal(t, int64(0), updated)
+}
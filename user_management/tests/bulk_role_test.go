@@ -0,0 +1,200 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/cache"
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/middleware"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/reqctx"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserController_BulkSetRole(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+	controller := controllers.NewUserController(userService, controllers.WithRoleConfirmThreshold(2))
+	router := setupTestRouter()
+	router.POST("/users/bulk-role", middleware.RequireAdmin(testJWTSecret), controller.BulkSetRole)
+	admin := "Bearer " + signTestToken(t, "1", "admin", time.Hour)
+
+	for _, req := range []models.UserRequest{
+		{Name: "Alice", Email: "alice@example.com", Age: 30},
+		{Name: "Bob", Email: "bob@example.com", Age: 30},
+		{Name: "Carol", Email: "carol@example.com", Age: 45},
+		{Name: "Dave", Email: "dave@example.com", Age: 50},
+		{Name: "Erin", Email: "erin@example.com", Age: 60},
+	} {
+		_, err := userService.CreateUser(req)
+		require.NoError(t, err)
+	}
+
+	roles := func() map[string]string {
+		var users []models.User
+		require.NoError(t, db.Order("id").Find(&users).Error)
+		byName := make(map[string]string, len(users))
+		for _, user := range users {
+			byName[user.Name] = user.Role
+		}
+		return byName
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		body           string
+		expectedStatus int
+		expectedCode   string
+		expectedCount  int64
+		expectedRoles  map[string]string
+	}{
+		{
+			name:           "at the threshold",
+			body:           `{"role": "admin", "filter": {"max_age": 30}}`,
+			expectedStatus: http.StatusOK,
+			expectedCount:  2,
+			expectedRoles:  map[string]string{"Alice": "admin", "Bob": "admin", "Carol": "user", "Dave": "user", "Erin": "user"},
+		},
+		{
+			name:           "over the threshold without confirmation",
+			body:           `{"role": "admin", "filter": {"min_age": 40}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.CodeConfirmRequired,
+			expectedRoles:  map[string]string{"Alice": "admin", "Bob": "admin", "Carol": "user", "Dave": "user", "Erin": "user"},
+		},
+		{
+			name:           "over the threshold with confirmation not true",
+			query:          "?confirm=yes",
+			body:           `{"role": "admin", "filter": {"min_age": 40}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.CodeConfirmRequired,
+			expectedRoles:  map[string]string{"Alice": "admin", "Bob": "admin", "Carol": "user", "Dave": "user", "Erin": "user"},
+		},
+		{
+			name:           "over the threshold confirmed",
+			query:          "?confirm=true",
+			body:           `{"role": "admin", "filter": {"min_age": 40}}`,
+			expectedStatus: http.StatusOK,
+			expectedCount:  3,
+			expectedRoles:  map[string]string{"Alice": "admin", "Bob": "admin", "Carol": "admin", "Dave": "admin", "Erin": "admin"},
+		},
+		{
+			name:           "demotes by filter",
+			body:           `{"role": "USER", "filter": {"name": "bob"}}`,
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+			expectedRoles:  map[string]string{"Alice": "admin", "Bob": "user", "Carol": "admin", "Dave": "admin", "Erin": "admin"},
+		},
+		{
+			name:           "unknown role",
+			body:           `{"role": "superuser", "filter": {"name": "bob"}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.CodeValidationFailed,
+		},
+		{
+			name:           "empty filter",
+			query:          "?confirm=true",
+			body:           `{"role": "admin", "filter": {}}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   models.CodeValidationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/users/bulk-role"+tt.query, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", admin)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			if tt.expectedCode != "" {
+				assert.Equal(t, tt.expectedCode, response["code"])
+			} else {
+				assert.Equal(t, float64(tt.expectedCount), response["updated"])
+			}
+			if tt.expectedCode == models.CodeConfirmRequired {
+				assert.Equal(t, float64(3), response["matched"])
+				assert.Equal(t, float64(2), response["threshold"])
+			}
+			if tt.expectedRoles != nil {
+				assert.Equal(t, tt.expectedRoles, roles())
+			}
+		})
+	}
+}
+
+func TestUserService_BulkSetRole_ClearsCache(t *testing.T) {
+	mr, client := newMiniRedis(t)
+	mockRepo := new(MockUserRepository)
+	userService := service.NewUserService(mockRepo, cache.NewRedis(client)).WithContext(reqctx.WithAdmin(context.Background()))
+
+	minAge := 40
+	filter := models.UserFilter{MinAge: &minAge}
+	mr.Set("user:1", "{}")
+	mr.Set("user:2", "{}")
+	mockRepo.On("SetRoleByFilter", filter, models.RoleAdmin, (*uint)(nil), 0).Return([]uint{1}, nil)
+
+	updated, err := userService.BulkSetRole(filter, models.RoleAdmin, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+	assert.False(t, mr.Exists("user:1"))
+	assert.True(t, mr.Exists("user:2"), "unaffected users stay cached")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_BulkSetRole_ImmutableRole(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := service.NewUserService(mockRepo, nil, service.WithImmutableFields([]string{"id", "created_at", "role"})).
+		WithContext(reqctx.WithAdmin(context.Background()))
+
+	minAge := 40
+	_, err := userService.BulkSetRole(models.UserFilter{MinAge: &minAge}, models.RoleAdmin, 0)
+
+	var immutable *models.FieldImmutableError
+	require.ErrorAs(t, err, &immutable, "admins are bound by it too")
+	assert.Equal(t, "role", immutable.Field)
+	mockRepo.AssertNotCalled(t, "SetRoleByFilter")
+}
+
+func TestUserRepository_SetRoleByFilter_RollsBackOverLimit(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	for _, user := range []*models.User{
+		{Name: "Alice", Email: "alice@example.com", Age: 45},
+		{Name: "Bob", Email: "bob@example.com", Age: 50},
+	} {
+		require.NoError(t, repo.Create(user))
+	}
+	minAge := 40
+	filter := models.UserFilter{MinAge: &minAge}
+
+	_, err := repo.SetRoleByFilter(filter, models.RoleAdmin, nil, 1)
+
+	var unconfirmed *models.ConfirmationRequiredError
+	require.ErrorAs(t, err, &unconfirmed)
+	assert.Equal(t, int64(2), unconfirmed.Matched, "the count comes from the update itself")
+	assert.Equal(t, 1, unconfirmed.Threshold)
+	var admins int64
+	require.NoError(t, db.Model(&models.User{}).Where("role = ?", models.RoleAdmin).Count(&admins).Error)
+	assert.Zero(t, admins, "the update is rolled back")
+
+	ids, err := repo.SetRoleByFilter(filter, models.RoleAdmin, nil, 2)
+	require.NoError(t, err)
+	assert.Len(t, ids, 2)
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupSQLMock opens a GORM postgres connection backed by sqlmock and
+// returns a counter of the statements executed against it
+func setupSQLMock(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, *int) {
+	t.Helper()
+
+	queries := 0
+	matcher := sqlmock.QueryMatcherFunc(func(expected, actual string) error {
+		queries++
+		return sqlmock.QueryMatcherRegexp.Match(expected, actual)
+	})
+
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(matcher))
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	return db, mock, &queries
+}
+
+func TestUserService_BulkUpdateUsers_BoundedQueries(t *testing.T) {
+	db, mock, queries := setupSQLMock(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+
+	now := time.Now()
+	columns := []string{"id", "name", "email", "age", "is_active", "created_at", "updated_at"}
+	rows := sqlmock.NewRows(columns)
+	for _, id := range []int{1, 2, 3} {
+		rows.AddRow(id, "User", fmt.Sprintf("user%d@example.com", id), 30, true, now, now)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id IN ($1,$2,$3)`)).
+		WillReturnRows(rows)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE LOWER(email) IN ($1,$2)`)).
+		WithArgs("new1@example.com", "new2@example.com").
+		WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectBegin()
+	for i := 0; i < 3; i++ {
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET`)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		// The first two items change email, which is recorded in the history
+		if i < 2 {
+			mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "user_email_history"`)).
+				WithArgs(uint(i+1), fmt.Sprintf("user%d@example.com", i+1), nil, "", sqlmock.AnyArg(), nil).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(i + 1))
+		}
+	}
+	mock.ExpectCommit()
+
+	users, err := userService.BulkUpdateUsers([]models.BulkUpdateItem{
+		{ID: 1, UserRequest: models.UserRequest{Name: "One", Email: "new1@example.com", Age: 31}},
+		{ID: 2, UserRequest: models.UserRequest{Name: "Two", Email: "new2@example.com", Age: 32}},
+		{ID: 3, UserRequest: models.UserRequest{Name: "Three", Email: "user3@example.com", Age: 33}},
+	})
+
+	require.NoError(t, err)
+	assert.Len(t, users, 3)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	// One fetch, one conflict check, one update per user and one history
+	// row per email change, instead of a fetch and conflict check per user
+	assert.Equal(t, 7, *queries)
+}
+
+func TestUserService_BulkUpdateUsers_BatchConflictAndMissing(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+
+	alice := &models.User{Name: "Alice", Email: "alice@example.com", Age: 30}
+	bob := &models.User{Name: "Bob", Email: "bob@example.com", Age: 30}
+	require.NoError(t, db.Create(alice).Error)
+	require.NoError(t, db.Create(bob).Error)
+
+	_, err := userService.BulkUpdateUsers([]models.BulkUpdateItem{
+		{ID: models.ID(alice.ID), UserRequest: models.UserRequest{Name: "Alice", Email: "bob@example.com", Age: 30}},
+	})
+	assert.ErrorIs(t, err, models.ErrEmailExists)
+
+	_, err = userService.BulkUpdateUsers([]models.BulkUpdateItem{
+		{ID: models.ID(alice.ID), UserRequest: models.UserRequest{Name: "Alice", Email: "alice@example.com", Age: 31}},
+		{ID: 999, UserRequest: models.UserRequest{Name: "Ghost", Email: "ghost@example.com", Age: 30}},
+	})
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+
+	// Nothing was written by the failed batches
+	stored, err := repository.NewUserRepository(db).GetByID(alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", stored.Email)
+	assert.Equal(t, 30, stored.Age)
+}
+
+func TestUserService_BulkCreateUsers_SingleConflictQuery(t *testing.T) {
+	db, mock, queries := setupSQLMock(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+
+	now := time.Now()
+	columns := []string{"id", "name", "email", "age", "is_active", "created_at", "updated_at"}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE LOWER(email) IN ($1,$2,$3,$4,$5)`)).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(4, "User", "user4@example.com", 30, true, now, now).
+			AddRow(2, "User", "user2@example.com", 30, true, now, now))
+
+	var reqs []models.UserRequest
+	for i := 1; i <= 5; i++ {
+		reqs = append(reqs, models.UserRequest{Name: "User", Email: fmt.Sprintf("user%d@example.com", i), Age: 30})
+	}
+
+	_, err := userService.BulkCreateUsers(reqs)
+
+	var conflict *models.EmailExistsError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, []string{"user2@example.com", "user4@example.com"}, conflict.Emails)
+	assert.Equal(t, "users with emails user2@example.com, user4@example.com already exist", err.Error())
+	assert.NoError(t, mock.ExpectationsWereMet())
+	// Both conflicts come from one lookup and nothing is inserted
+	assert.Equal(t, 1, *queries)
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/audit"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/reqctx"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_BulkWritesAreAudited(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	writer := audit.NewWriter(repo, 100, audit.WithFlushInterval(time.Hour))
+	ctx := reqctx.WithAdmin(reqctx.WithActorID(context.Background(), 7))
+	userService := service.NewUserService(repo, nil, service.WithAuditor(writer)).WithContext(ctx)
+
+	created, err := userService.BulkCreateUsers([]models.UserRequest{
+		{Name: "Alice", Email: "alice@example.com", Age: 30},
+		{Name: "Bob", Email: "bob@example.com", Age: 40},
+	})
+	require.NoError(t, err)
+	alice, bob := created[0].ID, created[1].ID
+
+	_, err = userService.BulkUpdateUsers([]models.BulkUpdateItem{
+		{ID: models.ID(alice), UserRequest: models.UserRequest{Name: "Alicia", Email: "alice@example.com", Age: 30}},
+		{ID: models.ID(bob), UserRequest: models.UserRequest{Name: "Robert", Email: "bob@example.com", Age: 40}},
+	})
+	require.NoError(t, err)
+
+	minAge := 35
+	updated, err := userService.BulkSetRole(models.UserFilter{MinAge: &minAge}, "admin", 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), updated)
+
+	result, err := userService.ImportUsers([]models.UserRequest{
+		{Name: "Alicia", Email: "alice@example.com", Age: 31},
+		{Name: "Carol", Email: "carol@example.com", Age: 50},
+	}, models.DuplicateUpdate)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Created)
+
+	_, err = userService.ScheduleDeletion(alice)
+	require.NoError(t, err)
+	require.NoError(t, userService.DeleteUser(bob))
+	_, err = userService.RestoreUser(bob)
+	require.NoError(t, err)
+	writer.Close()
+
+	actions := func(id uint) []models.AuditAction {
+		var entries []models.AuditEntry
+		require.NoError(t, db.Where("user_id = ?", id).Order("id").Find(&entries).Error)
+		var got []models.AuditAction
+		for _, entry := range entries {
+			require.NotNil(t, entry.ActorID)
+			assert.Equal(t, uint(7), *entry.ActorID)
+			got = append(got, entry.Action)
+		}
+		return got
+	}
+	assert.Equal(t, []models.AuditAction{
+		models.AuditUserCreated,
+		models.AuditUserUpdated,
+		models.AuditUserUpdated,
+		models.AuditUserDeletionScheduled,
+	}, actions(alice), "bulk create, bulk update, import and the deletion request")
+	assert.Equal(t, []models.AuditAction{
+		models.AuditUserCreated,
+		models.AuditUserUpdated,
+		models.AuditUserUpdated,
+		models.AuditUserDeleted,
+		models.AuditUserRestored,
+	}, actions(bob), "bulk create, bulk update, bulk role, delete and restore")
+	carol, err := repo.GetByEmail("carol@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []models.AuditAction{models.AuditUserCreated}, actions(carol.ID))
+}
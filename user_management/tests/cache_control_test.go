@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/middleware"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheControl_UserRoutes(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+	controller := controllers.NewUserController(userService)
+
+	router := setupTestRouter()
+	router.Use(middleware.CacheControl(30 * time.Second))
+	router.POST("/users", controller.CreateUser)
+	router.GET("/users/random", controller.GetRandomUser)
+	router.GET("/users/:id", controller.GetUser)
+
+	body, _ := json.Marshal(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	req, _ := http.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"), "mutations are never cached")
+
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	id := created["data"].(map[string]interface{})["id"]
+
+	for path, expected := range map[string]string{
+		fmt.Sprintf("/users/%v", id): "private, max-age=30",
+		"/users/999":                 "no-store",
+		"/users/random":              "no-store",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, expected, w.Header().Get("Cache-Control"), path)
+	}
+}
+
+func TestCacheControl_Defaults(t *testing.T) {
+	router := setupTestRouter()
+	router.Use(middleware.CacheControl(0))
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) }
+	router.GET("/items", ok)
+	router.GET("/api/v1/auth/whoami", ok)
+	router.DELETE("/items", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	tests := []struct {
+		name, method, path, expected string
+	}{
+		{name: "read without max-age revalidates", method: http.MethodGet, path: "/items", expected: "private, no-cache"},
+		{name: "auth endpoints", method: http.MethodGet, path: "/api/v1/auth/whoami", expected: "no-store"},
+		{name: "write without a body", method: http.MethodDelete, path: "/items", expected: "no-store"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.expected, w.Header().Get("Cache-Control"))
+		})
+	}
+}
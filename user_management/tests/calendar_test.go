@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupCalendarRouter(t *testing.T) (*gin.Engine, repository.UserRepository) {
+	t.Helper()
+
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	controller := controllers.NewUserController(service.NewUserService(repo, nil))
+	router := setupTestRouter()
+	router.GET("/users/calendar", controller.GetSignupCalendar)
+	return router, repo
+}
+
+func TestUserController_GetSignupCalendar(t *testing.T) {
+	router, repo := setupCalendarRouter(t)
+
+	signups := []time.Time{
+		time.Date(2024, 2, 29, 23, 59, 59, 0, time.UTC), // previous month
+		time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 1, 18, 30, 0, 0, time.UTC),
+		time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC),
+		// 01:00 on the 31st in UTC+7 is still the 30th in UTC
+		time.Date(2024, 3, 31, 1, 0, 0, 0, time.FixedZone("UTC+7", 7*60*60)),
+		time.Date(2024, 3, 31, 23, 59, 59, 0, time.UTC),
+		time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), // next month
+	}
+	for i, createdAt := range signups {
+		user := &models.User{Name: "User", Email: fmt.Sprintf("user%d@example.com", i), Age: 30, CreatedAt: createdAt}
+		require.NoError(t, repo.Create(user))
+	}
+	deleted := &models.User{Name: "Gone", Email: "gone@example.com", Age: 30, CreatedAt: time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)}
+	require.NoError(t, repo.Create(deleted))
+	require.NoError(t, repo.Delete(deleted.ID))
+
+	status, response := getJSON(t, router, "/users/calendar?year=2024&month=3")
+
+	require.Equal(t, http.StatusOK, status)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, float64(2024), data["year"])
+	assert.Equal(t, float64(3), data["month"])
+	assert.Equal(t, float64(5), data["total"])
+
+	days := data["days"].([]interface{})
+	require.Len(t, days, 31)
+	want := map[string]float64{"2024-03-01": 2, "2024-03-15": 1, "2024-03-30": 1, "2024-03-31": 1}
+	for i, raw := range days {
+		day := raw.(map[string]interface{})
+		date := fmt.Sprintf("2024-03-%02d", i+1)
+		assert.Equal(t, date, day["date"])
+		assert.Equal(t, want[date], day["count"], date)
+	}
+}
+
+func TestUserController_GetSignupCalendar_DaysInMonth(t *testing.T) {
+	router, _ := setupCalendarRouter(t)
+
+	tests := []struct {
+		name  string
+		query string
+		days  int
+	}{
+		{name: "leap February", query: "year=2024&month=2", days: 29},
+		{name: "common February", query: "year=2023&month=2", days: 28},
+		{name: "30-day month", query: "year=2024&month=4", days: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := getJSON(t, router, "/users/calendar?"+tt.query)
+
+			require.Equal(t, http.StatusOK, status)
+			data := response["data"].(map[string]interface{})
+			assert.Len(t, data["days"], tt.days)
+			assert.Equal(t, float64(0), data["total"])
+		})
+	}
+}
+
+func TestUserController_GetSignupCalendar_InvalidParameters(t *testing.T) {
+	router, _ := setupCalendarRouter(t)
+
+	tests := []struct {
+		name    string
+		query   string
+		message string
+	}{
+		{name: "missing year", query: "month=3", message: "year must be an integer between 1 and 9999"},
+		{name: "non-numeric year", query: "year=twenty&month=3", message: "year must be an integer between 1 and 9999"},
+		{name: "year out of range", query: "year=10000&month=3", message: "year must be an integer between 1 and 9999"},
+		{name: "missing month", query: "year=2024", message: "month must be an integer between 1 and 12"},
+		{name: "month zero", query: "year=2024&month=0", message: "month must be an integer between 1 and 12"},
+		{name: "month thirteen", query: "year=2024&month=13", message: "month must be an integer between 1 and 12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := getJSON(t, router, "/users/calendar?"+tt.query)
+
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Equal(t, models.CodeInvalidParameter, response["code"])
+			assert.Equal(t, tt.message, response["error"])
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/config"
+	"github.com/IntouchOpec/user_management/routes"
+	"github.com/stretchr/testify/assert"
+)
+
+func getCapabilities(t *testing.T, features config.Features, authConfig config.AuthConfig, caching bool) map[string]interface{} {
+	t.Helper()
+
+	router := setupTestRouter()
+	routes.SetupCapabilityRoutes(router, features, authConfig, caching)
+
+	status, response := getJSON(t, router, "/api/v1/capabilities")
+	assert.Equal(t, http.StatusOK, status)
+	return response
+}
+
+func TestCapabilities_Defaults(t *testing.T) {
+	response := getCapabilities(t, nil, config.AuthConfig{}, false)
+
+	assert.Equal(t, true, response["search"])
+	assert.Equal(t, false, response["auth"], "tokens cannot be verified without a secret")
+	assert.Equal(t, false, response["caching"])
+	assert.Equal(t, false, response["metrics"])
+	assert.Equal(t, true, response["export"])
+	assert.Equal(t, map[string]interface{}{
+		"similar": true, "import": true, "swagger": true, "debug": false, "export": true,
+	}, response["features"])
+}
+
+func TestCapabilities_FollowConfiguration(t *testing.T) {
+	response := getCapabilities(t, config.Features{"export": false, "debug": true, "similar": false},
+		config.AuthConfig{JWTSecret: testJWTSecret}, true)
+
+	assert.Equal(t, true, response["auth"])
+	assert.Equal(t, true, response["caching"])
+	assert.Equal(t, false, response["export"])
+	features := response["features"].(map[string]interface{})
+	assert.Equal(t, false, features["export"])
+	assert.Equal(t, true, features["debug"])
+	assert.Equal(t, false, features["similar"])
+	assert.Equal(t, true, features["import"], "unset features keep their default")
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserController_GetUsers_CollectionETag(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	require.NoError(t, repo.Create(&models.User{Name: "John Doe", Email: "john@example.com", Age: 30}))
+
+	controller := controllers.NewUserController(service.NewUserService(repo, nil))
+	router := setupTestRouter()
+	router.GET("/users", controller.GetUsers)
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/users", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("")
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	assert.Regexp(t, `^W/".+"$`, etag)
+
+	t.Run("unchanged collection returns 304", func(t *testing.T) {
+		w := get(etag)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+	})
+
+	t.Run("matches within a list of tags", func(t *testing.T) {
+		w := get(`"other", ` + etag)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+	})
+
+	t.Run("new user busts the ETag", func(t *testing.T) {
+		require.NoError(t, repo.Create(&models.User{Name: "Jane Doe", Email: "jane@example.com", Age: 25}))
+
+		w := get(etag)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	})
+}
+
+func TestUserController_GetUsers_ETagPerFormat(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	require.NoError(t, repo.Create(&models.User{Name: "John Doe", Email: "john@example.com", Age: 30}))
+
+	controller := controllers.NewUserController(service.NewUserService(repo, nil))
+	router := setupTestRouter()
+	router.GET("/users", controller.GetUsers)
+
+	get := func(accept, ifNoneMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/users", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	jsonResponse := get("application/json", "")
+	xmlResponse := get("application/xml", "")
+	require.Equal(t, http.StatusOK, jsonResponse.Code)
+	require.Equal(t, http.StatusOK, xmlResponse.Code)
+	jsonETag := jsonResponse.Header().Get("ETag")
+	xmlETag := xmlResponse.Header().Get("ETag")
+	assert.NotEqual(t, jsonETag, xmlETag)
+	assert.Equal(t, jsonETag, get("", "").Header().Get("ETag"), "JSON is the default")
+	assert.Equal(t, xmlETag, get("text/xml", "").Header().Get("ETag"))
+
+	w := get("application/xml", jsonETag)
+	assert.Equal(t, http.StatusOK, w.Code, "a JSON tag does not validate the XML body")
+	assert.Contains(t, w.Header().Get("Content-Type"), "xml")
+
+	w = get("application/xml", xmlETag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+}
+
+func TestUserController_GetUsers_VersionError(t *testing.T) {
+	mockService := new(MockUserService)
+	controller := controllers.NewUserController(mockService)
+	router := setupTestRouter()
+	router.GET("/users", controller.GetUsers)
+	mockService.On("UsersVersion").Return(models.CollectionVersion{}, errors.New("database error"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/users", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	mockService.AssertNotCalled(t, "GetAllUsers", mock.Anything, mock.Anything)
+}
+
+func TestUserController_GetUsers_ETagStyle(t *testing.T) {
+	tests := []struct {
+		name    string
+		style   string
+		pattern string
+	}{
+		{name: "default", style: "", pattern: `^W/"[^"]+"$`},
+		{name: "weak", style: "weak", pattern: `^W/"[^"]+"$`},
+		{name: "strong", style: "strong", pattern: `^"[^"]+"$`},
+		{name: "case insensitive", style: "STRONG", pattern: `^"[^"]+"$`},
+		{name: "unknown", style: "bogus", pattern: `^W/"[^"]+"$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			repo := repository.NewUserRepository(db)
+			require.NoError(t, repo.Create(&models.User{Name: "John Doe", Email: "john@example.com", Age: 30}))
+
+			controller := controllers.NewUserController(service.NewUserService(repo, nil), controllers.WithETagStyle(tt.style))
+			router := setupTestRouter()
+			router.GET("/users", controller.GetUsers)
+
+			req, _ := http.NewRequest(http.MethodGet, "/users", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+			etag := w.Header().Get("ETag")
+			assert.Regexp(t, tt.pattern, etag)
+
+			// Either form of the tag revalidates, as If-None-Match compares weakly
+			for _, candidate := range []string{etag, "W/" + strings.TrimPrefix(etag, "W/")} {
+				req, _ = http.NewRequest(http.MethodGet, "/users", nil)
+				req.Header.Set("If-None-Match", candidate)
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				assert.Equal(t, http.StatusNotModified, w.Code, candidate)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserController_CompareUsers(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+	router := setupTestRouter()
+	router.GET("/users/compare", controllers.NewUserController(userService).CompareUsers)
+
+	john, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30, Address: "1 Main St"})
+	require.NoError(t, err)
+	jon, err := userService.CreateUser(models.UserRequest{Name: "Jon Doe", Email: "jon.doe@example.com", Age: 30, Address: "1 Main St"})
+	require.NoError(t, err)
+
+	status, response := getJSON(t, router, fmt.Sprintf("/users/compare?a=%d&b=%d", john.ID, jon.ID))
+
+	require.Equal(t, http.StatusOK, status)
+	data := response["data"].(map[string]interface{})
+	assert.Equal(t, float64(john.ID), data["a"].(map[string]interface{})["id"])
+	assert.Equal(t, float64(jon.ID), data["b"].(map[string]interface{})["id"])
+	assert.Equal(t, []interface{}{"name", "email"}, data["different"])
+
+	fields := data["fields"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"a": "John Doe", "b": "Jon Doe", "same": false}, fields["name"])
+	assert.Equal(t, false, fields["email"].(map[string]interface{})["same"])
+	for _, field := range []string{"age", "phone", "address", "birth_date", "status", "is_active"} {
+		assert.Equal(t, true, fields[field].(map[string]interface{})["same"], field)
+	}
+	assert.Equal(t, "1 Main St", fields["address"].(map[string]interface{})["a"])
+}
+
+func TestUserController_CompareUsers_Errors(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+	router := setupTestRouter()
+	router.GET("/users/compare", controllers.NewUserController(userService).CompareUsers)
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+
+	for name, path := range map[string]string{
+		"missing a":   fmt.Sprintf("/users/compare?a=999&b=%d", user.ID),
+		"missing b":   fmt.Sprintf("/users/compare?a=%d&b=999", user.ID),
+		"both absent": "/users/compare?a=998&b=999",
+	} {
+		status, response := getJSON(t, router, path)
+		assert.Equal(t, http.StatusNotFound, status, name)
+		assert.Equal(t, models.CodeUserNotFound, response["code"], name)
+	}
+
+	for _, query := range []string{"", "a=1", "a=1&b=x", "a=-1&b=2"} {
+		status, response := getJSON(t, router, "/users/compare?"+query)
+		assert.Equal(t, http.StatusBadRequest, status, query)
+		assert.Equal(t, models.CodeInvalidParameter, response["code"], query)
+	}
+}
+
+func TestUserService_CompareUsers_SingleQuery(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	mockRepo.On("GetByIDs", []uint{1, 2}).Return([]models.User{
+		{ID: 2, Name: "Jane", Email: "jane@example.com", Age: 30},
+		{ID: 1, Name: "John", Email: "john@example.com", Age: 30},
+	}, nil).Once()
+
+	comparison, err := service.NewUserService(mockRepo, nil).CompareUsers(1, 2)
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "GetByID")
+	assert.Equal(t, uint(1), comparison.A.ID, "users keep the requested order")
+	assert.Equal(t, uint(2), comparison.B.ID)
+	assert.Equal(t, []string{"name", "email"}, comparison.Different)
+	assert.True(t, comparison.Fields["age"].Same)
+}
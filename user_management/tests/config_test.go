@@ -1,8 +1,11 @@
 package tests
 
 import (
+	"bytes"
+	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/IntouchOpec/user_management/config"
 	"github.com/stretchr/testify/assert"
@@ -174,3 +177,355 @@ func TestGetEnv(t *testing.T) {
 		assert.Equal(t, "localhost", cfg.Database.Host) // should use default when empty
 	})
 }
+
+func TestLoadConfig_MaxBulkSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected int
+	}{
+		{name: "default when unset", value: "", expected: 1000},
+		{name: "custom value", value: "50", expected: 50},
+		{name: "invalid value uses default", value: "lots", expected: 1000},
+		{name: "non-positive value uses default", value: "0", expected: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("MAX_BULK_SIZE", tt.value)
+			defer os.Unsetenv("MAX_BULK_SIZE")
+
+			cfg := config.LoadConfig()
+			assert.Equal(t, tt.expected, cfg.API.MaxBulkSize)
+		})
+	}
+}
+
+func TestLoadConfig_GinMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+		warns    bool
+	}{
+		{name: "default when unset", value: "", expected: "debug"},
+		{name: "release", value: "release", expected: "release"},
+		{name: "case insensitive", value: " Test ", expected: "test"},
+		{name: "invalid value falls back to debug", value: "production", expected: "debug", warns: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("GIN_MODE", tt.value)
+			defer os.Unsetenv("GIN_MODE")
+
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			cfg := config.LoadConfig()
+
+			assert.Equal(t, tt.expected, cfg.Server.Mode)
+			if tt.warns {
+				assert.Contains(t, logs.String(), `Warning: invalid GIN_MODE "production"`)
+			} else {
+				assert.NotContains(t, logs.String(), "GIN_MODE")
+			}
+		})
+	}
+}
+
+func TestLoadConfig_StrictJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected bool
+	}{
+		{name: "default when unset", value: "", expected: false},
+		{name: "enabled", value: "true", expected: true},
+		{name: "invalid value uses default", value: "sometimes", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("STRICT_JSON", tt.value)
+			defer os.Unsetenv("STRICT_JSON")
+
+			cfg := config.LoadConfig()
+			assert.Equal(t, tt.expected, cfg.API.StrictJSON)
+		})
+	}
+}
+
+func TestLoadConfig_SortDefaults(t *testing.T) {
+	cfg := config.LoadConfig()
+	assert.Equal(t, "asc", cfg.API.DefaultSortOrder)
+	assert.Equal(t, "last", cfg.API.SortNulls)
+
+	os.Setenv("SORT_DEFAULT_ORDER", "desc")
+	os.Setenv("SORT_NULLS", "first")
+	defer os.Unsetenv("SORT_DEFAULT_ORDER")
+	defer os.Unsetenv("SORT_NULLS")
+
+	cfg = config.LoadConfig()
+	assert.Equal(t, "desc", cfg.API.DefaultSortOrder)
+	assert.Equal(t, "first", cfg.API.SortNulls)
+}
+
+func TestLoadConfig_Features(t *testing.T) {
+	os.Setenv("FEATURE_SEARCH", "true")
+	os.Setenv("FEATURE_Similar", "false")
+	os.Setenv("FEATURE_BROKEN", "maybe")
+	defer os.Unsetenv("FEATURE_SEARCH")
+	defer os.Unsetenv("FEATURE_Similar")
+	defer os.Unsetenv("FEATURE_BROKEN")
+
+	cfg := config.LoadConfig()
+
+	assert.True(t, cfg.IsEnabled("search"))
+	assert.True(t, cfg.IsEnabled("SEARCH"))
+	assert.False(t, cfg.IsEnabled(config.FeatureSimilar))
+	assert.True(t, cfg.IsEnabled(config.FeatureImport), "unset features keep their default")
+	assert.False(t, cfg.IsEnabled("broken"), "unparseable values are ignored")
+	assert.False(t, cfg.IsEnabled("unknown"))
+}
+
+func TestLoadConfig_RateLimit(t *testing.T) {
+	cfg := config.LoadConfig()
+	assert.Equal(t, 300, cfg.RateLimit.PerIP)
+	assert.Equal(t, 120, cfg.RateLimit.PerUser)
+	assert.Equal(t, time.Minute, cfg.RateLimit.Window)
+
+	os.Setenv("RATE_LIMIT_USER", "10")
+	os.Setenv("RATE_LIMIT_WINDOW", "30s")
+	defer os.Unsetenv("RATE_LIMIT_USER")
+	defer os.Unsetenv("RATE_LIMIT_WINDOW")
+
+	cfg = config.LoadConfig()
+	assert.Equal(t, 10, cfg.RateLimit.PerUser)
+	assert.Equal(t, 30*time.Second, cfg.RateLimit.Window)
+}
+
+func TestLoadConfig_MinRegistrationAge(t *testing.T) {
+	assert.Equal(t, 0, config.LoadConfig().API.MinRegistrationAge)
+
+	os.Setenv("MIN_REGISTRATION_AGE", "18")
+	defer os.Unsetenv("MIN_REGISTRATION_AGE")
+
+	assert.Equal(t, 18, config.LoadConfig().API.MinRegistrationAge)
+}
+
+func TestLoadConfig_Purge(t *testing.T) {
+	cfg := config.LoadConfig()
+	assert.Equal(t, time.Duration(0), cfg.Purge.Retention, "purging is disabled by default")
+	assert.Equal(t, time.Hour, cfg.Purge.Interval)
+
+	os.Setenv("SOFT_DELETE_RETENTION", "720h")
+	os.Setenv("PURGE_INTERVAL", "15m")
+	defer os.Unsetenv("SOFT_DELETE_RETENTION")
+	defer os.Unsetenv("PURGE_INTERVAL")
+
+	cfg = config.LoadConfig()
+	assert.Equal(t, 720*time.Hour, cfg.Purge.Retention)
+	assert.Equal(t, 15*time.Minute, cfg.Purge.Interval)
+}
+
+func TestLoadConfig_ServeStale(t *testing.T) {
+	assert.False(t, config.LoadConfig().Redis.ServeStale)
+
+	os.Setenv("SERVE_STALE_ON_DB_ERROR", "true")
+	defer os.Unsetenv("SERVE_STALE_ON_DB_ERROR")
+
+	assert.True(t, config.LoadConfig().Redis.ServeStale)
+}
+
+func TestLoadConfig_ServiceInfo(t *testing.T) {
+	cfg := config.LoadConfig()
+	assert.Equal(t, "user-management", cfg.Server.ServiceName)
+	assert.Equal(t, "1.0", cfg.Server.Version)
+
+	os.Setenv("SERVICE_VERSION", "2.0.0")
+	defer os.Unsetenv("SERVICE_VERSION")
+
+	assert.Equal(t, "2.0.0", config.LoadConfig().Server.Version)
+}
+
+func TestLoadConfig_RedisPool(t *testing.T) {
+	cfg := config.LoadConfig()
+	assert.Equal(t, 0, cfg.Redis.PoolSize, "0 keeps the client default")
+	assert.Equal(t, 0, cfg.Redis.MinIdleConns)
+	assert.Equal(t, 5*time.Second, cfg.Redis.DialTimeout)
+
+	os.Setenv("REDIS_POOL_SIZE", "50")
+	os.Setenv("REDIS_MIN_IDLE_CONNS", "5")
+	os.Setenv("REDIS_DIAL_TIMEOUT", "2s")
+	defer os.Unsetenv("REDIS_POOL_SIZE")
+	defer os.Unsetenv("REDIS_MIN_IDLE_CONNS")
+	defer os.Unsetenv("REDIS_DIAL_TIMEOUT")
+
+	cfg = config.LoadConfig()
+	assert.Equal(t, 50, cfg.Redis.PoolSize)
+	assert.Equal(t, 5, cfg.Redis.MinIdleConns)
+	assert.Equal(t, 2*time.Second, cfg.Redis.DialTimeout)
+
+	for _, invalid := range []string{"-1", "0", "abc"} {
+		os.Setenv("REDIS_POOL_SIZE", invalid)
+		os.Setenv("REDIS_MIN_IDLE_CONNS", invalid)
+		os.Setenv("REDIS_DIAL_TIMEOUT", invalid)
+
+		cfg = config.LoadConfig()
+		assert.Equal(t, 0, cfg.Redis.PoolSize, invalid)
+		assert.Equal(t, 0, cfg.Redis.MinIdleConns, invalid)
+		assert.Equal(t, 5*time.Second, cfg.Redis.DialTimeout, invalid)
+	}
+}
+
+func TestLoadConfig_ChaosLatency(t *testing.T) {
+	cfg := config.LoadConfig()
+	assert.Zero(t, cfg.Server.ChaosLatency, "disabled unless set")
+
+	os.Setenv("CHAOS_LATENCY", "250ms")
+	defer os.Unsetenv("CHAOS_LATENCY")
+
+	cfg = config.LoadConfig()
+	assert.Equal(t, 250*time.Millisecond, cfg.Server.ChaosLatency)
+}
+
+func TestLoadConfig_CacheTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{name: "default when unset", value: "", expected: 15 * time.Minute},
+		{name: "valid duration", value: "90s", expected: 90 * time.Second},
+		{name: "invalid duration falls back", value: "soon", expected: 15 * time.Minute},
+		{name: "non-positive duration falls back", value: "0s", expected: 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("CACHE_TTL", tt.value)
+			defer os.Unsetenv("CACHE_TTL")
+
+			cfg := config.LoadConfig()
+			assert.Equal(t, tt.expected, cfg.Redis.CacheTTL)
+		})
+	}
+}
+
+func TestLoadConfig_OnUserDelete(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{name: "default when unset", value: "", expected: config.OnDeleteRetain},
+		{name: "cascade", value: "Cascade", expected: config.OnDeleteCascade},
+		{name: "invalid value falls back to retain", value: "purge", expected: config.OnDeleteRetain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("ON_USER_DELETE", tt.value)
+			defer os.Unsetenv("ON_USER_DELETE")
+
+			cfg := config.LoadConfig()
+			assert.Equal(t, tt.expected, cfg.Purge.OnUserDelete)
+		})
+	}
+}
+
+func TestLoadConfig_DBLogLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+		warns    bool
+	}{
+		{name: "default when unset", value: "", expected: "info"},
+		{name: "silent", value: "silent", expected: "silent"},
+		{name: "case insensitive", value: " Warn ", expected: "warn"},
+		{name: "invalid value falls back to info", value: "quiet", expected: "info", warns: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("DB_LOG_LEVEL", tt.value)
+			defer os.Unsetenv("DB_LOG_LEVEL")
+
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			cfg := config.LoadConfig()
+
+			assert.Equal(t, tt.expected, cfg.Database.LogLevel)
+			if tt.warns {
+				assert.Contains(t, logs.String(), `Warning: invalid DB_LOG_LEVEL "quiet"`)
+			} else {
+				assert.NotContains(t, logs.String(), "DB_LOG_LEVEL")
+			}
+		})
+	}
+}
+
+func TestLoadConfig_ImmutableFields(t *testing.T) {
+	cfg := config.LoadConfig()
+	assert.Equal(t, []string{"id", "created_at"}, cfg.API.ImmutableFields)
+
+	os.Setenv("IMMUTABLE_FIELDS", "id, created_at, email")
+	defer os.Unsetenv("IMMUTABLE_FIELDS")
+
+	cfg = config.LoadConfig()
+	assert.Equal(t, []string{"id", "created_at", "email"}, cfg.API.ImmutableFields)
+}
+
+func TestLoadConfig_RedisMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+		warns    bool
+	}{
+		{name: "default when unset", value: "", expected: config.RedisModeSingle},
+		{name: "sentinel", value: "sentinel", expected: config.RedisModeSentinel},
+		{name: "case insensitive", value: " Cluster ", expected: config.RedisModeCluster},
+		{name: "invalid value falls back to single", value: "replicated", expected: config.RedisModeSingle, warns: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("REDIS_MODE", tt.value)
+			defer os.Unsetenv("REDIS_MODE")
+
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			cfg := config.LoadConfig()
+
+			assert.Equal(t, tt.expected, cfg.Redis.Mode)
+			if tt.warns {
+				assert.Contains(t, logs.String(), `Warning: invalid REDIS_MODE "replicated"`)
+			} else {
+				assert.NotContains(t, logs.String(), "REDIS_MODE")
+			}
+		})
+	}
+}
+
+func TestLoadConfig_RedisSentinel(t *testing.T) {
+	cfg := config.LoadConfig()
+	assert.Empty(t, cfg.Redis.Addrs)
+	assert.Equal(t, "mymaster", cfg.Redis.MasterName)
+
+	os.Setenv("REDIS_ADDRS", "sentinel-1:26379, sentinel-2:26379")
+	os.Setenv("REDIS_MASTER_NAME", "users")
+	defer os.Unsetenv("REDIS_ADDRS")
+	defer os.Unsetenv("REDIS_MASTER_NAME")
+
+	cfg = config.LoadConfig()
+	assert.Equal(t, []string{"sentinel-1:26379", "sentinel-2:26379"}, cfg.Redis.Addrs)
+	assert.Equal(t, "users", cfg.Redis.MasterName)
+}
@@ -185,7 +185,7 @@ func TestUserController_UpdateUser_UpdateError(t *testing.T) {
 	}
 
 	// Mock setup - simulate update error (not user not found)
-	mockService.On("UpdateUser", uint(1), requestBody).Return(nil, errors.New("database connection failed"))
+	mockService.On("UpdateUser", uint(1), requestBody).Return(nil, nil, errors.New("database connection failed"))
 
 	// Create request
 	body, _ := json.Marshal(requestBody)
@@ -6,7 +6,12 @@ import (
 
 	"github.com/IntouchOpec/user_management/config"
 	"github.com/IntouchOpec/user_management/database"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/logger"
 )
 
 func TestConnectDatabase_InvalidDSN(t *testing.T) {
@@ -27,6 +32,30 @@ func TestConnectDatabase_InvalidDSN(t *testing.T) {
 	assert.Contains(t, err.Error(), "failed to connect to database")
 }
 
+func TestGormConfig_LogLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    string
+		expected logger.LogLevel
+	}{
+		{name: "silent", level: "silent", expected: logger.Silent},
+		{name: "error", level: "error", expected: logger.Error},
+		{name: "warn", level: "warn", expected: logger.Warn},
+		{name: "info", level: "info", expected: logger.Info},
+		{name: "unset logs everything", level: "", expected: logger.Info},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Database: config.DatabaseConfig{LogLevel: tt.level}}
+
+			gormConfig := database.GormConfig(cfg)
+
+			assert.Equal(t, logger.Default.LogMode(tt.expected), gormConfig.Logger)
+		})
+	}
+}
+
 func TestMigrateDatabase_NoConnection(t *testing.T) {
 	// Ensure DB is nil to test the error condition
 	originalDB := database.DB
@@ -191,3 +220,102 @@ func TestDatabaseOperations_MockScenario(t *testing.T) {
 	assert.Equal(t, "5432", cfg.Database.Port)
 	assert.Equal(t, "disable", cfg.Database.SSLMode)
 }
+
+func TestMigrateDatabase_CaseInsensitiveEmailIndex(t *testing.T) {
+	db := setupTestDB(t)
+
+	first := &models.User{Name: "Alice", Email: "Alice@Example.com", Age: 30}
+	assert.NoError(t, db.Create(first).Error)
+
+	// A case variant of an existing email must be rejected by the index
+	duplicate := &models.User{Name: "Alice Again", Email: "alice@example.com", Age: 31}
+	assert.Error(t, db.Create(duplicate).Error)
+
+	// A genuinely different email is still accepted
+	other := &models.User{Name: "Bob", Email: "bob@example.com", Age: 40}
+	assert.NoError(t, db.Create(other).Error)
+
+	// Running the migration again must be idempotent
+	assert.NoError(t, database.MigrateDatabase())
+	assert.False(t, db.Migrator().HasIndex(&models.User{}, "idx_users_email"))
+	assert.False(t, db.Migrator().HasIndex(&models.User{}, "idx_users_email_lower"))
+	assert.True(t, db.Migrator().HasIndex(&models.User{}, "idx_users_email_lower_active"))
+}
+
+func TestMigrateDatabase_SoftDeletedEmailCanBeReused(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+
+	old, err := userService.CreateUser(models.UserRequest{Name: "Old Account", Email: "reused@example.com", Age: 30})
+	require.NoError(t, err)
+	require.NoError(t, userService.DeleteUser(old.ID))
+
+	reused, err := userService.CreateUser(models.UserRequest{Name: "New Account", Email: "Reused@example.com", Age: 31})
+	require.NoError(t, err, "a soft-deleted user's email is free again")
+	assert.NotEqual(t, old.ID, reused.ID)
+
+	// Active emails stay unique, at the index as well as in the service
+	_, err = userService.CreateUser(models.UserRequest{Name: "Third", Email: "reused@example.com", Age: 32})
+	assert.ErrorIs(t, err, models.ErrEmailExists)
+	assert.Error(t, db.Create(&models.User{Name: "Raw", Email: "REUSED@example.com", Age: 30}).Error)
+}
+
+func TestMigrateDatabase_ReplacesFullEmailIndex(t *testing.T) {
+	db := setupTestDB(t)
+
+	// Recreate the index as it was before soft-deleted rows were excluded
+	require.NoError(t, db.Exec("DROP INDEX idx_users_email_lower_active").Error)
+	require.NoError(t, db.Exec("CREATE UNIQUE INDEX idx_users_email_lower ON users (LOWER(email))").Error)
+
+	require.NoError(t, database.MigrateDatabase())
+
+	assert.False(t, db.Migrator().HasIndex(&models.User{}, "idx_users_email_lower"))
+	assert.True(t, db.Migrator().HasIndex(&models.User{}, "idx_users_email_lower_active"))
+}
+
+func TestTransaction(t *testing.T) {
+	t.Run("commits all steps on success", func(t *testing.T) {
+		db := setupTestDB(t)
+
+		err := database.Transaction(func(txRepo repository.UserRepository) error {
+			if err := txRepo.Create(&models.User{Name: "Alice", Email: "alice@example.com", Age: 30}); err != nil {
+				return err
+			}
+			return txRepo.Create(&models.User{Name: "Bob", Email: "bob@example.com", Age: 30})
+		})
+
+		assert.NoError(t, err)
+		count, err := repository.NewUserRepository(db).Count()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("error rolls back every step", func(t *testing.T) {
+		db := setupTestDB(t)
+
+		err := database.Transaction(func(txRepo repository.UserRepository) error {
+			if err := txRepo.Create(&models.User{Name: "Alice", Email: "alice@example.com", Age: 30}); err != nil {
+				return err
+			}
+			// Violates the case-insensitive email index
+			return txRepo.Create(&models.User{Name: "Alice Again", Email: "ALICE@example.com", Age: 30})
+		})
+
+		assert.Error(t, err)
+		count, err := repository.NewUserRepository(db).Count()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("no connection", func(t *testing.T) {
+		originalDB := database.DB
+		database.DB = nil
+		defer func() { database.DB = originalDB }()
+
+		err := database.Transaction(func(txRepo repository.UserRepository) error {
+			return nil
+		})
+
+		assert.EqualError(t, err, "database not connected")
+	})
+}
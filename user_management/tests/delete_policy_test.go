@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_DeleteUser_Dependents(t *testing.T) {
+	tests := []struct {
+		name            string
+		cascade         bool
+		expectedHistory int
+	}{
+		{name: "retain keeps the email history", cascade: false, expectedHistory: 1},
+		{name: "cascade soft deletes the email history", cascade: true, expectedHistory: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			repo := repository.NewUserRepository(db)
+			userService := service.NewUserService(repo, nil, service.WithCascadeDelete(tt.cascade))
+
+			user, err := userService.CreateUser(models.UserRequest{Name: "Jane Doe", Email: "jane@example.com", Age: 30})
+			require.NoError(t, err)
+			_, _, err = userService.UpdateUser(user.ID, models.UserRequest{Name: "Jane Doe", Email: "jane.doe@example.com", Age: 30})
+			require.NoError(t, err)
+
+			require.NoError(t, userService.DeleteUser(user.ID))
+
+			history, err := repo.GetEmailHistory(user.ID)
+			require.NoError(t, err)
+			assert.Len(t, history, tt.expectedHistory)
+
+			var stored int64
+			require.NoError(t, db.Unscoped().Model(&models.UserEmailHistory{}).Where("user_id = ?", user.ID).Count(&stored).Error)
+			assert.Equal(t, int64(1), stored, "history is soft deleted, never removed")
+
+			_, err = userService.RestoreUser(user.ID)
+			require.NoError(t, err)
+			history, err = repo.GetEmailHistory(user.ID)
+			require.NoError(t, err)
+			assert.Len(t, history, 1, "restoring the user restores its history")
+		})
+	}
+}
+
+func TestUserService_DeleteUser_CascadeMissingUser(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil, service.WithCascadeDelete(true))
+
+	err := userService.DeleteUser(999)
+
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+}
@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/cache"
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/middleware"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/reqctx"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postAuthorized(t *testing.T, router *gin.Engine, path, authorization string) (int, map[string]interface{}) {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodPost, path, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+func TestUserController_DeletionRequest(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	grace := 7 * 24 * time.Hour
+	userService := service.NewUserService(repo, nil, service.WithDeletionGrace(grace))
+	controller := controllers.NewUserController(userService)
+
+	router := setupTestRouter()
+	requireAuth := middleware.RequireAuth(testJWTSecret)
+	router.POST("/users/:id/delete-request", requireAuth, controller.RequestDeletion)
+	router.POST("/users/:id/cancel-deletion", requireAuth, controller.CancelDeletion)
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	self := "Bearer " + signTestToken(t, fmt.Sprint(user.ID), "user", time.Hour)
+	stranger := "Bearer " + signTestToken(t, fmt.Sprint(user.ID+1), "user", time.Hour)
+	requestPath := fmt.Sprintf("/users/%d/delete-request", user.ID)
+	cancelPath := fmt.Sprintf("/users/%d/cancel-deletion", user.ID)
+
+	t.Run("schedules after the grace period", func(t *testing.T) {
+		status, response := postAuthorized(t, router, requestPath, self)
+
+		require.Equal(t, http.StatusOK, status)
+		data := response["data"].(map[string]interface{})
+		scheduled, err := time.Parse(time.RFC3339, data["deletion_scheduled_at"].(string))
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(grace), scheduled, time.Minute)
+
+		stored, err := repo.GetByID(user.ID)
+		require.NoError(t, err)
+		require.NotNil(t, stored.DeletionScheduledAt)
+
+		// Asking again keeps the original date
+		_, response = postAuthorized(t, router, requestPath, self)
+		assert.Equal(t, data["deletion_scheduled_at"], response["data"].(map[string]interface{})["deletion_scheduled_at"])
+	})
+
+	t.Run("other users are denied", func(t *testing.T) {
+		status, response := postAuthorized(t, router, cancelPath, stranger)
+
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.Equal(t, models.CodeForbidden, response["code"])
+		stored, err := repo.GetByID(user.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, stored.DeletionScheduledAt, "the schedule is untouched")
+	})
+
+	t.Run("cancels", func(t *testing.T) {
+		status, response := postAuthorized(t, router, cancelPath, self)
+
+		require.Equal(t, http.StatusOK, status)
+		assert.NotContains(t, response["data"], "deletion_scheduled_at")
+		stored, err := repo.GetByID(user.ID)
+		require.NoError(t, err)
+		assert.Nil(t, stored.DeletionScheduledAt)
+	})
+
+	t.Run("requires a token", func(t *testing.T) {
+		status, _ := postAuthorized(t, router, requestPath, "")
+
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+}
+
+func TestUserService_DeleteDueAccounts(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	due := &models.User{Name: "Due", Email: "due@example.com", Age: 30, DeletionScheduledAt: &past}
+	later := &models.User{Name: "Later", Email: "later@example.com", Age: 30, DeletionScheduledAt: &future}
+	kept := &models.User{Name: "Kept", Email: "kept@example.com", Age: 30}
+	for _, user := range []*models.User{due, later, kept} {
+		require.NoError(t, repo.Create(user))
+	}
+
+	deleted, err := userService.DeleteDueAccounts(now)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	_, err = repo.GetByID(due.ID)
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+	for _, user := range []*models.User{later, kept} {
+		_, err := repo.GetByID(user.ID)
+		assert.NoError(t, err, user.Email)
+	}
+
+	audit, err := repo.GetAuditEntries(due.ID)
+	require.NoError(t, err)
+	require.Len(t, audit, 1)
+	assert.Equal(t, models.AuditUserDeleted, audit[0].Action)
+	assert.Nil(t, audit[0].ActorID, "the job deletes on nobody's behalf")
+
+	deleted, err = userService.DeleteDueAccounts(now)
+	require.NoError(t, err)
+	assert.Zero(t, deleted, "already deleted accounts are not counted again")
+}
+
+func TestUserService_DeleteDueAccounts_EvictsTenantKeys(t *testing.T) {
+	mr, client := newMiniRedis(t)
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), cache.NewRedis(client))
+	acme := userService.WithContext(reqctx.WithTenantID(context.Background(), "acme"))
+
+	user, err := acme.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	_, _, err = acme.GetAllUsers(1, 10)
+	require.NoError(t, err)
+	_, err = acme.CountUsers()
+	require.NoError(t, err)
+	userKey := fmt.Sprintf("user:acme:%d", user.ID)
+	require.True(t, mr.Exists(userKey))
+	require.True(t, mr.Exists("user:acme:email:john@example.com"))
+	require.True(t, mr.Exists("users:count:acme"))
+	version, _ := mr.Get("users:list:version:acme")
+
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", user.ID).Update("deletion_scheduled_at", past).Error)
+
+	// The job runs with no tenant in its context
+	deleted, err := userService.DeleteDueAccounts(time.Now())
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	assert.False(t, mr.Exists(userKey))
+	assert.False(t, mr.Exists("user:acme:email:john@example.com"))
+	assert.False(t, mr.Exists("users:count:acme"))
+	after, _ := mr.Get("users:list:version:acme")
+	assert.NotEqual(t, version, after, "the tenant's cached lists are invalidated")
+
+	audit, err := repository.NewUserRepository(db).WithContext(reqctx.WithTenantID(context.Background(), "acme")).GetAuditEntries(user.ID)
+	require.NoError(t, err)
+	require.Len(t, audit, 1, "the audit entry belongs to the user's tenant")
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/cache"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_EmailCacheKey(t *testing.T) {
+	mr, client := newMiniRedis(t)
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, cache.NewRedis(client))
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "John@Example.com", Age: 30})
+	require.NoError(t, err)
+
+	t.Run("written on create", func(t *testing.T) {
+		id, err := mr.Get("user:email:john@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, "1", id)
+	})
+
+	t.Run("moved when the email changes", func(t *testing.T) {
+		_, _, err := userService.UpdateUser(user.ID, models.UserRequest{Name: "John Doe", Email: "johnny@example.com", Age: 30})
+		require.NoError(t, err)
+
+		assert.False(t, mr.Exists("user:email:john@example.com"), "the old email no longer resolves")
+		id, err := mr.Get("user:email:johnny@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, "1", id)
+	})
+
+	t.Run("removed on delete", func(t *testing.T) {
+		require.NoError(t, userService.DeleteUser(user.ID))
+
+		assert.False(t, mr.Exists("user:1"))
+		assert.False(t, mr.Exists("user:email:johnny@example.com"))
+	})
+}
+
+func TestUserService_GetUserByEmail_FromCache(t *testing.T) {
+	mr, client := newMiniRedis(t)
+	mockRepo := new(MockUserRepository)
+	userService := service.NewUserService(mockRepo, cache.NewRedis(client))
+
+	cached, _ := json.Marshal(&models.User{ID: 1, Name: "John Doe", Email: "john@example.com", Age: 30})
+	mr.Set("user:1", string(cached))
+	mr.Set("user:email:john@example.com", "1")
+
+	user, err := userService.GetUserByEmail(" JOHN@example.com ")
+
+	require.NoError(t, err)
+	assert.Equal(t, "John Doe", user.Name)
+	mockRepo.AssertNotCalled(t, "GetByEmail", mock.Anything)
+}
+
+func TestUserService_GetUserByEmail_StaleEmailEntry(t *testing.T) {
+	mr, client := newMiniRedis(t)
+	mockRepo := new(MockUserRepository)
+	userService := service.NewUserService(mockRepo, cache.NewRedis(client))
+
+	// The entry points at a user who has since changed email
+	cached, _ := json.Marshal(&models.User{ID: 1, Name: "John Doe", Email: "johnny@example.com", Age: 30})
+	mr.Set("user:1", string(cached))
+	mr.Set("user:email:john@example.com", "1")
+	mockRepo.On("GetByEmail", "john@example.com").Return(nil, models.ErrUserNotFound)
+
+	_, err := userService.GetUserByEmail("john@example.com")
+
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/IntouchOpec/user_management/database"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_EmailsAreCaseInsensitive(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: " A@B.com ", Age: 30})
+	require.NoError(t, err)
+	assert.Equal(t, "a@b.com", user.Email, "emails are stored lowercased")
+
+	_, err = userService.CreateUser(models.UserRequest{Name: "Johnny", Email: "a@b.com", Age: 30})
+	assert.ErrorIs(t, err, models.ErrEmailExists)
+	assert.EqualError(t, err, "user with email a@b.com already exists")
+
+	other, err := userService.CreateUser(models.UserRequest{Name: "Jane Doe", Email: "jane@example.com", Age: 30})
+	require.NoError(t, err)
+	_, _, err = userService.UpdateUser(other.ID, models.UserRequest{Name: "Jane Doe", Email: "A@b.COM", Age: 30})
+	assert.ErrorIs(t, err, models.ErrEmailExists, "updates cannot take another user's email in a different case")
+
+	_, err = userService.BulkCreateUsers([]models.UserRequest{{Name: "Bulk", Email: "A@B.COM", Age: 30}})
+	assert.ErrorIs(t, err, models.ErrEmailExists)
+
+	_, err = userService.BulkUpdateUsers([]models.BulkUpdateItem{
+		{ID: models.ID(user.ID), UserRequest: models.UserRequest{Name: "John Doe", Email: "new@example.com", Age: 30}},
+		{ID: models.ID(other.ID), UserRequest: models.UserRequest{Name: "Jane Doe", Email: " New@Example.COM", Age: 30}},
+	})
+	var validationErr *models.ValidationError
+	require.ErrorAs(t, err, &validationErr, "bulk updates cannot give two users one email in different cases")
+	assert.Equal(t, "duplicate email new@example.com in request", validationErr.Message)
+}
+
+func TestUserRepository_GetByEmail_IgnoresCase(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+
+	// Written directly, the way rows stored before normalization look
+	require.NoError(t, db.Create(&models.User{Name: "John Doe", Email: "John@Example.com", Age: 30}).Error)
+
+	user, err := repo.GetByEmail("jOHN@example.COM")
+	require.NoError(t, err)
+	assert.Equal(t, "John@Example.com", user.Email)
+
+	users, err := repo.GetByEmails([]string{"JOHN@EXAMPLE.COM"})
+	require.NoError(t, err)
+	assert.Len(t, users, 1)
+
+	// The owner may rewrite its own email in canonical form
+	userService := service.NewUserService(repo, nil)
+	updated, _, err := userService.UpdateUser(user.ID, models.UserRequest{Name: "John Doe", Email: "John@Example.com", Age: 30})
+	require.NoError(t, err)
+	assert.Equal(t, "john@example.com", updated.Email)
+}
+
+func TestMigrateDatabase_LowercasesEmails(t *testing.T) {
+	db := setupTestDB(t)
+
+	require.NoError(t, db.Create(&models.User{Name: "Mixed", Email: "Mixed.Case@Example.com", Age: 30}).Error)
+	deleted := &models.User{Name: "Deleted", Email: "Deleted@Example.com", Age: 30}
+	require.NoError(t, db.Create(deleted).Error)
+	require.NoError(t, db.Delete(deleted).Error)
+
+	require.NoError(t, database.MigrateDatabase())
+
+	var emails []string
+	require.NoError(t, db.Unscoped().Model(&models.User{}).Order("id").Pluck("email", &emails).Error)
+	assert.Equal(t, []string{"mixed.case@example.com", "deleted@example.com"}, emails)
+}
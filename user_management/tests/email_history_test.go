@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/config"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/reqctx"
+	"github.com/IntouchOpec/user_management/routes"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_EmailHistory(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "first@example.com", Age: 30})
+	require.NoError(t, err)
+
+	actor := uint(9)
+	asActor := userService.WithContext(reqctx.WithActorID(context.Background(), actor))
+	_, _, err = asActor.UpdateUser(user.ID, models.UserRequest{Name: "John Doe", Email: "second@example.com", Age: 30})
+	require.NoError(t, err)
+	_, _, err = asActor.UpdateUser(user.ID, models.UserRequest{Name: "John Doe", Email: "third@example.com", Age: 30})
+	require.NoError(t, err)
+
+	// Updates that keep the email add nothing
+	_, _, err = asActor.UpdateUser(user.ID, models.UserRequest{Name: "John Renamed", Email: "third@example.com", Age: 31})
+	require.NoError(t, err)
+
+	asAdmin := userService.WithContext(reqctx.WithAdmin(context.Background()))
+	history, err := asAdmin.GetEmailHistory(user.ID)
+
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "first@example.com", history[0].Email)
+	assert.Equal(t, "second@example.com", history[1].Email)
+	assert.False(t, history[1].ChangedAt.Before(history[0].ChangedAt))
+	for _, entry := range history {
+		assert.Equal(t, user.ID, entry.UserID)
+		require.NotNil(t, entry.ChangedBy)
+		assert.Equal(t, actor, *entry.ChangedBy)
+	}
+}
+
+func TestUserService_EmailHistory_BulkUpdate(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "Jane Doe", Email: "jane@example.com", Age: 30})
+	require.NoError(t, err)
+
+	_, err = userService.BulkUpdateUsers([]models.BulkUpdateItem{
+		{ID: models.ID(user.ID), UserRequest: models.UserRequest{Name: "Jane Doe", Email: "jane.doe@example.com", Age: 30}},
+	})
+	require.NoError(t, err)
+
+	asSelf := userService.WithContext(reqctx.WithActorID(context.Background(), user.ID))
+	history, err := asSelf.GetEmailHistory(user.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "jane@example.com", history[0].Email)
+}
+
+func TestUserService_EmailHistory_UserNotFound(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+
+	_, err := userService.WithContext(reqctx.WithAdmin(context.Background())).GetEmailHistory(999)
+
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+}
+
+func TestUserService_EmailHistory_OtherUserForbidden(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+
+	_, err = userService.WithContext(reqctx.WithActorID(context.Background(), user.ID+1)).GetEmailHistory(user.ID)
+	assert.ErrorIs(t, err, models.ErrForbidden)
+
+	_, err = userService.GetEmailHistory(user.ID)
+	assert.ErrorIs(t, err, models.ErrForbidden, "anonymous callers are denied too")
+}
+
+func TestUserController_GetEmailHistory(t *testing.T) {
+	mockService := new(MockUserService)
+	controller := controllers.NewUserController(mockService)
+	router := setupTestRouter()
+	router.GET("/users/:id/email-history", controller.GetEmailHistory)
+
+	mockService.On("GetEmailHistory", uint(1)).Return([]models.UserEmailHistory{
+		{ID: 1, UserID: 1, Email: "first@example.com"},
+	}, nil)
+	mockService.On("GetEmailHistory", uint(2)).Return(nil, models.ErrUserNotFound)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/1/email-history", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data []models.UserEmailHistory `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, "first@example.com", response.Data[0].Email)
+
+	req, _ = http.NewRequest(http.MethodGet, "/users/2/email-history", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req, _ = http.NewRequest(http.MethodGet, "/users/abc/email-history", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSetupRoutes_EmailHistoryRequiresSelfOrAdmin(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	_, _, err = userService.UpdateUser(user.ID, models.UserRequest{Name: "John Doe", Email: "jd@example.com", Age: 30})
+	require.NoError(t, err)
+
+	router := setupTestRouter()
+	routes.SetupRoutes(router, controllers.NewUserController(userService), nil, config.AuthConfig{JWTSecret: testJWTSecret})
+	path := fmt.Sprintf("/api/v1/users/%d/email-history", user.ID)
+
+	tests := []struct {
+		name           string
+		authorization  string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "anonymous", expectedStatus: http.StatusUnauthorized, expectedCode: models.CodeUnauthorized},
+		{name: "other user", authorization: "Bearer " + signTestToken(t, fmt.Sprint(user.ID+1), "user", time.Hour), expectedStatus: http.StatusForbidden, expectedCode: models.CodeForbidden},
+		{name: "self", authorization: "Bearer " + signTestToken(t, fmt.Sprint(user.ID), "user", time.Hour), expectedStatus: http.StatusOK},
+		{name: "admin", authorization: "Bearer " + signTestToken(t, "1000", "admin", time.Hour), expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := sendAuthorized(t, router, http.MethodGet, path, tt.authorization, nil)
+
+			assert.Equal(t, tt.expectedStatus, status)
+			if tt.expectedCode != "" {
+				assert.Equal(t, tt.expectedCode, response["code"])
+				return
+			}
+			history := response["data"].([]interface{})
+			require.Len(t, history, 1)
+			assert.Equal(t, "john@example.com", history[0].(map[string]interface{})["email"])
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserController_ErrorCodes(t *testing.T) {
+	requestBody := models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30}
+
+	tests := []struct {
+		name           string
+		serviceError   error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "user not found", serviceError: models.ErrUserNotFound, expectedStatus: http.StatusNotFound, expectedCode: models.CodeUserNotFound},
+		{name: "wrapped user not found", serviceError: fmt.Errorf("user 1: %w", models.ErrUserNotFound), expectedStatus: http.StatusNotFound, expectedCode: models.CodeUserNotFound},
+		{name: "email exists", serviceError: &models.EmailExistsError{Email: "john@example.com"}, expectedStatus: http.StatusConflict, expectedCode: models.CodeEmailExists},
+		{name: "wrapped email exists", serviceError: fmt.Errorf("failed to update user: %w", &models.EmailExistsError{}), expectedStatus: http.StatusConflict, expectedCode: models.CodeEmailExists},
+		{name: "unique violation", serviceError: &models.UniqueViolationError{Field: "phone"}, expectedStatus: http.StatusConflict, expectedCode: models.CodeDuplicateValue},
+		{name: "validation failed", serviceError: &models.ValidationError{Message: "duplicate email john@example.com in request"}, expectedStatus: http.StatusBadRequest, expectedCode: models.CodeValidationFailed},
+		{name: "unregistered error uses handler fallback", serviceError: errors.New("database connection failed"), expectedStatus: http.StatusBadRequest, expectedCode: models.CodeBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockService := new(MockUserService)
+			controller := controllers.NewUserController(mockService)
+			router := setupTestRouter()
+			router.PUT("/users/:id", controller.UpdateUser)
+			mockService.On("UpdateUser", uint(1), requestBody).Return(nil, nil, tt.serviceError)
+
+			// Create request
+			body, _ := json.Marshal(requestBody)
+			req, _ := http.NewRequest(http.MethodPut, "/users/1", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			// Perform request
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// Assertions
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedCode, response["code"])
+			assert.Equal(t, tt.serviceError.Error(), response["error"])
+		})
+	}
+}
+
+func TestUserController_RequestErrorCodes(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		expectedCode string
+	}{
+		{name: "invalid ID", method: http.MethodGet, path: "/users/abc", expectedCode: models.CodeInvalidID},
+		{name: "invalid body", method: http.MethodPost, path: "/users", body: "{", expectedCode: models.CodeInvalidRequestBody},
+		{name: "invalid filter", method: http.MethodGet, path: "/users?updated_by=admin", expectedCode: models.CodeInvalidParameter},
+		{name: "empty bulk request", method: http.MethodDelete, path: "/users", body: `{"ids": []}`, expectedCode: models.CodeValidationFailed},
+		{name: "bulk limit exceeded", method: http.MethodDelete, path: "/users", body: `{"ids": [1, 2, 3]}`, expectedCode: models.CodeBulkLimitExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockService := new(MockUserService)
+			controller := controllers.NewUserController(mockService, controllers.WithMaxBulkSize(2))
+			router := setupTestRouter()
+			router.GET("/users", controller.GetUsers)
+			router.POST("/users", controller.CreateUser)
+			router.DELETE("/users", controller.BulkDeleteUsers)
+			router.GET("/users/:id", controller.GetUser)
+
+			// Create request
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			// Perform request
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// Assertions
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedCode, response["code"])
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package tests
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupExportRouter seeds two active and one inactive user and serves the
+// export endpoint over a real repository
+func setupExportRouter(t *testing.T) (*gin.Engine, *gorm.DB) {
+	t.Helper()
+
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+
+	for _, req := range []models.UserRequest{
+		{Name: "Alice Active", Email: "alice@example.com", Age: 25},
+		{Name: "Bob Active", Email: "bob@example.com", Age: 40},
+	} {
+		_, err := userService.CreateUser(req)
+		require.NoError(t, err)
+	}
+	carol, err := userService.CreateUser(models.UserRequest{Name: "Carol Inactive", Email: "carol@example.com", Age: 35})
+	require.NoError(t, err)
+	_, err = userService.SetUserStatus(carol.ID, models.StatusSuspended)
+	require.NoError(t, err)
+
+	router := setupTestRouter()
+	router.GET("/users/export", controllers.NewUserController(userService).ExportUsers)
+	return router, db
+}
+
+func TestExportUsers_CSV_ActiveOnly(t *testing.T) {
+	router, _ := setupExportRouter(t)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/export?active=true", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="users.csv"`, w.Header().Get("Content-Disposition"))
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"name", "email", "age", "phone", "address", "is_active"}, records[0])
+	assert.Equal(t, "alice@example.com", records[1][1])
+	assert.Equal(t, "bob@example.com", records[2][1])
+	for _, record := range records[1:] {
+		assert.Equal(t, "true", record[5])
+	}
+}
+
+func TestExportUsers_JSON_AgeRangeAndSearch(t *testing.T) {
+	router, _ := setupExportRouter(t)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/export?format=json&min_age=30&search=ACTIVE", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var users []models.UserResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &users))
+
+	var emails []string
+	for _, user := range users {
+		emails = append(emails, user.Email)
+	}
+	assert.Equal(t, []string{"bob@example.com", "carol@example.com"}, emails)
+}
+
+func TestExportUsers_SpansBatches(t *testing.T) {
+	router, db := setupExportRouter(t)
+
+	users := make([]models.User, 0, 600)
+	for i := 0; i < 600; i++ {
+		users = append(users, models.User{Name: "Batch User", Email: fmt.Sprintf("batch%d@example.com", i), Age: 50, IsActive: true})
+	}
+	require.NoError(t, db.CreateInBatches(users, 200).Error)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/export?format=json&min_age=50", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var exported []models.UserResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &exported))
+	assert.Len(t, exported, 600)
+}
+
+func TestUserController_GetUsers_Stream(t *testing.T) {
+	db := setupTestDB(t)
+	router := setupTestRouter()
+	router.GET("/users", controllers.NewUserController(service.NewUserService(repository.NewUserRepository(db), nil)).GetUsers)
+
+	// Every other user matches, so the 700 matches span two export batches
+	users := make([]models.User, 0, 1400)
+	for i := 0; i < 1400; i++ {
+		users = append(users, models.User{Name: fmt.Sprintf("Stream User %d", i), Email: fmt.Sprintf("stream%d@example.com", i), Age: 20 + i%2*30, IsActive: true})
+	}
+	require.NoError(t, db.CreateInBatches(users, 200).Error)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users?stream=true&min_age=50&page=3&page_size=5", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+	require.True(t, json.Valid(w.Body.Bytes()), "the stream is one JSON document")
+	var streamed []json.RawMessage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &streamed))
+	require.Len(t, streamed, 700, "pagination is ignored")
+
+	var paged []json.RawMessage
+	for page := 1; page <= 7; page++ {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/users?min_age=50&page=%d&page_size=100", page), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Data []json.RawMessage `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		paged = append(paged, response.Data...)
+	}
+	require.Len(t, paged, 700)
+	for i := range paged {
+		require.JSONEq(t, string(paged[i]), string(streamed[i]), "user %d", i)
+	}
+}
+
+func TestUserController_GetUsers_StreamInvalid(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/users", controllers.NewUserController(new(MockUserService)).GetUsers)
+
+	for name, query := range map[string]string{
+		"not a boolean": "stream=yes",
+		"with sorting":  "stream=true&sort_by=name",
+		"bad filter":    "stream=true&min_age=old",
+	} {
+		t.Run(name, func(t *testing.T) {
+			status, response := getJSON(t, router, "/users?"+query)
+
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.NotEmpty(t, response["code"])
+		})
+	}
+}
+
+func TestExportUsers_Gzip(t *testing.T) {
+	router, db := setupExportRouter(t)
+
+	users := make([]models.User, 0, 600)
+	for i := 0; i < 600; i++ {
+		users = append(users, models.User{Name: "Batch User", Email: fmt.Sprintf("batch%d@example.com", i), Age: 50, IsActive: true})
+	}
+	require.NoError(t, db.CreateInBatches(users, 200).Error)
+
+	export := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, format := range []string{"csv", "json"} {
+		t.Run(format, func(t *testing.T) {
+			path := "/users/export?format=" + format
+			plain := export(path, "")
+			require.Equal(t, http.StatusOK, plain.Code)
+			assert.Empty(t, plain.Header().Get("Content-Encoding"))
+
+			compressed := export(path, "deflate, gzip;q=0.8")
+			require.Equal(t, http.StatusOK, compressed.Code)
+			assert.Equal(t, "gzip", compressed.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", compressed.Header().Get("Vary"))
+			assert.Equal(t, plain.Header().Get("Content-Type"), compressed.Header().Get("Content-Type"))
+			assert.Less(t, compressed.Body.Len(), plain.Body.Len())
+
+			reader, err := gzip.NewReader(compressed.Body)
+			require.NoError(t, err)
+			decompressed, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, plain.Body.String(), string(decompressed))
+		})
+	}
+
+	t.Run("refused", func(t *testing.T) {
+		w := export("/users/export", "gzip;q=0, identity")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.True(t, strings.HasPrefix(w.Body.String(), "name,email"))
+	})
+
+	t.Run("errors are not compressed", func(t *testing.T) {
+		w := export("/users/export?format=xlsx", "gzip")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Contains(t, w.Body.String(), models.CodeInvalidParameter)
+	})
+}
+
+func TestExportUsers_EmptyResult(t *testing.T) {
+	router, _ := setupExportRouter(t)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/export?format=json&search=nobody", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[]", strings.TrimSpace(w.Body.String()))
+}
+
+func TestExportUsers_InvalidParameters(t *testing.T) {
+	router, _ := setupExportRouter(t)
+
+	for _, query := range []string{"format=xlsx", "active=maybe", "min_age=50&max_age=20", "min_age=old"} {
+		req, _ := http.NewRequest(http.MethodGet, "/users/export?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		assert.Contains(t, w.Body.String(), models.CodeInvalidParameter, query)
+	}
+}
+
+func TestUserRepository_Search_LiteralWildcards(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+
+	for _, email := range []string{"100%@example.com", "1000@example.com"} {
+		require.NoError(t, repo.Create(&models.User{Name: "User", Email: email, Age: 30}))
+	}
+
+	users, total, err := repo.Search(models.UserFilter{Search: "100%"}, models.UserSort{}, 0, 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, users, 1)
+	assert.Equal(t, "100%@example.com", users[0].Email)
+}
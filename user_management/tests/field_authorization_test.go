@@ -0,0 +1,241 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/middleware"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupFieldAuthRouter(t *testing.T) (*gin.Engine, repository.UserRepository, *models.UserResponse) {
+	t.Helper()
+
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil, service.WithAdminOnlyFields([]string{"email", "is_active"}))
+	controller := controllers.NewUserController(userService)
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+
+	router := setupTestRouter()
+	optionalAuth := middleware.OptionalAuth(testJWTSecret)
+	router.PUT("/users/:id", optionalAuth, controller.UpdateUser)
+	router.PUT("/users/:id/status", optionalAuth, controller.SetUserStatus)
+	return router, repo, user
+}
+
+func sendAuthorized(t *testing.T, router *gin.Engine, method, path, authorization string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+
+	payload, _ := json.Marshal(body)
+	req, _ := http.NewRequest(method, path, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+func TestUpdateUser_AdminOnlyFields(t *testing.T) {
+	member := "Bearer " + signTestToken(t, "7", "user", time.Hour)
+	admin := "Bearer " + signTestToken(t, "1", "admin", time.Hour)
+	inactive := false
+
+	t.Run("non-admin changing email", func(t *testing.T) {
+		router, repo, user := setupFieldAuthRouter(t)
+
+		status, response := sendAuthorized(t, router, http.MethodPut, fmt.Sprintf("/users/%d", user.ID), member,
+			models.UserRequest{Name: "John Doe", Email: "other@example.com", Age: 30})
+
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.Equal(t, models.CodeForbidden, response["code"])
+		assert.Equal(t, "email", response["field"])
+		stored, err := repo.GetByID(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "john@example.com", stored.Email)
+	})
+
+	t.Run("non-admin changing name", func(t *testing.T) {
+		router, repo, user := setupFieldAuthRouter(t)
+
+		status, response := sendAuthorized(t, router, http.MethodPut, fmt.Sprintf("/users/%d", user.ID), member,
+			models.UserRequest{Name: "Johnny", Email: "john@example.com", Age: 30})
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []interface{}{"name"}, response["changed"])
+		stored, err := repo.GetByID(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Johnny", stored.Name)
+	})
+
+	t.Run("non-admin changing role", func(t *testing.T) {
+		router, repo, user := setupFieldAuthRouter(t)
+
+		status, response := sendAuthorized(t, router, http.MethodPut, fmt.Sprintf("/users/%d", user.ID), member,
+			models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30, Role: models.RoleAdmin})
+
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.Equal(t, models.CodeForbidden, response["code"])
+		assert.Equal(t, "role", response["field"])
+		stored, err := repo.GetByID(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.RoleUser, stored.Role)
+	})
+
+	t.Run("admin changing role", func(t *testing.T) {
+		router, repo, user := setupFieldAuthRouter(t)
+
+		status, response := sendAuthorized(t, router, http.MethodPut, fmt.Sprintf("/users/%d", user.ID), admin,
+			models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30, Role: "Admin"})
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []interface{}{"role"}, response["changed"])
+		stored, err := repo.GetByID(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.RoleAdmin, stored.Role)
+	})
+
+	t.Run("non-admin resending their role", func(t *testing.T) {
+		router, _, user := setupFieldAuthRouter(t)
+
+		status, response := sendAuthorized(t, router, http.MethodPut, fmt.Sprintf("/users/%d", user.ID), member,
+			models.UserRequest{Name: "Johnny", Email: "john@example.com", Age: 30, Role: models.RoleUser})
+
+		assert.Equal(t, http.StatusOK, status, "an unchanged role is not a change")
+		assert.Equal(t, []interface{}{"name"}, response["changed"])
+	})
+
+	t.Run("anonymous deactivating", func(t *testing.T) {
+		router, _, user := setupFieldAuthRouter(t)
+
+		status, response := sendAuthorized(t, router, http.MethodPut, fmt.Sprintf("/users/%d", user.ID), "",
+			models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30, IsActive: &inactive})
+
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.Equal(t, "is_active", response["field"])
+	})
+
+	t.Run("admin changing email", func(t *testing.T) {
+		router, repo, user := setupFieldAuthRouter(t)
+
+		status, _ := sendAuthorized(t, router, http.MethodPut, fmt.Sprintf("/users/%d", user.ID), admin,
+			models.UserRequest{Name: "John Doe", Email: "other@example.com", Age: 30})
+
+		assert.Equal(t, http.StatusOK, status)
+		stored, err := repo.GetByID(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "other@example.com", stored.Email)
+	})
+
+	t.Run("non-admin changing status", func(t *testing.T) {
+		router, _, user := setupFieldAuthRouter(t)
+
+		status, response := sendAuthorized(t, router, http.MethodPut, fmt.Sprintf("/users/%d/status", user.ID), member,
+			map[string]string{"status": "suspended"})
+
+		assert.Equal(t, http.StatusForbidden, status, "the status endpoint cannot bypass is_active")
+		assert.Equal(t, models.CodeForbidden, response["code"])
+	})
+}
+
+func TestUserService_AdminOnlyFieldsUnsetAllowsEveryone(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+
+	_, changed, err := userService.UpdateUser(user.ID, models.UserRequest{Name: "John Doe", Email: "other@example.com", Age: 30})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"email"}, changed)
+}
+
+func TestUserService_RoleAlwaysAdminOnly(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30, Role: models.RoleAdmin})
+	require.NoError(t, err)
+	assert.Equal(t, models.RoleUser, user.Role, "create ignores role")
+
+	_, _, err = userService.UpdateUser(user.ID, models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30, Role: models.RoleAdmin})
+	var forbidden *models.FieldForbiddenError
+	require.ErrorAs(t, err, &forbidden, "without ADMIN_ONLY_FIELDS role is still admin-only")
+	assert.Equal(t, "role", forbidden.Field)
+
+	_, _, err = userService.UpdateUser(user.ID, models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30, Role: "owner"})
+	var invalid *models.ValidationError
+	assert.ErrorAs(t, err, &invalid)
+}
+
+func TestUpdateUser_ImmutableFields(t *testing.T) {
+	admin := "Bearer " + signTestToken(t, "1", "admin", time.Hour)
+
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil, service.WithImmutableFields([]string{"id", "created_at", "email"}))
+	controller := controllers.NewUserController(userService)
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+
+	router := setupTestRouter()
+	optionalAuth := middleware.OptionalAuth(testJWTSecret)
+	router.PUT("/users/:id", optionalAuth, controller.UpdateUser)
+	router.PUT("/users/bulk", optionalAuth, controller.BulkUpdateUsers)
+	path := fmt.Sprintf("/users/%d", user.ID)
+
+	t.Run("changing an immutable field", func(t *testing.T) {
+		status, response := sendAuthorized(t, router, http.MethodPut, path, admin,
+			models.UserRequest{Name: "Johnny", Email: "other@example.com", Age: 31})
+
+		assert.Equal(t, http.StatusBadRequest, status, "admins are bound by it too")
+		assert.Equal(t, models.CodeFieldImmutable, response["code"])
+		assert.Equal(t, "email cannot be changed", response["error"])
+		assert.Equal(t, "email", response["field"])
+		stored, err := repo.GetByID(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "john@example.com", stored.Email)
+		assert.Equal(t, "John Doe", stored.Name, "nothing else is written either")
+	})
+
+	t.Run("changing it through a bulk update", func(t *testing.T) {
+		status, response := sendAuthorized(t, router, http.MethodPut, "/users/bulk", admin, map[string]interface{}{
+			"users": []map[string]interface{}{{"id": user.ID, "name": "John Doe", "email": "other@example.com", "age": 30}},
+		})
+
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, models.CodeFieldImmutable, response["code"])
+		assert.Equal(t, "email", response["field"])
+	})
+
+	t.Run("changing mutable fields", func(t *testing.T) {
+		status, response := sendAuthorized(t, router, http.MethodPut, path, "",
+			models.UserRequest{Name: "Johnny", Email: "john@example.com", Age: 31})
+
+		require.Equal(t, http.StatusOK, status, response)
+		assert.ElementsMatch(t, []interface{}{"name", "age"}, response["changed"])
+		stored, err := repo.GetByID(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Johnny", stored.Name)
+		assert.Equal(t, 31, stored.Age)
+	})
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/database"
+	"github.com/IntouchOpec/user_management/routes"
+	"github.com/stretchr/testify/assert"
+)
+
+func probeReady(health *controllers.HealthController) *httptest.ResponseRecorder {
+	router := setupTestRouter()
+	routes.SetupHealthRoutes(router, health)
+
+	req, _ := http.NewRequest(http.MethodGet, "/health/ready", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestHealthController_Ready(t *testing.T) {
+	health := controllers.NewHealthController()
+	router := setupTestRouter()
+	routes.SetupHealthRoutes(router, health)
+
+	probe := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/health/ready", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := probe()
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "5", w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"status":"warming_up"}`, w.Body.String())
+
+	health.SetReady(true)
+	w = probe()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"status":"ready"}`, w.Body.String())
+
+	// Shutdown takes the server out of rotation again
+	health.SetReady(false)
+	assert.Equal(t, http.StatusServiceUnavailable, probe().Code)
+}
+
+func TestHealthController_Ready_Dependencies(t *testing.T) {
+	setupTestDB(t)
+	mr, client := newMiniRedis(t)
+	pingRedis := func(ctx context.Context) error { return client.Ping(ctx).Err() }
+
+	t.Run("all dependencies up", func(t *testing.T) {
+		health := controllers.NewHealthController(
+			controllers.WithDependency("database", database.Ping),
+			controllers.WithDependency("redis", pingRedis),
+		)
+		health.SetReady(true)
+
+		w := probeReady(health)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status":"ready","database":"up","redis":"up"}`, w.Body.String())
+	})
+
+	t.Run("database down", func(t *testing.T) {
+		health := controllers.NewHealthController(
+			controllers.WithDependency("database", func(ctx context.Context) error { return errors.New("connection refused") }),
+			controllers.WithDependency("redis", pingRedis),
+		)
+		health.SetReady(true)
+
+		w := probeReady(health)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.JSONEq(t, `{"status":"unavailable","database":"down","redis":"up"}`, w.Body.String())
+	})
+
+	t.Run("nil redis", func(t *testing.T) {
+		health := controllers.NewHealthController(
+			controllers.WithDependency("database", database.Ping),
+			controllers.WithDependency("redis", nil),
+		)
+		health.SetReady(true)
+
+		w := probeReady(health)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.JSONEq(t, `{"status":"unavailable","database":"up","redis":"down"}`, w.Body.String())
+	})
+
+	t.Run("redis stopped", func(t *testing.T) {
+		health := controllers.NewHealthController(
+			controllers.WithDependency("database", database.Ping),
+			controllers.WithDependency("redis", pingRedis),
+		)
+		health.SetReady(true)
+		mr.Close()
+
+		w := probeReady(health)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.JSONEq(t, `{"status":"unavailable","database":"up","redis":"down"}`, w.Body.String())
+	})
+
+	t.Run("warmup is reported before dependencies", func(t *testing.T) {
+		health := controllers.NewHealthController(controllers.WithDependency("redis", nil))
+
+		w := probeReady(health)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.JSONEq(t, `{"status":"warming_up"}`, w.Body.String())
+	})
+}
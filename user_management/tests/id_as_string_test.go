@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserController_IDAsString(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+	controller := controllers.NewUserController(userService)
+	router := setupTestRouter()
+	router.GET("/users/:id", controller.GetUser)
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	get := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/users/%d", user.ID), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("numeric by default", func(t *testing.T) {
+		w := get()
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), fmt.Sprintf(`"id":%d,`, user.ID))
+	})
+
+	t.Run("string when enabled", func(t *testing.T) {
+		models.SetIDAsString(true)
+		t.Cleanup(func() { models.SetIDAsString(false) })
+
+		w := get()
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), fmt.Sprintf(`"id":"%d"`, user.ID))
+		assert.Equal(t, 1, strings.Count(w.Body.String(), `"id"`), "the numeric id is not rendered as well")
+
+		var response struct {
+			Data models.UserResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, user.ID, response.Data.ID, "string IDs read back")
+		assert.Equal(t, "john@example.com", response.Data.Email)
+	})
+}
+
+func TestUserController_BulkDeleteUsers_StringIDs(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+	controller := controllers.NewUserController(userService)
+	router := setupTestRouter()
+	router.DELETE("/users", controller.BulkDeleteUsers)
+
+	for _, email := range []string{"one@example.com", "two@example.com", "three@example.com"} {
+		_, err := userService.CreateUser(models.UserRequest{Name: "User", Email: email, Age: 30})
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "numbers and strings", body: `{"ids": [1, "2"]}`, expectedStatus: http.StatusOK},
+		{name: "not a number", body: `{"ids": ["three"]}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodDelete, "/users", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	count, err := repo.Count()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
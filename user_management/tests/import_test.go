@@ -0,0 +1,234 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/middleware"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// importCSV contains a row whose email already exists and a row that
+// repeats an earlier row of the same file
+const importCSV = `name,email,age,phone
+Existing Updated,existing@example.com,41,5550001111
+New User,new@example.com,22,
+New User Again,new@example.com,23,
+`
+
+func TestUserImport_DuplicateStrategies(t *testing.T) {
+	tests := []struct {
+		name           string
+		strategy       string
+		expectedStatus int
+		expected       models.ImportResult
+		existingName   string
+		newName        string
+		expectedCount  int64
+	}{
+		{
+			name:           "default skips duplicates",
+			strategy:       "",
+			expectedStatus: http.StatusOK,
+			expected:       models.ImportResult{Created: 1, Skipped: 2},
+			existingName:   "Existing",
+			newName:        "New User",
+			expectedCount:  2,
+		},
+		{
+			name:           "update overwrites duplicates",
+			strategy:       "update",
+			expectedStatus: http.StatusOK,
+			expected:       models.ImportResult{Created: 1, Updated: 1},
+			existingName:   "Existing Updated",
+			newName:        "New User Again",
+			expectedCount:  2,
+		},
+		{
+			name:           "error aborts the import",
+			strategy:       "error",
+			expectedStatus: http.StatusConflict,
+			existingName:   "Existing",
+			expectedCount:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			db := setupTestDB(t)
+			repo := repository.NewUserRepository(db)
+			require.NoError(t, repo.Create(&models.User{Name: "Existing", Email: "existing@example.com", Age: 40, IsActive: true}))
+
+			controller := controllers.NewUserController(service.NewUserService(repo, nil))
+			router := setupTestRouter()
+			router.POST("/users/import", controller.ImportUsers)
+
+			// Create request
+			path := "/users/import"
+			if tt.strategy != "" {
+				path += "?duplicate_strategy=" + tt.strategy
+			}
+			req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBufferString(importCSV))
+			req.Header.Set("Content-Type", "text/csv")
+
+			// Perform request
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// Assertions
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response struct {
+					Data models.ImportResult `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expected, response.Data)
+			}
+
+			existing, err := repo.GetByEmail("existing@example.com")
+			require.NoError(t, err)
+			assert.Equal(t, tt.existingName, existing.Name)
+
+			created, err := repo.GetByEmail("new@example.com")
+			if tt.newName == "" {
+				assert.Error(t, err, "aborted import must not create any user")
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.newName, created.Name)
+			}
+
+			count, err := repo.Count()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCount, count)
+		})
+	}
+}
+
+func TestUserController_ImportUsers_Multipart(t *testing.T) {
+	mockService := new(MockUserService)
+	controller := controllers.NewUserController(mockService)
+	router := setupTestRouter()
+	router.POST("/users/import", controller.ImportUsers)
+
+	active := false
+	expected := []models.UserRequest{{Name: "Jane Doe", Email: "jane@example.com", Age: 25, IsActive: &active}}
+	mockService.On("ImportUsers", expected, models.DuplicateSkip).Return(&models.ImportResult{Created: 1}, nil)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", "users.csv")
+	part.Write([]byte("Email,Name,Age,Is_Active\njane@example.com,Jane Doe,25,false\n"))
+	writer.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "/users/import", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestUserController_ImportUsers_InvalidInput(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		body  string
+	}{
+		{name: "invalid strategy", query: "?duplicate_strategy=merge", body: "name,email,age\nJohn,john@example.com,30\n"},
+		{name: "empty file", body: ""},
+		{name: "header only", body: "name,email,age\n"},
+		{name: "missing required column", body: "name,email\nJohn,john@example.com\n"},
+		{name: "unknown column", body: "name,emial,age\nJohn,john@example.com,30\n"},
+		{name: "invalid age", body: "name,email,age\nJohn,john@example.com,thirty\n"},
+		{name: "invalid created_at", body: "name,email,age,created_at\nJohn,john@example.com,30,yesterday\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUserService)
+			controller := controllers.NewUserController(mockService)
+			router := setupTestRouter()
+			router.POST("/users/import", controller.ImportUsers)
+
+			req, _ := http.NewRequest(http.MethodPost, "/users/import"+tt.query, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "text/csv")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockService.AssertNotCalled(t, "ImportUsers", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUserImport_TimestampOverride(t *testing.T) {
+	original := time.Date(2019, 3, 14, 9, 26, 53, 0, time.UTC)
+	csv := "name,email,age,created_at\nMigrated User,migrated@example.com,30," + original.Format(time.RFC3339) + "\n"
+	admin := "Bearer " + signTestToken(t, "1", "admin", time.Hour)
+
+	tests := []struct {
+		name          string
+		allow         bool
+		authorization string
+		keeps         bool
+	}{
+		{name: "flag on with admin keeps created_at", allow: true, authorization: admin, keeps: true},
+		{name: "flag off ignores created_at", allow: false, authorization: admin},
+		{name: "flag on without admin ignores created_at", allow: true, authorization: "Bearer " + signTestToken(t, "2", "user", time.Hour)},
+		{name: "flag on anonymous ignores created_at", allow: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			repo := repository.NewUserRepository(db)
+			userService := service.NewUserService(repo, nil, service.WithTimestampOverride(tt.allow))
+			controller := controllers.NewUserController(userService)
+			router := setupTestRouter()
+			router.POST("/users/import", middleware.OptionalAuth(testJWTSecret), controller.ImportUsers)
+
+			req, _ := http.NewRequest(http.MethodPost, "/users/import", strings.NewReader(csv))
+			req.Header.Set("Content-Type", "text/csv")
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			stored, err := repo.GetByEmail("migrated@example.com")
+			require.NoError(t, err)
+			if tt.keeps {
+				assert.True(t, original.Equal(stored.CreatedAt), "created_at = %v", stored.CreatedAt)
+			} else {
+				assert.WithinDuration(t, time.Now(), stored.CreatedAt, time.Minute)
+			}
+		})
+	}
+}
+
+func TestUserService_CreateUser_IgnoresCreatedAt(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil, service.WithTimestampOverride(true))
+
+	original := time.Date(2019, 3, 14, 0, 0, 0, 0, time.UTC)
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30, CreatedAt: &original})
+	require.NoError(t, err)
+
+	assert.WithinDuration(t, time.Now(), user.CreatedAt, time.Minute, "normal creates always stamp now")
+}
@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/auth"
+	"github.com/IntouchOpec/user_management/config"
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/middleware"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/routes"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTAuth(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/protected", middleware.JWTAuth(testJWTSecret), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.MustGet(middleware.UserIDKey)})
+	})
+
+	valid := signTestToken(t, "42", "user", time.Hour)
+	otherSecret, err := auth.Sign("another-secret", auth.Claims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   "42",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}})
+	require.NoError(t, err)
+	tests := []struct {
+		name          string
+		authorization string
+		expectedCode  int
+	}{
+		{name: "valid token", authorization: "Bearer " + valid, expectedCode: http.StatusOK},
+		{name: "expired token", authorization: "Bearer " + signTestToken(t, "42", "user", -time.Minute), expectedCode: http.StatusUnauthorized},
+		{name: "wrong secret", authorization: "Bearer " + otherSecret, expectedCode: http.StatusUnauthorized},
+		{name: "non-user subject", authorization: "Bearer " + signTestToken(t, "service-account", "user", time.Hour), expectedCode: http.StatusUnauthorized},
+		{name: "malformed token", authorization: "Bearer not-a-jwt", expectedCode: http.StatusUnauthorized},
+		{name: "wrong scheme", authorization: "Basic " + valid, expectedCode: http.StatusUnauthorized},
+		{name: "no token after scheme", authorization: "Bearer", expectedCode: http.StatusUnauthorized},
+		{name: "missing header", expectedCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/protected", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			if tt.expectedCode == http.StatusOK {
+				assert.Equal(t, float64(42), response["user_id"])
+				return
+			}
+			assert.Equal(t, "invalid or expired token", response["error"])
+			assert.Equal(t, models.CodeUnauthorized, response["code"])
+		})
+	}
+}
+
+func TestSetupRoutes_WritesRequireToken(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+
+	serve := func(authConfig config.AuthConfig, method, path, authorization string, body interface{}) int {
+		router := setupTestRouter()
+		routes.SetupRoutes(router, controllers.NewUserController(userService), nil, authConfig)
+
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(data))
+		req.Header.Set("Content-Type", "application/json")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	authConfig := config.AuthConfig{JWTSecret: testJWTSecret}
+	token := "Bearer " + signTestToken(t, "7", "user", time.Hour)
+	newUser := models.UserRequest{Name: "Jane Doe", Email: "jane@example.com", Age: 30}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(authConfig, http.MethodPost, "/api/v1/users", "", newUser))
+	assert.Equal(t, http.StatusUnauthorized, serve(authConfig, http.MethodDelete, "/api/v1/users/1", "", nil))
+	assert.Equal(t, http.StatusCreated, serve(authConfig, http.MethodPost, "/api/v1/users", token, newUser))
+
+	userPath := fmt.Sprintf("/api/v1/users/%d", user.ID)
+	assert.Equal(t, http.StatusOK, serve(authConfig, http.MethodGet, userPath, "", nil), "reads stay open by default")
+
+	authConfig.RequireAuthForReads = true
+	assert.Equal(t, http.StatusUnauthorized, serve(authConfig, http.MethodGet, userPath, "", nil))
+	assert.Equal(t, http.StatusOK, serve(authConfig, http.MethodGet, userPath, token, nil))
+}
+
+func TestSetupRoutes_DeleteInactiveRequiresAdmin(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", user.ID).Update("is_active", false).Error)
+
+	router := setupTestRouter()
+	routes.SetupRoutes(router, controllers.NewUserController(userService), nil, config.AuthConfig{JWTSecret: testJWTSecret})
+	path := "/api/v1/users/inactive?confirm=true&before=" + time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	status, response := sendAuthorized(t, router, http.MethodDelete, path, "Bearer "+signTestToken(t, "7", "user", time.Hour), nil)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, models.CodeForbidden, response["code"])
+	_, err = userService.GetUserByID(user.ID)
+	require.NoError(t, err, "nothing is deleted for a non-admin")
+
+	status, response = sendAuthorized(t, router, http.MethodDelete, path, "Bearer "+signTestToken(t, "1", "admin", time.Hour), nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(1), response["deleted"])
+}
+
+func TestSetupRoutes_ExportRequiresAdmin(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+	_, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+
+	router := setupTestRouter()
+	routes.SetupRoutes(router, controllers.NewUserController(userService), nil, config.AuthConfig{JWTSecret: testJWTSecret})
+	export := func(authorization string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/users/export", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, export("").Code)
+	w := export("Bearer " + signTestToken(t, "7", "user", time.Hour))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.NotContains(t, w.Body.String(), "john@example.com")
+
+	w = export("Bearer " + signTestToken(t, "1", "admin", time.Hour))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "john@example.com")
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/IntouchOpec/user_management/cache"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_ListCache(t *testing.T) {
+	mr, client := newMiniRedis(t)
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, cache.NewRedis(client))
+
+	first, err := userService.CreateUser(models.UserRequest{Name: "Alice", Email: "alice@example.com", Age: 30})
+	require.NoError(t, err)
+
+	users, total, err := userService.GetAllUsers(1, 10)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, int64(1), total)
+
+	// A row written behind the service's back is not seen while the page
+	// is cached
+	require.NoError(t, db.Create(&models.User{Name: "Hidden", Email: "hidden@example.com", Age: 30, Status: models.StatusActive}).Error)
+	users, total, err = userService.GetAllUsers(1, 10)
+	require.NoError(t, err)
+	assert.Len(t, users, 1, "the page is served from cache")
+	assert.Equal(t, int64(1), total)
+
+	t.Run("create bumps the version", func(t *testing.T) {
+		before, _ := mr.Get("users:list:version")
+		_, err := userService.CreateUser(models.UserRequest{Name: "Bob", Email: "bob@example.com", Age: 40})
+		require.NoError(t, err)
+		after, _ := mr.Get("users:list:version")
+		assert.NotEqual(t, before, after)
+
+		users, total, err := userService.GetAllUsers(1, 10)
+		require.NoError(t, err)
+		assert.Len(t, users, 3, "the stale page is not served")
+		assert.Equal(t, int64(3), total)
+	})
+
+	t.Run("filtered lists are cached separately", func(t *testing.T) {
+		minAge := 35
+		users, total, err := userService.SearchUsers(models.UserFilter{MinAge: &minAge}, models.UserSort{}, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, "Bob", users[0].Name)
+		assert.Equal(t, int64(1), total)
+
+		users, _, err = userService.SearchUsers(models.UserFilter{}, models.UserSort{Column: "name", Descending: true}, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, users, 3)
+		assert.Equal(t, "Hidden", users[0].Name)
+	})
+
+	t.Run("update bumps the version", func(t *testing.T) {
+		_, _, err := userService.UpdateUser(first.ID, models.UserRequest{Name: "Alicia", Email: "alice@example.com", Age: 30})
+		require.NoError(t, err)
+
+		users, _, err := userService.GetAllUsers(1, 10)
+		require.NoError(t, err)
+		require.NotEmpty(t, users)
+		assert.Equal(t, "Alicia", users[0].Name)
+	})
+
+	t.Run("delete bumps the version", func(t *testing.T) {
+		require.NoError(t, userService.DeleteUser(first.ID))
+
+		users, total, err := userService.GetAllUsers(1, 10)
+		require.NoError(t, err)
+		assert.Len(t, users, 2)
+		assert.Equal(t, int64(2), total)
+	})
+}
+
+func TestUserService_ListCache_WithoutRedis(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+
+	_, err := userService.CreateUser(models.UserRequest{Name: "Alice", Email: "alice@example.com", Age: 30})
+	require.NoError(t, err)
+	_, _, err = userService.GetAllUsers(1, 10)
+	require.NoError(t, err)
+
+	require.NoError(t, db.Create(&models.User{Name: "Bob", Email: "bob@example.com", Age: 30, Status: models.StatusActive}).Error)
+	users, _, err := userService.GetAllUsers(1, 10)
+	require.NoError(t, err)
+	assert.Len(t, users, 2, "every list is read from the database")
+}
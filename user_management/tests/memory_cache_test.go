@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/cache"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	memory := cache.NewMemory()
+	memory.SetClock(func() time.Time { return now })
+
+	_, err := memory.Get(ctx, "missing")
+	assert.ErrorIs(t, err, cache.ErrMiss)
+
+	require.NoError(t, memory.Set(ctx, "short", []byte("a"), time.Minute))
+	require.NoError(t, memory.SetMany(ctx, map[string][]byte{"long": []byte("b"), "forever": []byte("c")}, time.Hour))
+
+	value, err := memory.Get(ctx, "short")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("a"), value)
+
+	values, err := memory.GetMany(ctx, "short", "missing", "long")
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("a"), nil, []byte("b")}, values)
+
+	now = now.Add(time.Minute)
+	_, err = memory.Get(ctx, "short")
+	assert.ErrorIs(t, err, cache.ErrMiss, "entries expire after their TTL")
+	_, err = memory.Get(ctx, "long")
+	assert.NoError(t, err)
+
+	require.NoError(t, memory.Del(ctx, "long", "missing"))
+	_, err = memory.Get(ctx, "long")
+	assert.ErrorIs(t, err, cache.ErrMiss)
+}
+
+func TestUserService_MemoryCache(t *testing.T) {
+	user := &models.User{ID: 1, Name: "John", Email: "john@example.com", Age: 25, IsActive: true}
+	now := time.Now()
+	memory := cache.NewMemory()
+	memory.SetClock(func() time.Time { return now })
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByID", uint(1)).Return(user, nil)
+	userService := service.NewUserService(mockRepo, memory)
+
+	t.Run("miss loads and caches", func(t *testing.T) {
+		result, err := userService.GetUserByID(1)
+
+		require.NoError(t, err)
+		assert.Equal(t, "John", result.Name)
+		mockRepo.AssertNumberOfCalls(t, "GetByID", 1)
+		_, err = memory.Get(context.Background(), "user:1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("hit skips the repository", func(t *testing.T) {
+		result, err := userService.GetUserByID(1)
+
+		require.NoError(t, err)
+		assert.Equal(t, "John", result.Name)
+		mockRepo.AssertNumberOfCalls(t, "GetByID", 1)
+	})
+
+	t.Run("expired entry is reloaded", func(t *testing.T) {
+		now = now.Add(16 * time.Minute)
+
+		_, err := userService.GetUserByID(1)
+
+		require.NoError(t, err)
+		mockRepo.AssertNumberOfCalls(t, "GetByID", 2)
+	})
+
+	t.Run("delete evicts", func(t *testing.T) {
+		mockRepo.On("Delete", uint(1)).Return(nil)
+
+		require.NoError(t, userService.DeleteUser(1))
+
+		_, err := memory.Get(context.Background(), "user:1")
+		assert.ErrorIs(t, err, cache.ErrMiss)
+		_, err = userService.GetUserByID(1)
+		require.NoError(t, err)
+		mockRepo.AssertNumberOfCalls(t, "GetByID", 3)
+	})
+}
+
+func TestMemoryCache_Incr(t *testing.T) {
+	c := cache.NewMemory()
+	ctx := context.Background()
+
+	for want := int64(1); want <= 3; want++ {
+		got, err := c.Incr(ctx, "counter")
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	require.NoError(t, c.Set(ctx, "name", []byte("alice"), 0))
+	_, err := c.Incr(ctx, "name")
+	assert.Error(t, err, "only integers can be incremented")
+}
+
+// failingCache is a cache whose every operation fails
+type failingCache struct{}
+
+var errCacheDown = errors.New("cache down")
+
+func (failingCache) Get(ctx context.Context, key string) ([]byte, error) { return nil, errCacheDown }
+func (failingCache) GetMany(ctx context.Context, keys ...string) ([][]byte, error) {
+	return nil, errCacheDown
+}
+func (failingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return errCacheDown
+}
+func (failingCache) SetMany(ctx context.Context, values map[string][]byte, ttl time.Duration) error {
+	return errCacheDown
+}
+func (failingCache) Del(ctx context.Context, keys ...string) error       { return errCacheDown }
+func (failingCache) Incr(ctx context.Context, key string) (int64, error) { return 0, errCacheDown }
+
+func TestUserService_CacheFailuresFallBackToRepository(t *testing.T) {
+	user := &models.User{ID: 1, Name: "John", Email: "john@example.com", Age: 25, IsActive: true}
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByID", uint(1)).Return(user, nil)
+	mockRepo.On("Delete", uint(1)).Return(nil)
+	userService := service.NewUserService(mockRepo, failingCache{})
+
+	result, err := userService.GetUserByID(1)
+	require.NoError(t, err)
+	assert.Equal(t, "John", result.Name)
+
+	assert.NoError(t, userService.DeleteUser(1))
+}
+
+func TestUserService_InvalidCachedDataFallsBackToRepository(t *testing.T) {
+	user := &models.User{ID: 1, Name: "John", Email: "john@example.com", Age: 25, IsActive: true}
+	memory := cache.NewMemory()
+	require.NoError(t, memory.Set(context.Background(), "user:1", []byte("not json"), time.Minute))
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByID", uint(1)).Return(user, nil)
+	userService := service.NewUserService(mockRepo, memory)
+
+	result, err := userService.GetUserByID(1)
+
+	require.NoError(t, err)
+	assert.Equal(t, "John", result.Name)
+	mockRepo.AssertNumberOfCalls(t, "GetByID", 1)
+	cached, err := memory.Get(context.Background(), "user:1")
+	require.NoError(t, err)
+	assert.Contains(t, string(cached), `"John"`, "the bad entry is replaced")
+}
@@ -2,13 +2,25 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/IntouchOpec/user_management/config"
+	"github.com/IntouchOpec/user_management/controllers"
 	"github.com/IntouchOpec/user_management/middleware"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/reqctx"
+	"github.com/IntouchOpec/user_management/routes"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -64,7 +76,7 @@ func TestRecovery_WithStringError(t *testing.T) {
 	// Capture log output
 	var buf bytes.Buffer
 	log.SetOutput(&buf)
-	defer log.SetOutput(nil)
+	defer log.SetOutput(os.Stderr)
 
 	router.Use(middleware.Recovery())
 
@@ -87,6 +99,159 @@ func TestRecovery_WithStringError(t *testing.T) {
 	assert.Contains(t, logOutput, "string error message")
 }
 
+func TestRecoveryWithLog_WritesStackAndRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	var panicLog bytes.Buffer
+	router.Use(middleware.RequestID())
+	router.Use(middleware.RecoveryWithLog(&panicLog))
+	router.GET("/explode", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/explode?id=7", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Body.String(), "the client gets no panic details")
+
+	entry := panicLog.String()
+	assert.Contains(t, entry, "panic: boom")
+	assert.Contains(t, entry, "request: GET /explode?id=7")
+	assert.Contains(t, entry, "request_id: req-123")
+	assert.Contains(t, entry, "runtime/debug.Stack")
+	assert.Contains(t, entry, "middleware_test.go")
+}
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.RequestID())
+
+	var seen string
+	router.GET("/test", func(c *gin.Context) {
+		seen, _ = reqctx.RequestID(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+
+	t.Run("propagates the caller's ID", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set(middleware.RequestIDHeader, "abc-123")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, "abc-123", seen)
+		assert.Equal(t, "abc-123", w.Header().Get(middleware.RequestIDHeader))
+	})
+
+	t.Run("generates a missing or malformed ID", func(t *testing.T) {
+		for _, header := range []string{"", "has spaces\nand newlines"} {
+			req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set(middleware.RequestIDHeader, header)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Len(t, seen, 32)
+			assert.Equal(t, seen, w.Header().Get(middleware.RequestIDHeader))
+		}
+	})
+}
+
+func TestHTTPSRedirect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.HTTPSRedirect())
+	router.GET("/api/v1/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/api/v1/users", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(method, target, proto string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, target, nil)
+		req.Host = "api.example.com"
+		if proto != "" {
+			req.Header.Set("X-Forwarded-Proto", proto)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("redirects forwarded http", func(t *testing.T) {
+		w := serve(http.MethodGet, "/api/v1/users?page=2", "http")
+
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, "https://api.example.com/api/v1/users?page=2", w.Header().Get("Location"))
+	})
+
+	t.Run("keeps the method for writes", func(t *testing.T) {
+		w := serve(http.MethodPost, "/api/v1/users", "HTTP")
+
+		assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+		assert.Equal(t, "https://api.example.com/api/v1/users", w.Header().Get("Location"))
+	})
+
+	t.Run("passes through https and unproxied requests", func(t *testing.T) {
+		for _, proto := range []string{"https", "https, http", ""} {
+			w := serve(http.MethodGet, "/api/v1/users", proto)
+			assert.Equal(t, http.StatusOK, w.Code, proto)
+		}
+	})
+
+	t.Run("exempts health checks", func(t *testing.T) {
+		w := serve(http.MethodGet, "/health", "http")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestAPIVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		status   int
+		expected string
+	}{
+		{name: "no header uses the default", header: "", status: http.StatusOK, expected: "v2"},
+		{name: "supported version", header: "v1", status: http.StatusOK, expected: "v1"},
+		{name: "bare number", header: "2", status: http.StatusOK, expected: "v2"},
+		{name: "case and spacing", header: " V1 ", status: http.StatusOK, expected: "v1"},
+		{name: "unsupported version", header: "v3", status: http.StatusBadRequest},
+		{name: "garbage", header: "latest", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(middleware.APIVersion([]string{"v2", "1"}))
+
+			handled := false
+			router.GET("/test", func(c *gin.Context) {
+				handled = true
+				c.String(http.StatusOK, middleware.RequestAPIVersion(c))
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+			if tt.header != "" {
+				req.Header.Set(middleware.APIVersionHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status != http.StatusOK {
+				assert.False(t, handled, "unsupported versions never reach the handler")
+				assert.JSONEq(t, `{"error": "Unsupported API version `+tt.header+`", "code": "UNSUPPORTED_API_VERSION", "supported": ["v2", "v1"]}`, w.Body.String())
+				return
+			}
+			assert.Equal(t, tt.expected, w.Body.String())
+			assert.Equal(t, tt.expected, w.Header().Get("API-Version"))
+		})
+	}
+}
+
 func TestCORS(t *testing.T) {
 	// Setup
 	gin.SetMode(gin.TestMode)
@@ -108,7 +273,8 @@ func TestCORS(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
 	assert.Equal(t, "GET, POST, PUT, DELETE, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
-	assert.Equal(t, "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Tenant-ID, X-HTTP-Method-Override, X-Request-ID, Accept-Version", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "X-Response-Time, X-Request-ID, API-Version", w.Header().Get("Access-Control-Expose-Headers"))
 }
 
 func TestCORS_OptionsRequest(t *testing.T) {
@@ -136,7 +302,7 @@ func TestCORS_OptionsRequest(t *testing.T) {
 	assert.Equal(t, http.StatusNoContent, w.Code)
 	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
 	assert.Equal(t, "GET, POST, PUT, DELETE, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
-	assert.Equal(t, "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Tenant-ID, X-HTTP-Method-Override, X-Request-ID, Accept-Version", w.Header().Get("Access-Control-Allow-Headers"))
 }
 
 func TestMiddleware_Combined(t *testing.T) {
@@ -163,3 +329,364 @@ func TestMiddleware_Combined(t *testing.T) {
 	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
 	assert.True(t, w.Body.Len() > 0)
 }
+
+func TestResponseTime(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ResponseTime())
+
+	router.GET("/slow", func(c *gin.Context) {
+		time.Sleep(5 * time.Millisecond)
+		c.JSON(http.StatusOK, gin.H{"message": "test"})
+	})
+	router.GET("/empty", func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusNoContent)
+	})
+
+	t.Run("header reflects handler duration", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/slow", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		header := w.Header().Get("X-Response-Time")
+		assert.NotEmpty(t, header)
+
+		ms, err := strconv.ParseFloat(header, 64)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, ms, 5.0)
+	})
+
+	t.Run("header set without a body", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, "/empty", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		_, err := strconv.ParseFloat(w.Header().Get("X-Response-Time"), 64)
+		assert.NoError(t, err)
+	})
+}
+
+func TestCacheTTLHint(t *testing.T) {
+	tests := []struct {
+		name        string
+		remoteAddr  string
+		header      string
+		expectTTL   bool
+		expectedTTL time.Duration
+	}{
+		{name: "trusted caller with seconds", remoteAddr: "10.1.2.3:4321", header: "120", expectTTL: true, expectedTTL: 2 * time.Minute},
+		{name: "trusted caller with duration", remoteAddr: "10.1.2.3:4321", header: "5m", expectTTL: true, expectedTTL: 5 * time.Minute},
+		{name: "value above maximum is clamped", remoteAddr: "10.1.2.3:4321", header: "72h", expectTTL: true, expectedTTL: middleware.MaxCacheTTLHint},
+		{name: "value below minimum is clamped", remoteAddr: "10.1.2.3:4321", header: "0", expectTTL: true, expectedTTL: middleware.MinCacheTTLHint},
+		{name: "invalid value is ignored", remoteAddr: "10.1.2.3:4321", header: "forever", expectTTL: false},
+		{name: "untrusted caller is ignored", remoteAddr: "203.0.113.9:4321", header: "120", expectTTL: false},
+		{name: "no header", remoteAddr: "10.1.2.3:4321", header: "", expectTTL: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(middleware.CacheTTLHint([]string{"10.0.0.0/8", "not-a-cidr"}))
+
+			var gotTTL time.Duration
+			var gotOK bool
+			router.GET("/test", func(c *gin.Context) {
+				gotTTL, gotOK = reqctx.CacheTTL(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			// Create request
+			req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", "10.9.9.9")
+			if tt.header != "" {
+				req.Header.Set("X-Cache-TTL", tt.header)
+			}
+
+			// Perform request
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// Assertions
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expectTTL, gotOK)
+			if tt.expectTTL {
+				assert.Equal(t, tt.expectedTTL, gotTTL)
+			}
+		})
+	}
+}
+
+func TestTenant(t *testing.T) {
+	tests := []struct {
+		name           string
+		host           string
+		header         string
+		expectedStatus int
+		expectedTenant string
+	}{
+		{name: "header selects tenant", host: "api.example.com", header: "Acme", expectedStatus: http.StatusOK, expectedTenant: "acme"},
+		{name: "subdomain selects tenant", host: "globex.example.com:8080", expectedStatus: http.StatusOK, expectedTenant: "globex"},
+		{name: "www is not a tenant", host: "www.example.com", expectedStatus: http.StatusOK},
+		{name: "bare host has no tenant", host: "localhost:8080", expectedStatus: http.StatusOK},
+		{name: "ip host has no tenant", host: "10.0.0.1:8080", expectedStatus: http.StatusOK},
+		{name: "invalid header is rejected", host: "localhost", header: "acme corp", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(middleware.Tenant())
+
+			var gotTenant string
+			router.GET("/test", func(c *gin.Context) {
+				gotTenant, _ = reqctx.TenantID(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			// Create request
+			req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set(middleware.TenantHeader, tt.header)
+			}
+
+			// Perform request
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// Assertions
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedTenant, gotTenant)
+		})
+	}
+}
+
+// setupRateLimitRouter serves /test behind the same OptionalAuth and
+// RateLimit chain main installs
+func setupRateLimitRouter(t *testing.T, limits middleware.RateLimits) (*gin.Engine, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.OptionalAuth(testJWTSecret))
+	router.Use(middleware.RateLimit(client, limits))
+	router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router, mr
+}
+
+// rateLimitedRequest sends GET /test from ip, authenticated as actorID
+// unless it is empty
+func rateLimitedRequest(t *testing.T, router *gin.Engine, ip, actorID string) *httptest.ResponseRecorder {
+	return rateLimitedRequestTo(t, router, "/test", ip, actorID)
+}
+
+func rateLimitedRequestTo(t *testing.T, router *gin.Engine, path, ip, actorID string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = ip + ":12345"
+	if actorID != "" {
+		req.Header.Set("Authorization", "Bearer "+signTestToken(t, actorID, "user", time.Hour))
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit_PerUserAcrossIPs(t *testing.T) {
+	router, mr := setupRateLimitRouter(t, middleware.RateLimits{PerIP: 100, PerUser: 3, Window: time.Minute})
+
+	for i, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		w := rateLimitedRequest(t, router, ip, "42")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "3", w.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, strconv.Itoa(2-i), w.Header().Get("X-RateLimit-Remaining"))
+	}
+
+	w := rateLimitedRequest(t, router, "10.0.0.4", "42")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"RATE_LIMITED"`)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// Other accounts and anonymous callers from the same IP are unaffected
+	assert.Equal(t, http.StatusOK, rateLimitedRequest(t, router, "10.0.0.4", "43").Code)
+	assert.Equal(t, http.StatusOK, rateLimitedRequest(t, router, "10.0.0.4", "").Code)
+
+	// The window expires
+	mr.FastForward(time.Minute)
+	assert.Equal(t, http.StatusOK, rateLimitedRequest(t, router, "10.0.0.5", "42").Code)
+}
+
+func TestRateLimit_AnonymousFallsBackToIP(t *testing.T) {
+	router, mr := setupRateLimitRouter(t, middleware.RateLimits{PerIP: 2, PerUser: 100, Window: time.Minute})
+
+	assert.Equal(t, http.StatusOK, rateLimitedRequest(t, router, "10.0.0.1", "").Code)
+	assert.Equal(t, http.StatusOK, rateLimitedRequest(t, router, "10.0.0.1", "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, rateLimitedRequest(t, router, "10.0.0.1", "").Code)
+	assert.Equal(t, http.StatusOK, rateLimitedRequest(t, router, "10.0.0.2", "").Code)
+
+	assert.True(t, mr.Exists("ratelimit:ip:10.0.0.1"))
+	assert.False(t, mr.Exists("ratelimit:user:0"))
+}
+
+func TestRateLimit_IgnoresForwardedFor(t *testing.T) {
+	router, mr := setupRateLimitRouter(t, middleware.RateLimits{PerIP: 2, PerUser: 100, Window: time.Minute})
+
+	var codes []int
+	for _, forwarded := range []string{"203.0.113.1", "203.0.113.2", "203.0.113.3"} {
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		req.RemoteAddr = "10.0.0.1:12345"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes, "a spoofed header does not buy a fresh bucket")
+	assert.True(t, mr.Exists("ratelimit:ip:10.0.0.1"))
+	assert.False(t, mr.Exists("ratelimit:ip:203.0.113.1"))
+}
+
+func TestRateLimit_FailsOpen(t *testing.T) {
+	router, mr := setupRateLimitRouter(t, middleware.RateLimits{PerIP: 1, PerUser: 1, Window: time.Minute})
+	mr.Close()
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, rateLimitedRequest(t, router, "10.0.0.1", "42").Code)
+	}
+
+	gin.SetMode(gin.TestMode)
+	unlimited := gin.New()
+	unlimited.Use(middleware.RateLimit(nil, middleware.RateLimits{PerIP: 1, Window: time.Minute}))
+	unlimited.GET("/test", func(c *gin.Context) { c.Status(http.StatusOK) })
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, rateLimitedRequest(t, unlimited, "10.0.0.1", "").Code)
+	}
+}
+
+func TestRateLimit_PerUserThroughRoutes(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	userService := service.NewUserService(repository.NewUserRepository(setupTestDB(t)), nil)
+
+	// The chain main builds: the token is read globally, authentication is
+	// enforced per route
+	router := setupTestRouter()
+	router.Use(middleware.OptionalAuth(testJWTSecret))
+	router.Use(middleware.RateLimit(client, middleware.RateLimits{PerIP: 100, PerUser: 2, Window: time.Minute}))
+	routes.SetupRoutes(router, controllers.NewUserController(userService), nil, config.AuthConfig{JWTSecret: testJWTSecret})
+
+	assert.Equal(t, http.StatusOK, rateLimitedRequestTo(t, router, "/api/v1/users", "10.0.0.1", "42").Code)
+	assert.Equal(t, http.StatusOK, rateLimitedRequestTo(t, router, "/api/v1/users", "10.0.0.2", "42").Code)
+	w := rateLimitedRequestTo(t, router, "/api/v1/users", "10.0.0.3", "42")
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "the account's budget is shared across IPs")
+	assert.True(t, mr.Exists("ratelimit:user:42"))
+	assert.False(t, mr.Exists("ratelimit:ip:10.0.0.1"), "authenticated requests are not counted per IP")
+}
+
+func setupMethodOverrideRouter() http.Handler {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/users/:id", func(c *gin.Context) { c.String(http.StatusOK, "create") })
+	router.PUT("/users/:id", func(c *gin.Context) { c.String(http.StatusOK, "update") })
+	router.DELETE("/users/:id", func(c *gin.Context) {
+		c.String(http.StatusOK, "delete "+c.Param("id")+" "+c.GetHeader(middleware.MethodOverrideHeader))
+	})
+	router.GET("/users/:id", func(c *gin.Context) { c.String(http.StatusOK, "get") })
+	return middleware.MethodOverride(router)
+}
+
+func TestMethodOverride_PostAsDelete(t *testing.T) {
+	handler := setupMethodOverrideRouter()
+
+	req, _ := http.NewRequest(http.MethodPost, "/users/5", nil)
+	req.Header.Set(middleware.MethodOverrideHeader, "delete")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "delete 5 ", w.Body.String(), "the override header is consumed")
+}
+
+func TestMethodOverride_IgnoresUnsafeOverrides(t *testing.T) {
+	handler := setupMethodOverrideRouter()
+
+	tests := []struct {
+		name     string
+		method   string
+		override string
+		expected string
+	}{
+		{name: "no header", method: http.MethodPost, override: "", expected: "create"},
+		{name: "override to a safe method", method: http.MethodPost, override: http.MethodGet, expected: "create"},
+		{name: "unknown method", method: http.MethodPost, override: "TRACE", expected: "create"},
+		{name: "non-POST request", method: http.MethodGet, override: http.MethodDelete, expected: "get"},
+		{name: "override to PUT", method: http.MethodPost, override: " put ", expected: "update"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, "/users/5", nil)
+			if tt.override != "" {
+				req.Header.Set(middleware.MethodOverrideHeader, tt.override)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+}
+
+func TestInjectLatency(t *testing.T) {
+	timed := func(d time.Duration) (time.Duration, int) {
+		router := setupTestRouter()
+		router.Use(middleware.InjectLatency(d))
+		router.GET("/test", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+		req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+		w := httptest.NewRecorder()
+		start := time.Now()
+		router.ServeHTTP(w, req)
+		return time.Since(start), w.Code
+	}
+
+	baseline, status := timed(0)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Less(t, baseline, 50*time.Millisecond, "disabled latency is a no-op")
+
+	delay := 100 * time.Millisecond
+	elapsed, status := timed(delay)
+	assert.Equal(t, http.StatusOK, status)
+	assert.GreaterOrEqual(t, elapsed, delay)
+	assert.Less(t, elapsed, delay+baseline+200*time.Millisecond)
+}
+
+func TestInjectLatency_StopsWhenClientGoesAway(t *testing.T) {
+	router := setupTestRouter()
+	router.Use(middleware.InjectLatency(time.Minute))
+	router.GET("/test", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/test", nil)
+	w := httptest.NewRecorder()
+	start := time.Now()
+	router.ServeHTTP(w, req)
+
+	assert.Less(t, time.Since(start), time.Second)
+}
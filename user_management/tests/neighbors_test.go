@@ -0,0 +1,167 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_GetUserNeighbors(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+
+	var users []*models.User
+	for i := 1; i <= 4; i++ {
+		user := &models.User{Name: fmt.Sprintf("User %d", i), Email: fmt.Sprintf("user%d@example.com", i), Age: 30}
+		require.NoError(t, repo.Create(user))
+		users = append(users, user)
+	}
+	// Gaps left by deleted users are skipped
+	require.NoError(t, repo.Delete(users[2].ID))
+
+	t.Run("middle", func(t *testing.T) {
+		neighbors, err := userService.GetUserNeighbors(users[1].ID)
+
+		require.NoError(t, err)
+		require.NotNil(t, neighbors.Previous)
+		require.NotNil(t, neighbors.Next)
+		assert.Equal(t, users[0].ID, neighbors.Previous.ID)
+		assert.Equal(t, users[3].ID, neighbors.Next.ID)
+	})
+
+	t.Run("first", func(t *testing.T) {
+		neighbors, err := userService.GetUserNeighbors(users[0].ID)
+
+		require.NoError(t, err)
+		assert.Nil(t, neighbors.Previous)
+		require.NotNil(t, neighbors.Next)
+		assert.Equal(t, users[1].ID, neighbors.Next.ID)
+	})
+
+	t.Run("last", func(t *testing.T) {
+		neighbors, err := userService.GetUserNeighbors(users[3].ID)
+
+		require.NoError(t, err)
+		require.NotNil(t, neighbors.Previous)
+		assert.Equal(t, users[1].ID, neighbors.Previous.ID)
+		assert.Nil(t, neighbors.Next)
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		_, err := userService.GetUserNeighbors(users[2].ID)
+
+		assert.ErrorIs(t, err, models.ErrUserNotFound)
+	})
+}
+
+func TestUserController_GetUserNeighbors(t *testing.T) {
+	mockService := new(MockUserService)
+	controller := controllers.NewUserController(mockService)
+	router := setupTestRouter()
+	router.GET("/users/:id/neighbors", controller.GetUserNeighbors)
+
+	mockService.On("GetUserNeighbors", uint(1)).Return(&models.UserNeighbors{
+		Next: &models.UserResponse{ID: 2, Name: "Jane Doe"},
+	}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/1/neighbors", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "null", string(response.Data["previous"]), "the first user has no previous neighbor")
+	assert.Contains(t, string(response.Data["next"]), `"id":2`)
+
+	req, _ = http.NewRequest(http.MethodGet, "/users/abc/neighbors", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUserController_GetRandomUser(t *testing.T) {
+	db := setupTestDB(t)
+	controller := controllers.NewUserController(service.NewUserService(repository.NewUserRepository(db), nil))
+	router := setupTestRouter()
+	router.GET("/users/random", controller.GetRandomUser)
+
+	get := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/users/random", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get()
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), models.CodeUserNotFound)
+
+	require.NoError(t, db.Create(&models.User{Name: "John Doe", Email: "john@example.com", Age: 30}).Error)
+
+	w = get()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), `"email":"john@example.com"`)
+}
+
+func TestUserController_GetRecentUsers(t *testing.T) {
+	db := setupTestDB(t)
+	controller := controllers.NewUserController(service.NewUserService(repository.NewUserRepository(db), nil))
+	router := setupTestRouter()
+	router.GET("/users/recent", controller.GetRecentUsers)
+
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 7; i++ {
+		require.NoError(t, db.Create(&models.User{
+			Name: "User", Email: fmt.Sprintf("user%d@example.com", i), Age: 30,
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+		}).Error)
+	}
+
+	get := func(query string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest(http.MethodGet, "/users/recent"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+	emails := func(response map[string]interface{}) []string {
+		var emails []string
+		for _, item := range response["data"].([]interface{}) {
+			emails = append(emails, item.(map[string]interface{})["email"].(string))
+		}
+		return emails
+	}
+
+	status, response := get("")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"user6@example.com", "user5@example.com", "user4@example.com", "user3@example.com", "user2@example.com"}, emails(response), "defaults to the 5 newest")
+
+	status, response = get("?limit=2")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"user6@example.com", "user5@example.com"}, emails(response))
+
+	status, response = get("?limit=1000")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, float64(controllers.MaxPageSize), response["limit"])
+	assert.Len(t, emails(response), 7)
+
+	status, response = get("?limit=five")
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, models.CodeInvalidParameter, response["code"])
+}
@@ -0,0 +1,322 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRepository_GetPage(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+
+	var ids []uint
+	for i := 0; i < 5; i++ {
+		user := &models.User{Name: "User", Email: fmt.Sprintf("user%d@example.com", i), Age: 30}
+		require.NoError(t, repo.Create(user))
+		ids = append(ids, user.ID)
+	}
+
+	pageIDs := func(users []models.User) []uint {
+		got := make([]uint, 0, len(users))
+		for _, user := range users {
+			got = append(got, user.ID)
+		}
+		return got
+	}
+
+	tests := []struct {
+		name     string
+		page     int
+		size     int
+		opts     models.PageOptions
+		expected []uint
+		hasNext  bool
+	}{
+		{name: "full first page", page: 1, size: 2, expected: ids[0:2], hasNext: true},
+		{name: "full middle page", page: 2, size: 2, expected: ids[2:4], hasNext: true},
+		{name: "partial last page", page: 3, size: 2, expected: ids[4:5], hasNext: false},
+		{name: "exactly full last page", page: 1, size: 5, expected: ids, hasNext: false},
+		{name: "past the end", page: 4, size: 2, expected: []uint{}, hasNext: false},
+		{
+			name:     "sorted",
+			page:     1,
+			size:     2,
+			opts:     models.PageOptions{Sort: models.UserSort{Column: "id", Descending: true}},
+			expected: []uint{ids[4], ids[3]},
+			hasNext:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, hasNext, err := repo.GetPage(tt.page, tt.size, tt.opts)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, pageIDs(users))
+			assert.Equal(t, tt.hasNext, hasNext)
+		})
+	}
+}
+
+func TestUserController_GetUsers_WithoutTotal(t *testing.T) {
+	mockService := new(MockUserService)
+	controller := controllers.NewUserController(mockService)
+	router := setupTestRouter()
+	router.GET("/users", controller.GetUsers)
+
+	active := true
+	mockService.On("UsersVersion").Return(models.CollectionVersion{}, nil)
+	mockService.On("GetUsersPage", models.UserFilter{IsActive: &active}, models.UserSort{}, 2, 5).
+		Return(make([]models.UserResponse, 5), true, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users?include_total=false&is_active=true&page=2&page_size=5", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Meta map[string]interface{} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, true, response.Meta["has_next"])
+	assert.Equal(t, true, response.Meta["has_prev"])
+	assert.NotContains(t, response.Meta, "total_count")
+	assert.NotContains(t, response.Meta, "filtered_count")
+	mockService.AssertExpectations(t)
+	mockService.AssertNotCalled(t, "CountUsers")
+	mockService.AssertNotCalled(t, "SearchUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserController_GetUsers_InvalidIncludeTotal(t *testing.T) {
+	mockService := new(MockUserService)
+	controller := controllers.NewUserController(mockService)
+	router := setupTestRouter()
+	router.GET("/users", controller.GetUsers)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users?include_total=sometimes", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), models.CodeInvalidParameter)
+}
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		page     int
+		size     int
+		problems []string
+	}{
+		{name: "defaults", query: "", page: 1, size: controllers.DefaultPageSize},
+		{name: "explicit values", query: "page=3&page_size=25", page: 3, size: 25},
+		{name: "clamps low values", query: "page=-2&page_size=0", page: 1, size: 1},
+		{name: "clamps large page size", query: "page_size=1000", page: 1, size: controllers.MaxPageSize},
+		{name: "non-numeric page", query: "page=abc", problems: []string{`page must be an integer, got "abc"`}},
+		{name: "reports every problem", query: "page=abc&page_size=ten", problems: []string{
+			`page must be an integer, got "abc"`,
+			`page_size must be an integer, got "ten"`,
+		}},
+		{name: "offset and limit", query: "offset=20&limit=10", page: 3, size: 10},
+		{name: "limit alone", query: "limit=25", page: 1, size: 25},
+		{name: "clamps negative offset", query: "offset=-5&limit=5", page: 1, size: 5},
+		{name: "clamps large limit", query: "offset=200&limit=1000", page: 3, size: controllers.MaxPageSize},
+		{name: "unaligned offset", query: "offset=15&limit=10", problems: []string{"offset must be a multiple of limit (10), got 15"}},
+		{name: "non-numeric limit", query: "limit=ten", problems: []string{`limit must be an integer, got "ten"`}},
+		{name: "mixed styles", query: "page=2&limit=10", problems: []string{"use either page/page_size or offset/limit, not both"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request, _ = http.NewRequest(http.MethodGet, "/users?"+tt.query, nil)
+
+			page, size, err := controllers.ParsePagination(c)
+
+			if tt.problems != nil {
+				var pagination *controllers.PaginationError
+				require.ErrorAs(t, err, &pagination)
+				assert.Equal(t, tt.problems, pagination.Problems)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.page, page)
+			assert.Equal(t, tt.size, size)
+		})
+	}
+}
+
+func TestUserController_GetUsers_InvalidPage(t *testing.T) {
+	mockService := new(MockUserService)
+	controller := controllers.NewUserController(mockService)
+	router := setupTestRouter()
+	router.GET("/users", controller.GetUsers)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users?page=abc", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, models.CodeInvalidParameter, response["code"])
+	assert.Equal(t, `Invalid pagination: page must be an integer, got "abc"`, response["error"])
+	assert.Equal(t, []interface{}{`page must be an integer, got "abc"`}, response["details"])
+	mockService.AssertNotCalled(t, "GetAllUsers", mock.Anything, mock.Anything)
+}
+
+func TestUserController_GetUsers_OffsetLimitMatchesPage(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+	controller := controllers.NewUserController(userService)
+	router := setupTestRouter()
+	router.GET("/users", controller.GetUsers)
+
+	for i := 0; i < 25; i++ {
+		_, err := userService.CreateUser(models.UserRequest{Name: fmt.Sprintf("User %d", i), Email: fmt.Sprintf("user%d@example.com", i), Age: 30})
+		require.NoError(t, err)
+	}
+
+	status, byPage := getJSON(t, router, "/users?page=3&page_size=10")
+	require.Equal(t, http.StatusOK, status)
+	status, byOffset := getJSON(t, router, "/users?offset=20&limit=10")
+	require.Equal(t, http.StatusOK, status)
+
+	assert.Len(t, byOffset["data"], 5)
+	assert.Equal(t, byPage["data"], byOffset["data"])
+	assert.Equal(t, byPage["meta"], byOffset["meta"])
+
+	status, response := getJSON(t, router, "/users?page=3&offset=20")
+	assert.Equal(t, http.StatusBadRequest, status, "mixing styles is rejected")
+	assert.Equal(t, models.CodeInvalidParameter, response["code"])
+}
+
+func TestUserController_GetUsers_PastLastPage(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	controller := controllers.NewUserController(service.NewUserService(mockRepo, nil))
+	router := setupTestRouter()
+	router.GET("/users", controller.GetUsers)
+
+	mockRepo.On("CollectionVersion").Return(models.CollectionVersion{}, nil)
+	mockRepo.On("Count").Return(int64(23), nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users?page=50&page_size=10", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data []interface{}          `json:"data"`
+		Meta map[string]interface{} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.NotNil(t, response.Data, "data is an empty list, not null")
+	assert.Empty(t, response.Data)
+	assert.Equal(t, float64(50), response.Meta["current_page"])
+	assert.Equal(t, float64(3), response.Meta["total_pages"])
+	assert.Equal(t, float64(23), response.Meta["total_count"])
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "GetAll", mock.Anything, mock.Anything)
+}
+
+func TestUserRepository_Search_PastLastPage(t *testing.T) {
+	db, mock, queries := setupSQLMock(t)
+	repo := repository.NewUserRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "users"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+
+	users, total, err := repo.Search(models.UserFilter{Search: "john"}, models.UserSort{}, 40, 10)
+
+	require.NoError(t, err)
+	assert.NotNil(t, users)
+	assert.Empty(t, users)
+	assert.Equal(t, int64(4), total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, 1, *queries)
+}
+
+func TestUserController_GetUsers_Cursor(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+	router := setupTestRouter()
+	router.GET("/users", controllers.NewUserController(userService).GetUsers)
+
+	for i := 1; i <= 5; i++ {
+		_, err := userService.CreateUser(models.UserRequest{Name: fmt.Sprintf("User %d", i), Email: fmt.Sprintf("user%d@example.com", i), Age: 30})
+		require.NoError(t, err)
+	}
+
+	names := func(response map[string]interface{}) []string {
+		var names []string
+		for _, item := range response["data"].([]interface{}) {
+			names = append(names, item.(map[string]interface{})["name"].(string))
+		}
+		return names
+	}
+
+	status, first := getJSON(t, router, "/users?cursor=&limit=3")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"User 1", "User 2", "User 3"}, names(first))
+	require.NotNil(t, first["next_cursor"])
+
+	// A user created mid-scroll lands after the cursor instead of shifting
+	// rows already seen onto the next page
+	_, err := userService.CreateUser(models.UserRequest{Name: "User 6", Email: "user6@example.com", Age: 30})
+	require.NoError(t, err)
+
+	status, second := getJSON(t, router, fmt.Sprintf("/users?cursor=%v&limit=3", first["next_cursor"]))
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"User 4", "User 5", "User 6"}, names(second))
+	assert.NotContains(t, second, "next_cursor", "a full last page does not point at an empty one")
+
+	status, empty := getJSON(t, router, "/users?cursor=999&limit=3")
+	require.Equal(t, http.StatusOK, status)
+	assert.Empty(t, empty["data"])
+	assert.NotContains(t, empty, "next_cursor")
+}
+
+func TestUserController_GetUsers_InvalidCursor(t *testing.T) {
+	router := setupTestRouter()
+	router.GET("/users", controllers.NewUserController(new(MockUserService)).GetUsers)
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "not an ID", query: "cursor=abc"},
+		{name: "negative", query: "cursor=-1"},
+		{name: "invalid limit", query: "cursor=3&limit=many"},
+		{name: "mixed with page", query: "cursor=3&page=2"},
+		{name: "mixed with offset", query: "cursor=3&offset=10&limit=10"},
+		{name: "with a filter", query: "cursor=3&name=jo"},
+		{name: "with sorting", query: "cursor=3&sort_by=name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := getJSON(t, router, "/users?"+tt.query)
+
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Equal(t, models.CodeInvalidParameter, response["code"])
+		})
+	}
+}
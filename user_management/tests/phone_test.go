@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		name, phone, region, want string
+	}{
+		{name: "national number in the default region", phone: "081 234 5678", region: "TH", want: "+66812345678"},
+		{name: "lowercase region", phone: "081-234-5678", region: "th", want: "+66812345678"},
+		{name: "explicit country overrides the default", phone: "+1 415 555 2671", region: "TH", want: "+14155552671"},
+		{name: "explicit country without a default", phone: "+66 81 234 5678", want: "+66812345678"},
+		{name: "empty", phone: "", region: "TH", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := models.NormalizePhone(tt.phone, tt.region)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNormalizePhone_Invalid(t *testing.T) {
+	tests := []struct {
+		name, phone, region, message string
+	}{
+		{name: "too short", phone: "12345", region: "TH", message: "must be a valid phone number"},
+		{name: "not a number", phone: "call me", region: "TH", message: "must be a valid phone number"},
+		{name: "national number without a default", phone: "0812345678", message: "must start with a +country code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := models.NormalizePhone(tt.phone, tt.region)
+
+			var validation *models.ValidationError
+			require.ErrorAs(t, err, &validation)
+			assert.Equal(t, tt.message, validation.Fields["phone"])
+		})
+	}
+}
+
+func TestUserService_PhoneRegion(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil, service.WithPhoneRegion("TH"))
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "Somchai", Email: "somchai@example.com", Age: 30, Phone: "081 234 5678"})
+	require.NoError(t, err)
+	assert.Equal(t, "+66812345678", user.Phone)
+
+	stored, err := repo.GetByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "+66812345678", stored.Phone)
+
+	updated, _, err := userService.UpdateUser(user.ID, models.UserRequest{Name: "Somchai", Email: "somchai@example.com", Age: 30, Phone: "+1 (415) 555-2671"})
+	require.NoError(t, err)
+	assert.Equal(t, "+14155552671", updated.Phone)
+
+	_, err = userService.BulkCreateUsers([]models.UserRequest{
+		{Name: "Valid", Email: "valid@example.com", Age: 30, Phone: "0812345678"},
+		{Name: "Invalid", Email: "invalid@example.com", Age: 30, Phone: "12345"},
+	})
+	assert.ErrorContains(t, err, "users[1]: invalid phone number")
+	var validation *models.ValidationError
+	assert.ErrorAs(t, err, &validation)
+}
+
+func TestUserService_NoPhoneRegionKeepsPhones(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30, Phone: "555-000-1111"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "555-000-1111", user.Phone)
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserController_GetUserProfile(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+	controller := controllers.NewUserController(userService)
+
+	router := setupTestRouter()
+	router.GET("/users/:id/profile", controller.GetUserProfile)
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	for _, email := range []string{"john.doe@example.com", "jd@example.com"} {
+		_, _, err := userService.UpdateUser(user.ID, models.UserRequest{Name: "John Doe", Email: email, Age: 30})
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Model(&models.User{}).Where("id = ?", user.ID).
+		UpdateColumn("created_at", time.Now().Add(-72*time.Hour-time.Minute)).Error)
+	require.NoError(t, db.Create(&models.AuditEntry{Action: models.AuditUserDeleted, UserID: user.ID}).Error)
+
+	status, response := getJSON(t, router, fmt.Sprintf("/users/%d/profile", user.ID))
+
+	require.Equal(t, http.StatusOK, status)
+	data := response["data"].(map[string]interface{})
+	profileUser := data["user"].(map[string]interface{})
+	assert.Equal(t, float64(user.ID), profileUser["id"])
+	assert.Equal(t, "jd@example.com", profileUser["email"])
+	assert.Equal(t, map[string]interface{}{
+		"days_since_signup":  float64(3),
+		"email_change_count": float64(2),
+		"audit_event_count":  float64(1),
+	}, data["stats"])
+
+	status, response = getJSON(t, router, "/users/999/profile")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, models.CodeUserNotFound, response["code"])
+
+	status, _ = getJSON(t, router, "/users/abc/profile")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestDaysSince(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 0, models.DaysSince(now.Add(-23*time.Hour), now))
+	assert.Equal(t, 9, models.DaysSince(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), now))
+	assert.Equal(t, 0, models.DaysSince(now.Add(time.Hour), now), "future dates count as today")
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/jobs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPurgeSoftDeleted_RunsUntilCancelled(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	retention := 24 * time.Hour
+
+	purged := make(chan time.Time, 10)
+	mockRepo.On("PurgeSoftDeletedBefore", mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) { purged <- args.Get(0).(time.Time) }).
+		Return(int64(2), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		jobs.PurgeSoftDeleted(ctx, mockRepo, 10*time.Millisecond, retention)
+		close(done)
+	}()
+
+	// Runs immediately, then again on the interval
+	for i := 0; i < 2; i++ {
+		select {
+		case cutoff := <-purged:
+			assert.WithinDuration(t, time.Now().Add(-retention), cutoff, time.Second)
+		case <-time.After(time.Second):
+			t.Fatal("purge did not run")
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("purge job did not stop after cancellation")
+	}
+}
+
+func TestDeleteScheduledAccounts_RunsUntilCancelled(t *testing.T) {
+	mockService := new(MockUserService)
+
+	ran := make(chan time.Time, 10)
+	mockService.On("DeleteDueAccounts", mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) { ran <- args.Get(0).(time.Time) }).
+		Return(int64(1), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		jobs.DeleteScheduledAccounts(ctx, mockService, 10*time.Millisecond)
+		close(done)
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case now := <-ran:
+			assert.WithinDuration(t, time.Now(), now, time.Second)
+		case <-time.After(time.Second):
+			t.Fatal("scheduled deletion did not run")
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("scheduled deletion job did not stop after cancellation")
+	}
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/cache"
+	"github.com/IntouchOpec/user_management/config"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRedisClient(t *testing.T) {
+	base := config.RedisConfig{Host: "redis", Port: "6379", MasterName: "users"}
+
+	t.Run("single", func(t *testing.T) {
+		cfg := base
+		cfg.Mode = config.RedisModeSingle
+
+		client, ok := cache.NewRedisClient(cfg).(*redis.Client)
+		require.True(t, ok)
+		defer client.Close()
+		assert.Equal(t, "redis:6379", client.Options().Addr)
+	})
+
+	t.Run("unset mode is single", func(t *testing.T) {
+		client, ok := cache.NewRedisClient(base).(*redis.Client)
+		require.True(t, ok)
+		defer client.Close()
+		assert.Equal(t, "redis:6379", client.Options().Addr)
+	})
+
+	t.Run("sentinel", func(t *testing.T) {
+		cfg := base
+		cfg.Mode = config.RedisModeSentinel
+		cfg.Addrs = []string{"sentinel-1:26379", "sentinel-2:26379"}
+
+		client, ok := cache.NewRedisClient(cfg).(*redis.Client)
+		require.True(t, ok)
+		defer client.Close()
+		// Failover clients resolve the master through the sentinels rather
+		// than dialing a fixed address
+		assert.Equal(t, "FailoverClient", client.Options().Addr)
+	})
+
+	t.Run("cluster", func(t *testing.T) {
+		cfg := base
+		cfg.Mode = config.RedisModeCluster
+		cfg.Addrs = []string{"node-1:6379", "node-2:6379", "node-3:6379"}
+
+		client, ok := cache.NewRedisClient(cfg).(*redis.ClusterClient)
+		require.True(t, ok)
+		defer client.Close()
+		assert.Equal(t, cfg.Addrs, client.Options().Addrs)
+	})
+
+	t.Run("cluster without addresses uses host and port", func(t *testing.T) {
+		cfg := base
+		cfg.Mode = config.RedisModeCluster
+
+		client, ok := cache.NewRedisClient(cfg).(*redis.ClusterClient)
+		require.True(t, ok)
+		defer client.Close()
+		assert.Equal(t, []string{"redis:6379"}, client.Options().Addrs)
+	})
+}
+
+// crossSlotHook fails multi-key DEL and MGET the way Redis Cluster does
+// when their keys hash to different slots
+type crossSlotHook struct{}
+
+func (crossSlotHook) check(cmd redis.Cmder) error {
+	name := strings.ToLower(cmd.Name())
+	if (name == "del" || name == "mget") && len(cmd.Args()) > 2 {
+		return errors.New("CROSSSLOT Keys in request don't hash to the same slot")
+	}
+	return nil
+}
+
+func (h crossSlotHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, h.check(cmd)
+}
+
+func (crossSlotHook) AfterProcess(context.Context, redis.Cmder) error { return nil }
+
+func (h crossSlotHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	for _, cmd := range cmds {
+		if err := h.check(cmd); err != nil {
+			return ctx, err
+		}
+	}
+	return ctx, nil
+}
+
+func (crossSlotHook) AfterProcessPipeline(context.Context, []redis.Cmder) error { return nil }
+
+func TestRedis_ClusterKeysAcrossSlots(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{mr.Addr()}})
+	t.Cleanup(func() { client.Close() })
+	client.AddHook(crossSlotHook{})
+	redisCache := cache.NewRedis(client)
+	ctx := context.Background()
+
+	keys := []string{"user:1", "stale:user:1", "user:email:john@example.com"}
+	for _, key := range keys[:2] {
+		mr.Set(key, "cached")
+	}
+
+	values, err := redisCache.GetMany(ctx, keys...)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("cached"), []byte("cached"), nil}, values)
+
+	require.NoError(t, redisCache.Del(ctx, keys...))
+	for _, key := range keys {
+		assert.False(t, mr.Exists(key), key)
+	}
+}
@@ -1,8 +1,10 @@
 package tests
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/IntouchOpec/user_management/models"
 	"github.com/IntouchOpec/user_management/repository"
@@ -272,6 +274,14 @@ type MockUserRepositoryTest struct {
 	mock.Mock
 }
 
+func (m *MockUserRepositoryTest) WithContext(ctx context.Context) repository.UserRepository {
+	return m
+}
+
+func (m *MockUserRepositoryTest) WithTx(tx *gorm.DB) repository.UserRepository {
+	return m
+}
+
 func (m *MockUserRepositoryTest) Create(user *models.User) error {
 	args := m.Called(user)
 	return args.Error(0)
@@ -293,11 +303,167 @@ func (m *MockUserRepositoryTest) GetByEmail(email string) (*models.User, error)
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepositoryTest) GetByIDs(ids []uint) ([]models.User, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) GetByEmails(emails []string) ([]models.User, error) {
+	args := m.Called(emails)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) PurgeSoftDeletedBefore(t time.Time) (int64, error) {
+	args := m.Called(t)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) GetPage(page, size int, opts models.PageOptions) ([]models.User, bool, error) {
+	args := m.Called(page, size, opts)
+	return args.Get(0).([]models.User), args.Bool(1), args.Error(2)
+}
+
+func (m *MockUserRepositoryTest) FindInBatches(filter models.UserFilter, batchSize int, fn func([]models.User) error) error {
+	args := m.Called(filter, batchSize, fn)
+	return args.Error(0)
+}
+
+func (m *MockUserRepositoryTest) GetEmailHistory(userID uint) ([]models.UserEmailHistory, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.UserEmailHistory), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) CountAuditEntries(userID uint) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) GetAuditEntries(userID uint) ([]models.AuditEntry, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AuditEntry), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) DeleteScheduledBefore(t time.Time) ([]models.User, error) {
+	args := m.Called(t)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) CountCreatedByDay(from, to time.Time) (map[string]int64, error) {
+	args := m.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) CreateAuditEntries(entries []models.AuditEntry) error {
+	args := m.Called(entries)
+	return args.Error(0)
+}
+
 func (m *MockUserRepositoryTest) GetAll(offset, limit int) ([]models.User, error) {
 	args := m.Called(offset, limit)
 	return args.Get(0).([]models.User), args.Error(1)
 }
 
+func (m *MockUserRepositoryTest) SetRoleByFilter(filter models.UserFilter, role string, updatedBy *uint, maxAffected int) ([]uint, error) {
+	args := m.Called(filter, role, updatedBy, maxAffected)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) GetAllCursor(afterID uint, limit int) ([]models.User, error) {
+	args := m.Called(afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) GetByIDIncludingDeleted(id uint) (*models.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) Restore(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepositoryTest) SetActiveByFilter(filter models.UserFilter, active bool, updatedBy *uint) ([]uint, error) {
+	args := m.Called(filter, active, updatedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) FindSimilar(user *models.User, limit int) ([]models.User, error) {
+	args := m.Called(user, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) GetRandom() (*models.User, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) GetRecent(limit int) ([]models.User, error) {
+	args := m.Called(limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) CountFiltered(filter models.UserFilter) (int64, error) {
+	args := m.Called(filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) FindNeighbors(id uint) (*models.User, *models.User, error) {
+	args := m.Called(id)
+	prev, _ := args.Get(0).(*models.User)
+	next, _ := args.Get(1).(*models.User)
+	return prev, next, args.Error(2)
+}
+
+func (m *MockUserRepositoryTest) CollectionVersion() (models.CollectionVersion, error) {
+	args := m.Called()
+	return args.Get(0).(models.CollectionVersion), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) Search(filter models.UserFilter, sort models.UserSort, offset, limit int) ([]models.User, int64, error) {
+	args := m.Called(filter, sort, offset, limit)
+	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
+}
+
 func (m *MockUserRepositoryTest) Update(user *models.User) error {
 	args := m.Called(user)
 	return args.Error(0)
@@ -308,7 +474,38 @@ func (m *MockUserRepositoryTest) Delete(id uint) error {
 	return args.Error(0)
 }
 
+func (m *MockUserRepositoryTest) DeleteWithDependents(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
 func (m *MockUserRepositoryTest) Count() (int64, error) {
 	args := m.Called()
 	return args.Get(0).(int64), args.Error(1)
 }
+
+func (m *MockUserRepositoryTest) CreateBatch(users []*models.User) error {
+	args := m.Called(users)
+	return args.Error(0)
+}
+
+func (m *MockUserRepositoryTest) UpdateBatch(users []*models.User) error {
+	args := m.Called(users)
+	return args.Error(0)
+}
+
+func (m *MockUserRepositoryTest) DeleteByIDs(ids []uint, deletedBy *uint) ([]uint, error) {
+	args := m.Called(ids, deletedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockUserRepositoryTest) DeleteWhere(batchSize int, query interface{}, conds ...interface{}) ([]uint, error) {
+	args := m.Called(batchSize, query, conds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserController_EnforcesFieldRules(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	controller := controllers.NewUserController(service.NewUserService(repo, nil))
+	router := setupTestRouter()
+	router.POST("/users", controller.CreateUser)
+	router.PUT("/users/:id", controller.UpdateUser)
+
+	send := func(method, path, body string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	status, response := send(http.MethodPost, "/users", `{"name": "Jane Doe", "email": "jane@example.com", "age": 30}`)
+	require.Equal(t, http.StatusCreated, status, response)
+	path := fmt.Sprintf("/users/%v", response["data"].(map[string]interface{})["id"])
+
+	tests := []struct {
+		name   string
+		body   string
+		fields map[string]interface{}
+	}{
+		{
+			name:   "age over 150",
+			body:   `{"name": "John Doe", "email": "john@example.com", "age": 151}`,
+			fields: map[string]interface{}{"age": "must be at most 150"},
+		},
+		{
+			name:   "name under 2 characters",
+			body:   `{"name": "x", "email": "john@example.com", "age": 30}`,
+			fields: map[string]interface{}{"name": "must be at least 2 characters"},
+		},
+		{
+			name:   "malformed email",
+			body:   `{"name": "John Doe", "email": "john-at-example", "age": 30}`,
+			fields: map[string]interface{}{"email": "must be a valid email"},
+		},
+		{
+			name: "every failing field",
+			body: `{"name": "x", "age": 999}`,
+			fields: map[string]interface{}{
+				"name":  "must be at least 2 characters",
+				"email": "is required",
+				"age":   "must be at most 150",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for method, target := range map[string]string{http.MethodPost: "/users", http.MethodPut: path} {
+				status, response := send(method, target, tt.body)
+
+				assert.Equal(t, http.StatusBadRequest, status, method)
+				assert.Equal(t, models.CodeValidationFailed, response["code"], method)
+				assert.True(t, strings.HasPrefix(response["error"].(string), "validation failed"), method)
+				assert.Equal(t, tt.fields, response["fields"], method)
+			}
+		})
+	}
+
+	// Nothing invalid was stored or written over the existing user
+	count, err := repo.Count()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	stored, err := repo.GetByEmail("jane@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", stored.Name)
+	assert.Equal(t, 30, stored.Age)
+}
+
+func TestUserService_BulkCreateUsers_EnforcesFieldRules(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+
+	_, err := userService.BulkCreateUsers([]models.UserRequest{
+		{Name: "Valid", Email: "valid@example.com", Age: 30},
+		{Name: "Too Old", Email: "old@example.com", Age: 200},
+	})
+
+	var validation *models.ValidationError
+	require.ErrorAs(t, err, &validation)
+	assert.Equal(t, map[string]string{"age": "must be at most 150"}, validation.Fields)
+	assert.ErrorContains(t, err, "users[1]: validation failed")
+	count, err := repo.Count()
+	require.NoError(t, err)
+	assert.Zero(t, count, "the batch is rejected as a whole")
+}
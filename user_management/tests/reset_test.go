@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/middleware"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetRequest(t *testing.T, controller *controllers.UserController, id uint, authorization string) (int, map[string]interface{}) {
+	t.Helper()
+
+	router := setupTestRouter()
+	router.POST("/users/:id/reset", middleware.RequireAdmin(testJWTSecret), controller.ResetUser)
+
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/users/%d/reset", id), nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+func TestUserController_ResetUser(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+	controller := controllers.NewUserController(userService)
+
+	user, err := userService.CreateUser(models.UserRequest{
+		Name: "John Doe", Email: "john@example.com", Age: 30,
+		Phone: "+14155550100", Address: "1 Main St",
+	})
+	require.NoError(t, err)
+	_, err = userService.SetUserStatus(user.ID, models.StatusBanned)
+	require.NoError(t, err)
+
+	admin := "Bearer " + signTestToken(t, "1", "admin", time.Hour)
+
+	t.Run("clears optional fields and reactivates", func(t *testing.T) {
+		status, response := resetRequest(t, controller, user.ID, admin)
+
+		assert.Equal(t, http.StatusOK, status)
+		data := response["data"].(map[string]interface{})
+		assert.Empty(t, data["phone"])
+		assert.Empty(t, data["address"])
+		assert.Equal(t, "active", data["status"])
+
+		stored, err := repo.GetByID(user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, stored.Phone)
+		assert.Empty(t, stored.Address)
+		assert.Equal(t, models.StatusActive, stored.Status)
+		assert.True(t, stored.IsActive)
+		assert.Equal(t, "John Doe", stored.Name, "identity fields are kept")
+		assert.Equal(t, "john@example.com", stored.Email)
+		assert.Equal(t, 30, stored.Age)
+		require.NotNil(t, stored.UpdatedBy)
+		assert.Equal(t, uint(1), *stored.UpdatedBy, "the admin is recorded as the actor")
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		status, _ := resetRequest(t, controller, 999, admin)
+
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+}
+
+func TestUserController_ResetUser_RequiresAdmin(t *testing.T) {
+	userService := new(MockUserService)
+	controller := controllers.NewUserController(userService)
+
+	status, response := resetRequest(t, controller, 1, "")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, models.CodeUnauthorized, response["code"])
+
+	status, response = resetRequest(t, controller, 1, "Bearer "+signTestToken(t, "42", "admin", -time.Minute))
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, models.CodeUnauthorized, response["code"])
+
+	status, response = resetRequest(t, controller, 1, "Bearer "+signTestToken(t, "42", "user", time.Hour))
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, models.CodeForbidden, response["code"])
+
+	userService.AssertNotCalled(t, "ResetUser", uint(1))
+}
+
+func TestUserController_ResetUser_ImmutableFields(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil, service.WithImmutableFields([]string{"id", "created_at", "phone"}))
+	controller := controllers.NewUserController(userService)
+	admin := "Bearer " + signTestToken(t, "1", "admin", time.Hour)
+
+	withPhone, err := userService.CreateUser(models.UserRequest{
+		Name: "John Doe", Email: "john@example.com", Age: 30,
+		Phone: "+14155550100", Address: "1 Main St",
+	})
+	require.NoError(t, err)
+	withoutPhone, err := userService.CreateUser(models.UserRequest{
+		Name: "Jane Doe", Email: "jane@example.com", Age: 30, Address: "2 Main St",
+	})
+	require.NoError(t, err)
+
+	t.Run("clearing an immutable phone", func(t *testing.T) {
+		status, response := resetRequest(t, controller, withPhone.ID, admin)
+
+		assert.Equal(t, http.StatusBadRequest, status, "admins are bound by it too")
+		assert.Equal(t, models.CodeFieldImmutable, response["code"])
+		assert.Equal(t, "phone", response["field"])
+		stored, err := repo.GetByID(withPhone.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "+14155550100", stored.Phone)
+		assert.Equal(t, "1 Main St", stored.Address, "nothing is written")
+	})
+
+	t.Run("no phone to clear", func(t *testing.T) {
+		status, _ := resetRequest(t, controller, withoutPhone.ID, admin)
+
+		assert.Equal(t, http.StatusOK, status)
+		stored, err := repo.GetByID(withoutPhone.ID)
+		require.NoError(t, err)
+		assert.Empty(t, stored.Address)
+	})
+}
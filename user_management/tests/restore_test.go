@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func restoreRequest(t *testing.T, controller *controllers.UserController, id uint) (int, map[string]interface{}) {
+	t.Helper()
+
+	router := setupTestRouter()
+	router.POST("/users/:id/restore", controller.RestoreUser)
+
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/users/%d/restore", id), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+func TestUserController_RestoreUser(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+	controller := controllers.NewUserController(userService)
+
+	deleted, err := userService.CreateUser(models.UserRequest{Name: "Deleted", Email: "deleted@example.com", Age: 30})
+	require.NoError(t, err)
+	require.NoError(t, userService.DeleteUser(deleted.ID))
+	active, err := userService.CreateUser(models.UserRequest{Name: "Active", Email: "active@example.com", Age: 30})
+	require.NoError(t, err)
+
+	t.Run("deleted user", func(t *testing.T) {
+		status, response := restoreRequest(t, controller, deleted.ID)
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "deleted@example.com", response["data"].(map[string]interface{})["email"])
+
+		stored, err := repo.GetByID(deleted.ID)
+		require.NoError(t, err, "the user is visible again")
+		assert.Equal(t, "Deleted", stored.Name)
+	})
+
+	t.Run("user that is not deleted", func(t *testing.T) {
+		status, response := restoreRequest(t, controller, active.ID)
+
+		assert.Equal(t, http.StatusConflict, status)
+		assert.Equal(t, models.CodeUserNotDeleted, response["code"])
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		status, response := restoreRequest(t, controller, 999)
+
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Equal(t, models.CodeUserNotFound, response["code"])
+	})
+
+	t.Run("email taken since the delete", func(t *testing.T) {
+		gone, err := userService.CreateUser(models.UserRequest{Name: "Gone", Email: "reused@example.com", Age: 30})
+		require.NoError(t, err)
+		require.NoError(t, userService.DeleteUser(gone.ID))
+		_, err = userService.CreateUser(models.UserRequest{Name: "New Owner", Email: "Reused@example.com", Age: 30})
+		require.NoError(t, err)
+
+		status, response := restoreRequest(t, controller, gone.ID)
+
+		assert.Equal(t, http.StatusConflict, status)
+		assert.Equal(t, models.CodeEmailExists, response["code"])
+		_, err = repo.GetByID(gone.ID)
+		assert.ErrorIs(t, err, models.ErrUserNotFound, "the user stays deleted")
+	})
+}
+
+func TestUserRepository_GetByIDIncludingDeleted(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+
+	user := &models.User{Name: "Deleted", Email: "deleted@example.com", Age: 30}
+	require.NoError(t, repo.Create(user))
+	require.NoError(t, repo.Delete(user.ID))
+
+	_, err := repo.GetByID(user.ID)
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+
+	found, err := repo.GetByIDIncludingDeleted(user.ID)
+	require.NoError(t, err)
+	assert.True(t, found.DeletedAt.Valid)
+
+	_, err = repo.GetByIDIncludingDeleted(999)
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+}
@@ -1,9 +1,14 @@
 package tests
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/IntouchOpec/user_management/config"
 	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
 	"github.com/IntouchOpec/user_management/routes"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -18,7 +23,7 @@ func TestSetupRoutes(t *testing.T) {
 	userController := controllers.NewUserController(mockService)
 
 	// Execute
-	routes.SetupRoutes(router, userController)
+	routes.SetupRoutes(router, userController, nil, config.AuthConfig{})
 
 	// Get the registered routes
 	routesList := router.Routes()
@@ -48,6 +53,20 @@ func TestSetupRoutes(t *testing.T) {
 	assert.Contains(t, routeMap["PUT"], "/api/v1/users/:id")
 	assert.Contains(t, routeMap["DELETE"], "/api/v1/users/:id")
 
+	// Check bulk endpoints
+	assert.Contains(t, routeMap["POST"], "/api/v1/users/bulk")
+	assert.Contains(t, routeMap["PUT"], "/api/v1/users/bulk")
+	assert.Contains(t, routeMap["POST"], "/api/v1/users/bulk-set-active")
+	assert.Contains(t, routeMap["POST"], "/api/v1/users/bulk-role")
+	assert.Contains(t, routeMap["POST"], "/api/v1/users/:id/restore")
+	assert.Contains(t, routeMap["GET"], "/api/v1/users/by-email")
+	assert.Contains(t, routeMap["GET"], "/api/v1/users/schema")
+	assert.Contains(t, routeMap["POST"], "/api/v1/users/import")
+	assert.Contains(t, routeMap["DELETE"], "/api/v1/users")
+	assert.Contains(t, routeMap["DELETE"], "/api/v1/users/inactive")
+	assert.Contains(t, routeMap["GET"], "/api/v1/users/:id/similar")
+	assert.Contains(t, routeMap["GET"], "/api/v1/users/:id/email-history")
+
 	// Verify expected routes exist
 	for _, route := range expectedGetRoutes {
 		assert.Contains(t, routeMap["GET"], route)
@@ -74,7 +93,7 @@ func TestSetupRoutes_Integration(t *testing.T) {
 	userController := controllers.NewUserController(mockService)
 
 	// Execute
-	routes.SetupRoutes(router, userController)
+	routes.SetupRoutes(router, userController, nil, config.AuthConfig{})
 
 	// Verify router is not nil and has routes
 	assert.NotNil(t, router)
@@ -103,7 +122,7 @@ func TestSetupRoutes_APIVersioning(t *testing.T) {
 	userController := controllers.NewUserController(mockService)
 
 	// Execute
-	routes.SetupRoutes(router, userController)
+	routes.SetupRoutes(router, userController, nil, config.AuthConfig{})
 
 	// Get routes and verify API versioning
 	routesList := router.Routes()
@@ -111,7 +130,7 @@ func TestSetupRoutes_APIVersioning(t *testing.T) {
 	// Check that all user routes are under /api/v1
 	userRoutes := []string{}
 	for _, route := range routesList {
-		if route.Path != "/health" && route.Path != "/swagger/*any" {
+		if route.Path != "/" && route.Path != "/health" && route.Path != "/swagger/*any" {
 			userRoutes = append(userRoutes, route.Path)
 		}
 	}
@@ -122,6 +141,23 @@ func TestSetupRoutes_APIVersioning(t *testing.T) {
 	}
 }
 
+func TestSetupRoutes_AcceptVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.SetupRoutes(router, controllers.NewUserController(new(MockUserService)), nil, config.AuthConfig{})
+
+	for header, code := range map[string]string{"v1": models.CodeInvalidParameter, "v2": models.CodeUnsupportedVersion} {
+		req, _ := http.NewRequest(http.MethodGet, "/api/v1/users/compare", nil)
+		req.Header.Set("Accept-Version", header)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Both are 400s, but only v1 reaches the handler
+		assert.Equal(t, http.StatusBadRequest, w.Code, header)
+		assert.Contains(t, w.Body.String(), code, header)
+	}
+}
+
 func TestSetupRoutes_ControllerBinding(t *testing.T) {
 	// Setup
 	gin.SetMode(gin.TestMode)
@@ -131,7 +167,7 @@ func TestSetupRoutes_ControllerBinding(t *testing.T) {
 
 	// Execute - this should not panic
 	assert.NotPanics(t, func() {
-		routes.SetupRoutes(router, userController)
+		routes.SetupRoutes(router, userController, nil, config.AuthConfig{})
 	})
 
 	// Verify routes are bound to handlers
@@ -153,7 +189,7 @@ func TestSetupRoutes_GroupStructure(t *testing.T) {
 	userController := controllers.NewUserController(mockService)
 
 	// Execute
-	routes.SetupRoutes(router, userController)
+	routes.SetupRoutes(router, userController, nil, config.AuthConfig{})
 
 	// Get routes and analyze structure
 	routesList := router.Routes()
@@ -209,3 +245,61 @@ func (m *MockUserServiceForRoutes) DeleteUser(id uint) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
+
+func TestSetupRoutes_FeatureFlags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registered := func(features config.Features) map[string]bool {
+		router := gin.New()
+		routes.SetupRoutes(router, controllers.NewUserController(new(MockUserService)), features, config.AuthConfig{})
+
+		paths := make(map[string]bool)
+		for _, route := range router.Routes() {
+			paths[route.Method+" "+route.Path] = true
+		}
+		return paths
+	}
+
+	defaults := registered(nil)
+	assert.True(t, defaults["GET /api/v1/users/:id/similar"])
+	assert.True(t, defaults["POST /api/v1/users/import"])
+	assert.True(t, defaults["GET /swagger/*any"])
+	assert.True(t, defaults["GET /api/v1/users/export"])
+
+	disabled := registered(config.Features{"similar": false, "import": false, "swagger": false, "export": false})
+	assert.False(t, disabled["GET /api/v1/users/:id/similar"])
+	assert.False(t, disabled["POST /api/v1/users/import"])
+	assert.False(t, disabled["GET /swagger/*any"])
+	assert.False(t, disabled["GET /api/v1/users/export"])
+	assert.True(t, disabled["GET /api/v1/users/:id/export"], "a user's own data export is not gated")
+	assert.True(t, disabled["GET /api/v1/users/:id"], "core routes are never gated")
+}
+
+func TestSetupRoutes_Root(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(controller *controllers.UserController, features config.Features) map[string]interface{} {
+		router := gin.New()
+		routes.SetupRoutes(router, controller, features, config.AuthConfig{})
+
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var body map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	body := serve(controllers.NewUserController(new(MockUserService)), nil)
+	assert.Equal(t, "user-management", body["service"])
+	assert.Equal(t, "1.0", body["version"])
+	assert.Equal(t, "/swagger/index.html", body["docs"])
+
+	body = serve(controllers.NewUserController(new(MockUserService), controllers.WithServiceInfo("accounts", "2.3.1")),
+		config.Features{config.FeatureSwagger: false})
+	assert.Equal(t, "accounts", body["service"])
+	assert.Equal(t, "2.3.1", body["version"])
+	assert.NotContains(t, body, "docs", "no docs link when swagger is disabled")
+}
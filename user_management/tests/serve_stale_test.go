@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/cache"
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupOutage caches a user through a real service, lets the regular cache
+// entry expire and then takes the database down
+func setupOutage(t *testing.T, serveStale bool) (*gin.Engine, *models.UserResponse) {
+	t.Helper()
+
+	db := setupTestDB(t)
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	userService := service.NewUserService(repository.NewUserRepository(db), cache.NewRedis(client), service.WithServeStale(serveStale))
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "Cached User", Email: "cached@example.com", Age: 30})
+	require.NoError(t, err)
+
+	mr.FastForward(time.Hour)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	controller := controllers.NewUserController(userService)
+	router := setupTestRouter()
+	router.GET("/users/:id", controller.GetUser)
+	router.POST("/users", controller.CreateUser)
+	return router, user
+}
+
+func TestServeStale_CachedUserDuringOutage(t *testing.T) {
+	router, user := setupOutage(t, true)
+
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/users/%d", user.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `110 - "Response is Stale"`, w.Header().Get("Warning"))
+
+	var response struct {
+		Data  models.UserResponse `json:"data"`
+		Stale bool                `json:"stale"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Stale)
+	assert.Equal(t, user.ID, response.Data.ID)
+	assert.Equal(t, "cached@example.com", response.Data.Email)
+}
+
+func TestServeStale_UncachedUserDuringOutage(t *testing.T) {
+	router, user := setupOutage(t, true)
+
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/users/%d", user.ID+1), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), models.CodeDBUnavailable)
+}
+
+func TestServeStale_Disabled(t *testing.T) {
+	router, user := setupOutage(t, false)
+
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/users/%d", user.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Empty(t, w.Header().Get("Warning"))
+}
+
+func TestServeStale_WritesFailDuringOutage(t *testing.T) {
+	router, _ := setupOutage(t, true)
+
+	body, _ := json.Marshal(models.UserRequest{Name: "New User", Email: "new@example.com", Age: 30})
+	req, _ := http.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, models.CodeDBUnavailable, response["code"])
+}
@@ -3,12 +3,16 @@ package tests
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/IntouchOpec/user_management/cache"
 	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/reqctx"
 	"github.com/IntouchOpec/user_management/service"
+	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -67,9 +71,9 @@ func (m *MockRedisClient) Ping(ctx context.Context) *redis.StatusCmd {
 func TestUserService_CacheEdgeCases(t *testing.T) {
 	mockRepo := &MockUserRepository{}
 	// mockRedis := &MockRedisClient{}
-	realRedis := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	_, realRedis := newMiniRedis(t)
 
-	userService := service.NewUserService(mockRepo, realRedis)
+	userService := service.NewUserService(mockRepo, cache.NewRedis(realRedis))
 	ctx := context.Background()
 
 	t.Run("Cache set failure should not affect operation", func(t *testing.T) {
@@ -159,3 +163,213 @@ func TestCacheKeyGeneration(t *testing.T) {
 		})
 	}
 }
+
+// newMiniRedis starts an in-process Redis server and returns a client for it
+func newMiniRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
+
+func TestUserService_CacheTTLOverride(t *testing.T) {
+	user := &models.User{ID: 1, Name: "John", Email: "john@example.com", Age: 25, IsActive: true}
+
+	t.Run("default TTL without override", func(t *testing.T) {
+		mr, client := newMiniRedis(t)
+		mockRepo := &MockUserRepository{}
+		mockRepo.On("GetByID", uint(1)).Return(user, nil)
+
+		userService := service.NewUserService(mockRepo, cache.NewRedis(client))
+		_, err := userService.GetUserByID(1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 15*time.Minute, mr.TTL("user:1"))
+	})
+
+	t.Run("configured TTL", func(t *testing.T) {
+		mr, client := newMiniRedis(t)
+		mockRepo := &MockUserRepository{}
+		mockRepo.On("GetByID", uint(1)).Return(user, nil)
+
+		userService := service.NewUserService(mockRepo, cache.NewRedis(client), service.WithCacheTTL(2*time.Second))
+		_, err := userService.GetUserByID(1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Second, mr.TTL("user:1"))
+	})
+
+	t.Run("request TTL override is passed to Set", func(t *testing.T) {
+		mr, client := newMiniRedis(t)
+		mockRepo := &MockUserRepository{}
+		mockRepo.On("GetByID", uint(1)).Return(user, nil)
+
+		ctx := reqctx.WithCacheTTL(context.Background(), 90*time.Second)
+		userService := service.NewUserService(mockRepo, cache.NewRedis(client)).WithContext(ctx)
+		_, err := userService.GetUserByID(1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 90*time.Second, mr.TTL("user:1"))
+	})
+}
+
+func TestUserService_CacheKeyIsTenantScoped(t *testing.T) {
+	mr, client := newMiniRedis(t)
+	user := &models.User{ID: 1, Name: "John", Email: "john@example.com", Age: 25, IsActive: true, TenantID: "acme"}
+	mockRepo := &MockUserRepository{}
+	mockRepo.On("GetByID", uint(1)).Return(user, nil).Once()
+	mockRepo.On("GetByID", uint(1)).Return(nil, errors.New("user not found")).Once()
+
+	svc := service.NewUserService(mockRepo, cache.NewRedis(client))
+	_, err := svc.WithContext(reqctx.WithTenantID(context.Background(), "acme")).GetUserByID(1)
+	assert.NoError(t, err)
+	assert.True(t, mr.Exists("user:acme:1"))
+	assert.False(t, mr.Exists("user:1"))
+
+	_, err = svc.WithContext(reqctx.WithTenantID(context.Background(), "globex")).GetUserByID(1)
+	assert.EqualError(t, err, "user not found", "another tenant must not be served the cached entry")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_CountUsersIsCached(t *testing.T) {
+	mr, client := newMiniRedis(t)
+	mockRepo := &MockUserRepository{}
+	mockRepo.On("Count").Return(int64(7), nil).Once()
+
+	userService := service.NewUserService(mockRepo, cache.NewRedis(client))
+
+	for i := 0; i < 2; i++ {
+		total, err := userService.CountUsers()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), total)
+	}
+	assert.True(t, mr.Exists("users:count"))
+	mockRepo.AssertExpectations(t)
+
+	mockRepo.On("Delete", uint(3)).Return(nil)
+	assert.NoError(t, userService.DeleteUser(3))
+	assert.False(t, mr.Exists("users:count"), "deleting a user must invalidate the cached count")
+}
+
+// roundTripCounter is a go-redis hook counting single commands and
+// pipelines sent to the server
+type roundTripCounter struct {
+	commands  []string
+	pipelines [][]string
+}
+
+func (h *roundTripCounter) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	h.commands = append(h.commands, cmd.Name())
+	return ctx, nil
+}
+
+func (h *roundTripCounter) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h *roundTripCounter) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	var names []string
+	for _, cmd := range cmds {
+		names = append(names, cmd.Name())
+	}
+	h.pipelines = append(h.pipelines, names)
+	return ctx, nil
+}
+
+func (h *roundTripCounter) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func TestUserService_PreloadCache(t *testing.T) {
+	mr, client := newMiniRedis(t)
+	counter := &roundTripCounter{}
+	client.AddHook(counter)
+
+	cached, _ := json.Marshal(&models.User{ID: 2, Name: "Cached", Email: "cached@example.com", Age: 40})
+	mr.Set("user:2", string(cached))
+
+	mockRepo := &MockUserRepository{}
+	mockRepo.On("GetByIDs", []uint{1, 3, 4}).Return([]models.User{
+		{ID: 1, Name: "John", Email: "john@example.com", Age: 25},
+		{ID: 3, Name: "Jane", Email: "jane@example.com", Age: 30},
+	}, nil).Once()
+
+	userService := service.NewUserService(mockRepo, cache.NewRedis(client))
+	err := userService.PreloadCache([]uint{1, 2, 3, 1, 4})
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	assert.Equal(t, []string{"mget"}, counter.commands, "cached IDs are found in one lookup")
+	assert.Equal(t, [][]string{{"set", "set", "set", "set"}}, counter.pipelines, "uncached users are written in one pipeline")
+
+	for _, key := range []string{"user:1", "user:3", "user:email:john@example.com", "user:email:jane@example.com"} {
+		assert.True(t, mr.Exists(key), key)
+		assert.Equal(t, 15*time.Minute, mr.TTL(key), key)
+	}
+	assert.False(t, mr.Exists("user:4"), "unknown IDs are not cached")
+	stored, _ := mr.Get("user:2")
+	assert.Equal(t, string(cached), stored, "already cached users are left alone")
+}
+
+func TestUserService_PreloadCache_AllCached(t *testing.T) {
+	mr, client := newMiniRedis(t)
+	mr.Set("user:1", `{"id":1}`)
+
+	mockRepo := &MockUserRepository{}
+	userService := service.NewUserService(mockRepo, cache.NewRedis(client))
+
+	assert.NoError(t, userService.PreloadCache([]uint{1}))
+	mockRepo.AssertNotCalled(t, "GetByIDs", mock.Anything)
+}
+
+func TestUserService_PreloadCache_WithoutRedis(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	userService := service.NewUserService(mockRepo, nil)
+
+	assert.NoError(t, userService.PreloadCache([]uint{1, 2}))
+	mockRepo.AssertNotCalled(t, "GetByIDs", mock.Anything)
+}
+
+func TestUserService_WarmCache(t *testing.T) {
+	mr, client := newMiniRedis(t)
+	counter := &roundTripCounter{}
+	client.AddHook(counter)
+
+	mockRepo := &MockUserRepository{}
+	mockRepo.On("GetRecent", 2).Return([]models.User{
+		{ID: 3, Name: "Jane", Email: "jane@example.com", Age: 30},
+		{ID: 2, Name: "John", Email: "john@example.com", Age: 25},
+	}, nil).Once()
+
+	userService := service.NewUserService(mockRepo, cache.NewRedis(client))
+
+	assert.NoError(t, userService.WarmCache(2))
+	mockRepo.AssertExpectations(t)
+	assert.Equal(t, [][]string{{"set", "set", "set", "set"}}, counter.pipelines)
+	assert.True(t, mr.Exists("user:2"))
+	assert.True(t, mr.Exists("user:3"))
+}
+
+func TestUserService_WarmCache_WithoutRedis(t *testing.T) {
+	userService := service.NewUserService(&MockUserRepository{}, nil)
+
+	assert.NoError(t, userService.WarmCache(100), "the repository is not touched without Redis")
+}
+
+func TestUserService_GetRecentUsers_Cached(t *testing.T) {
+	mr, client := newMiniRedis(t)
+	mockRepo := &MockUserRepository{}
+	mockRepo.On("GetRecent", 5).Return([]models.User{{ID: 2, Name: "Newest", Email: "newest@example.com", Age: 30}}, nil).Once()
+
+	userService := service.NewUserService(mockRepo, cache.NewRedis(client))
+	first, err := userService.GetRecentUsers(5)
+	assert.NoError(t, err)
+	second, err := userService.GetRecentUsers(5)
+	assert.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	mockRepo.AssertNumberOfCalls(t, "GetRecent", 1)
+	assert.Equal(t, 30*time.Second, mr.TTL("users:recent:5"))
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_FindSimilarUsers(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+
+	target := &models.User{Name: "John Smith", Email: "john.smith@example.com", Age: 30, Phone: "5550001111"}
+	samePhone := &models.User{Name: " john SMITH ", Email: "jsmith@work.example", Age: 30, Phone: "5550001111"}
+	taggedEmail := &models.User{Name: "Johnny", Email: "John.Smith+shop@mail.example", Age: 31}
+	unrelated := &models.User{Name: "Jane Doe", Email: "jane@example.com", Age: 25, Phone: "5559998888"}
+	for _, user := range []*models.User{target, samePhone, taggedEmail, unrelated} {
+		require.NoError(t, repo.Create(user))
+	}
+
+	similar, err := userService.FindSimilarUsers(target.ID)
+
+	require.NoError(t, err)
+	require.Len(t, similar, 2)
+
+	assert.Equal(t, samePhone.ID, similar[0].User.ID, "same phone and name ranks first")
+	assert.Equal(t, 0.8, similar[0].Score)
+	assert.Equal(t, []string{"name", "phone"}, similar[0].Matches)
+
+	assert.Equal(t, taggedEmail.ID, similar[1].User.ID)
+	assert.Equal(t, 0.2, similar[1].Score)
+	assert.Equal(t, []string{"email"}, similar[1].Matches)
+}
+
+func TestUserRepository_FindSimilar_EscapesWildcards(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+
+	target := &models.User{Name: "Percent", Email: "a_b%@example.com", Age: 30}
+	sameLocal := &models.User{Name: "Same", Email: "A_B%+news@mail.example", Age: 30}
+	wildcardMatch := &models.User{Name: "Other", Email: "axbyz@example.com", Age: 30}
+	for _, user := range []*models.User{target, sameLocal, wildcardMatch} {
+		require.NoError(t, repo.Create(user))
+	}
+
+	similar, err := repo.FindSimilar(target, 10)
+
+	require.NoError(t, err)
+	require.Len(t, similar, 1, "_ and % in the local part match literally")
+	assert.Equal(t, sameLocal.ID, similar[0].ID)
+}
+
+func TestUserService_FindSimilarUsers_NotFound(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := service.NewUserService(mockRepo, nil)
+	mockRepo.On("GetByID", uint(9)).Return(nil, errors.New("user not found"))
+
+	similar, err := userService.FindSimilarUsers(9)
+
+	assert.Nil(t, similar)
+	assert.EqualError(t, err, "user not found")
+	mockRepo.AssertNotCalled(t, "FindSimilar")
+}
+
+func TestUserController_GetSimilarUsers(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		mockReturn     []models.SimilarUser
+		mockError      error
+		expectedStatus int
+	}{
+		{
+			name:           "returns candidates",
+			userID:         "1",
+			mockReturn:     []models.SimilarUser{{User: models.UserResponse{ID: 2}, Score: 0.8, Matches: []string{"name", "phone"}}},
+			expectedStatus: http.StatusOK,
+		},
+		{name: "user not found", userID: "9", mockError: models.ErrUserNotFound, expectedStatus: http.StatusNotFound},
+		{name: "invalid ID", userID: "abc", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUserService)
+			controller := controllers.NewUserController(mockService)
+			router := setupTestRouter()
+			router.GET("/users/:id/similar", controller.GetSimilarUsers)
+
+			if tt.expectedStatus != http.StatusBadRequest {
+				userID, _ := strconv.ParseUint(tt.userID, 10, 32)
+				mockService.On("FindSimilarUsers", uint(userID)).Return(tt.mockReturn, tt.mockError)
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, "/users/"+tt.userID+"/similar", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
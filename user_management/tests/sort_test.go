@@ -0,0 +1,221 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRepository_Search_NullOrdering(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+
+	one, two := uint(1), uint(2)
+	var ids []uint
+	for i, actor := range []*uint{&two, nil, &one, nil} {
+		user := &models.User{Name: "User", Email: fmt.Sprintf("user%d@example.com", i), Age: 30, UpdatedBy: actor}
+		require.NoError(t, repo.Create(user))
+		ids = append(ids, user.ID)
+	}
+	withTwo, firstNull, withOne, secondNull := ids[0], ids[1], ids[2], ids[3]
+
+	tests := []struct {
+		name     string
+		sort     models.UserSort
+		expected []uint
+	}{
+		{name: "asc nulls last", sort: models.UserSort{Column: "updated_by"}, expected: []uint{withOne, withTwo, firstNull, secondNull}},
+		{name: "asc nulls first", sort: models.UserSort{Column: "updated_by", NullsFirst: true}, expected: []uint{firstNull, secondNull, withOne, withTwo}},
+		{name: "desc nulls last", sort: models.UserSort{Column: "updated_by", Descending: true}, expected: []uint{withTwo, withOne, firstNull, secondNull}},
+		{name: "desc nulls first", sort: models.UserSort{Column: "updated_by", Descending: true, NullsFirst: true}, expected: []uint{firstNull, secondNull, withTwo, withOne}},
+		{name: "unknown column falls back to id", sort: models.UserSort{Column: "name; DROP TABLE users", Descending: true}, expected: []uint{secondNull, withOne, firstNull, withTwo}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, total, err := repo.Search(models.UserFilter{}, tt.sort, 0, 10)
+
+			require.NoError(t, err)
+			assert.Equal(t, int64(4), total)
+			got := make([]uint, 0, len(users))
+			for _, user := range users {
+				got = append(got, user.ID)
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestUserController_GetUsers_Sort(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults []string
+		query    string
+		expected models.UserSort
+	}{
+		{name: "built-in defaults", query: "?sort_by=updated_by", expected: models.UserSort{Column: "updated_by"}},
+		{name: "configured defaults", defaults: []string{"desc", "first"}, query: "?sort_by=updated_by", expected: models.UserSort{Column: "updated_by", Descending: true, NullsFirst: true}},
+		{name: "query overrides defaults", defaults: []string{"desc", "first"}, query: "?sort_by=updated_by&order=asc&nulls=last", expected: models.UserSort{Column: "updated_by"}},
+		{name: "invalid configured defaults are ignored", defaults: []string{"sideways", "middle"}, query: "?sort_by=name", expected: models.UserSort{Column: "name"}},
+		{name: "unknown column falls back to id", query: "?sort_by=password&order=desc", expected: models.UserSort{Column: "id", Descending: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockService := new(MockUserService)
+			var opts []controllers.Option
+			if tt.defaults != nil {
+				opts = append(opts, controllers.WithSortDefaults(tt.defaults[0], tt.defaults[1]))
+			}
+			controller := controllers.NewUserController(mockService, opts...)
+			router := setupTestRouter()
+			router.GET("/users", controller.GetUsers)
+
+			mockService.On("UsersVersion").Return(models.CollectionVersion{}, nil)
+			mockService.On("SearchUsers", models.UserFilter{}, tt.expected, 1, 10).Return([]models.UserResponse{}, int64(0), nil)
+
+			// Perform request
+			req, _ := http.NewRequest(http.MethodGet, "/users"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// Assertions
+			assert.Equal(t, http.StatusOK, w.Code)
+			mockService.AssertExpectations(t)
+			mockService.AssertNotCalled(t, "CountUsers")
+		})
+	}
+}
+
+func TestUserController_GetUsers_InvalidSort(t *testing.T) {
+	for _, query := range []string{"?sort_by=name&order=up", "?sort_by=updated_by&nulls=middle"} {
+		t.Run(query, func(t *testing.T) {
+			mockService := new(MockUserService)
+			controller := controllers.NewUserController(mockService)
+			router := setupTestRouter()
+			router.GET("/users", controller.GetUsers)
+
+			req, _ := http.NewRequest(http.MethodGet, "/users"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockService.AssertNotCalled(t, "SearchUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUserController_GetUsers_SortOrder(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	controller := controllers.NewUserController(service.NewUserService(repo, nil))
+	router := setupTestRouter()
+	router.GET("/users", controller.GetUsers)
+
+	// Inserted out of creation order so id order alone would get it wrong
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, user := range []*models.User{
+		{Name: "Carol", Email: "carol@example.com", Age: 41, CreatedAt: base.Add(time.Hour)},
+		{Name: "Alice", Email: "alice@example.com", Age: 25, CreatedAt: base.Add(3 * time.Hour)},
+		{Name: "Bob", Email: "bob@example.com", Age: 33, CreatedAt: base},
+		{Name: "Dave", Email: "dave@example.com", Age: 19, CreatedAt: base.Add(2 * time.Hour)},
+	} {
+		require.NoError(t, repo.Create(user))
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{name: "newest first", query: "sort_by=created_at&order=desc", expected: []string{"Alice", "Dave", "Carol", "Bob"}},
+		{name: "oldest first", query: "sort_by=created_at", expected: []string{"Bob", "Carol", "Dave", "Alice"}},
+		{name: "by name", query: "sort_by=name&order=asc", expected: []string{"Alice", "Bob", "Carol", "Dave"}},
+		{name: "by age descending", query: "sort_by=age&order=desc", expected: []string{"Carol", "Bob", "Alice", "Dave"}},
+		{name: "unknown column uses id", query: "sort_by=password", expected: []string{"Carol", "Alice", "Bob", "Dave"}},
+		{name: "injected column uses id", query: "sort_by=name%3B%20DROP%20TABLE%20users", expected: []string{"Carol", "Alice", "Bob", "Dave"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := getJSON(t, router, "/users?"+tt.query)
+
+			require.Equal(t, http.StatusOK, status)
+			var names []string
+			for _, item := range response["data"].([]interface{}) {
+				names = append(names, item.(map[string]interface{})["name"].(string))
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+
+	assert.True(t, db.Migrator().HasTable(&models.User{}), "the users table survives")
+}
+
+func TestUserRepository_Search_RanksEmailMatchesFirst(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+
+	// Inserted worst match first so id order alone would get it wrong
+	for _, user := range []*models.User{
+		{Name: "Tom Annex", Email: "tom@example.com", Age: 30},
+		{Name: "Ann Lee", Email: "lee@example.com", Age: 30},
+		{Name: "Bob", Email: "ann.smith@example.com", Age: 30},
+		{Name: "Carol", Email: "ann", Age: 30},
+	} {
+		require.NoError(t, repo.Create(user))
+	}
+
+	names := func(users []models.User) []string {
+		var out []string
+		for _, user := range users {
+			out = append(out, user.Name)
+		}
+		return out
+	}
+	want := []string{"Carol", "Bob", "Ann Lee", "Tom Annex"}
+
+	users, total, err := repo.Search(models.UserFilter{Search: "Ann"}, models.UserSort{}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	assert.Equal(t, want, names(users), "exact email, email prefix, name prefix, then substring")
+
+	users, _, err = repo.GetPage(1, 10, models.PageOptions{Filter: models.UserFilter{Search: "ann"}})
+	require.NoError(t, err)
+	assert.Equal(t, want, names(users))
+
+	// An explicit sort takes precedence over the ranking
+	users, _, err = repo.Search(models.UserFilter{Search: "ann"}, models.UserSort{Column: "id"}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Tom Annex", "Ann Lee", "Bob", "Carol"}, names(users))
+}
+
+func TestUserController_GetUsers_QAliasesSearch(t *testing.T) {
+	mockService := new(MockUserService)
+	controller := controllers.NewUserController(mockService)
+	router := setupTestRouter()
+	router.GET("/users", controller.GetUsers)
+
+	mockService.On("UsersVersion").Return(models.CollectionVersion{}, nil)
+	mockService.On("SearchUsers", models.UserFilter{Search: "ann"}, models.UserSort{}, 1, 10).
+		Return([]models.UserResponse{}, int64(0), nil)
+	mockService.On("CountUsers").Return(int64(0), nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users?q=ann", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,200 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/auth"
+	"github.com/IntouchOpec/user_management/config"
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/middleware"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/reqctx"
+	"github.com/IntouchOpec/user_management/routes"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signTenantToken issues an hour-long token for subject with the given role,
+// bound to tenantID unless it is empty
+func signTenantToken(t *testing.T, subject, role, tenantID string) string {
+	t.Helper()
+
+	now := time.Now()
+	token, err := auth.Sign(testJWTSecret, auth.Claims{
+		Role:     role,
+		TenantID: tenantID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	})
+	require.NoError(t, err)
+	return "Bearer " + token
+}
+
+func TestTenant_BoundToToken(t *testing.T) {
+	tests := []struct {
+		name           string
+		host           string
+		header         string
+		authorization  string
+		expectedStatus int
+		expectedTenant string
+	}{
+		{name: "token tenant applies without a header", host: "localhost", authorization: signTenantToken(t, "7", "user", "acme"), expectedStatus: http.StatusOK, expectedTenant: "acme"},
+		{name: "matching header", host: "localhost", header: "ACME", authorization: signTenantToken(t, "7", "user", "acme"), expectedStatus: http.StatusOK, expectedTenant: "acme"},
+		{name: "other tenant by header", host: "localhost", header: "globex", authorization: signTenantToken(t, "7", "user", "acme"), expectedStatus: http.StatusForbidden},
+		{name: "other tenant by subdomain", host: "globex.example.com", authorization: signTenantToken(t, "7", "user", "acme"), expectedStatus: http.StatusForbidden},
+		{name: "admin token bound to a tenant", host: "localhost", header: "globex", authorization: signTenantToken(t, "1", "admin", "acme"), expectedStatus: http.StatusForbidden},
+		{name: "user token without a tenant", host: "localhost", header: "globex", authorization: signTenantToken(t, "7", "user", ""), expectedStatus: http.StatusForbidden},
+		{name: "admin token without a tenant", host: "localhost", header: "globex", authorization: signTenantToken(t, "1", "admin", ""), expectedStatus: http.StatusOK, expectedTenant: "globex"},
+		{name: "token without a tenant and no header", host: "localhost", authorization: signTenantToken(t, "7", "user", ""), expectedStatus: http.StatusOK},
+		{name: "anonymous", host: "localhost", header: "globex", expectedStatus: http.StatusOK, expectedTenant: "globex"},
+		{name: "invalid token counts as anonymous", host: "localhost", header: "globex", authorization: "Bearer not-a-token", expectedStatus: http.StatusOK, expectedTenant: "globex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupTestRouter()
+			router.Use(middleware.OptionalAuth(testJWTSecret), middleware.Tenant())
+			var gotTenant string
+			router.GET("/test", func(c *gin.Context) {
+				gotTenant, _ = reqctx.TenantID(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set(middleware.TenantHeader, tt.header)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedTenant, gotTenant)
+			if tt.expectedStatus == http.StatusForbidden {
+				assert.Contains(t, w.Body.String(), `"code":"`+models.CodeTenantMismatch+`"`)
+			}
+		})
+	}
+}
+
+func TestTenant_TokenCannotReadOtherTenant(t *testing.T) {
+	db := setupTestDB(t)
+	tenantA, tenantB := tenantServices(db)
+	alice, err := tenantA.CreateUser(models.UserRequest{Name: "Alice", Email: "alice@example.com", Age: 30})
+	require.NoError(t, err)
+	bob, err := tenantB.CreateUser(models.UserRequest{Name: "Bob", Email: "bob@example.com", Age: 30})
+	require.NoError(t, err)
+
+	// The chain main builds
+	router := setupTestRouter()
+	router.Use(middleware.OptionalAuth(testJWTSecret), middleware.Tenant())
+	routes.SetupRoutes(router, controllers.NewUserController(service.NewUserService(repository.NewUserRepository(db), nil)), nil,
+		config.AuthConfig{JWTSecret: testJWTSecret, RequireAuthForReads: true})
+	token := signTenantToken(t, fmt.Sprint(alice.ID), "user", "tenant-a")
+	get := func(path, tenantHeader string) int {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", token)
+		if tenantHeader != "" {
+			req.Header.Set(middleware.TenantHeader, tenantHeader)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get(fmt.Sprintf("/api/v1/users/%d", alice.ID), ""))
+	assert.Equal(t, http.StatusForbidden, get(fmt.Sprintf("/api/v1/users/%d", bob.ID), "tenant-b"), "naming the other tenant is rejected")
+	assert.Equal(t, http.StatusNotFound, get(fmt.Sprintf("/api/v1/users/%d", bob.ID), ""), "without a header the token's tenant still applies")
+
+	status, response := sendAuthorized(t, router, http.MethodGet, "/api/v1/users", token, nil)
+	require.Equal(t, http.StatusOK, status)
+	users := response["data"].([]interface{})
+	require.Len(t, users, 1)
+	assert.Equal(t, "Alice", users[0].(map[string]interface{})["name"])
+}
+
+func TestIntrospect_ReportsTenant(t *testing.T) {
+	router := setupAuthRouter()
+	token := strings.TrimPrefix(signTenantToken(t, "42", "user", "acme"), "Bearer ")
+
+	status, response := introspect(t, router, token)
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "acme", response["tenant_id"])
+}
+
+func TestRequireTenant_RejectsUnscopedRequests(t *testing.T) {
+	db := setupTestDB(t)
+	tenantA, tenantB := tenantServices(db)
+	_, err := tenantA.CreateUser(models.UserRequest{Name: "Alice", Email: "alice@example.com", Age: 30})
+	require.NoError(t, err)
+	_, err = tenantB.CreateUser(models.UserRequest{Name: "Bob", Email: "bob@example.com", Age: 30})
+	require.NoError(t, err)
+
+	router := setupTestRouter()
+	router.Use(middleware.OptionalAuth(testJWTSecret), middleware.Tenant())
+	routes.SetupRoutes(router, controllers.NewUserController(service.NewUserService(repository.NewUserRepository(db), nil)), nil,
+		config.AuthConfig{JWTSecret: testJWTSecret, RequireTenant: true})
+
+	tests := []struct {
+		name           string
+		header         string
+		authorization  string
+		expectedStatus int
+		expectedUsers  int
+	}{
+		{name: "anonymous without a tenant", expectedStatus: http.StatusForbidden},
+		{name: "user token without a tenant", authorization: signTenantToken(t, "7", "user", ""), expectedStatus: http.StatusForbidden},
+		{name: "invalid token without a tenant", authorization: "Bearer not-a-token", expectedStatus: http.StatusForbidden},
+		{name: "user token bound to a tenant", authorization: signTenantToken(t, "7", "user", "tenant-a"), expectedStatus: http.StatusOK, expectedUsers: 1},
+		{name: "anonymous naming a tenant", header: "tenant-b", expectedStatus: http.StatusOK, expectedUsers: 1},
+		{name: "admin token without a tenant", authorization: signTenantToken(t, "1", "admin", ""), expectedStatus: http.StatusOK, expectedUsers: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/api/v1/users", nil)
+			if tt.header != "" {
+				req.Header.Set(middleware.TenantHeader, tt.header)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusForbidden {
+				assert.Contains(t, w.Body.String(), `"code":"`+models.CodeTenantRequired+`"`)
+				return
+			}
+			var response struct {
+				Data []models.UserResponse `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Len(t, response.Data, tt.expectedUsers)
+		})
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "/health", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.NotEqual(t, http.StatusForbidden, w.Code, "routes outside /users stay open")
+}
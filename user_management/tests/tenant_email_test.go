@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/database"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/reqctx"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func tenantServices(db *gorm.DB) (a, b service.UserService) {
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+	a = userService.WithContext(reqctx.WithTenantID(context.Background(), "tenant-a"))
+	b = userService.WithContext(reqctx.WithTenantID(context.Background(), "tenant-b"))
+	return a, b
+}
+
+func TestTenantScopedEmails(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, database.MigrateDatabase(database.WithTenantScopedEmails(true)))
+	tenantA, tenantB := tenantServices(db)
+
+	_, err := tenantA.CreateUser(models.UserRequest{Name: "John A", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+
+	_, err = tenantB.CreateUser(models.UserRequest{Name: "John B", Email: "john@example.com", Age: 31})
+	assert.NoError(t, err, "another tenant may reuse the address")
+
+	_, err = tenantA.CreateUser(models.UserRequest{Name: "John Again", Email: "john@example.com", Age: 32})
+	var exists *models.EmailExistsError
+	assert.True(t, errors.As(err, &exists), "the address is taken within the tenant: %v", err)
+
+	// The index itself enforces it, ignoring case, even when the lookup is skipped
+	err = db.Create(&models.User{Name: "Raw", Email: "JOHN@example.com", Age: 30, TenantID: "tenant-a"}).Error
+	assert.Error(t, err)
+}
+
+func TestTenantScopedEmails_DisabledKeepsGlobalIndex(t *testing.T) {
+	db := setupTestDB(t)
+	tenantA, tenantB := tenantServices(db)
+
+	_, err := tenantA.CreateUser(models.UserRequest{Name: "John A", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+
+	_, err = tenantB.CreateUser(models.UserRequest{Name: "John B", Email: "john@example.com", Age: 31})
+	assert.Error(t, err, "emails are unique across tenants by default")
+}
+
+func TestTenantScopedEmails_SwitchingBackRestoresGlobalIndex(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, database.MigrateDatabase(database.WithTenantScopedEmails(true)))
+	require.NoError(t, database.MigrateDatabase())
+
+	tenantA, tenantB := tenantServices(db)
+	_, err := tenantA.CreateUser(models.UserRequest{Name: "John A", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	_, err = tenantB.CreateUser(models.UserRequest{Name: "John B", Email: "john@example.com", Age: 31})
+	assert.Error(t, err)
+}
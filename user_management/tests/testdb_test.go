@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/database"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupTestDB opens an isolated in-memory SQLite database with the tenant
+// scope and availability check installed, points the database package at
+// it and runs the application migrations.
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", name)
+
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, database.RegisterTenantScope(db))
+	require.NoError(t, database.RegisterAvailabilityCheck(db))
+
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = originalDB
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	require.NoError(t, database.MigrateDatabase())
+	return db
+}
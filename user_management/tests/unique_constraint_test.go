@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestUserRepository_UniqueViolationNamesField(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Exec("CREATE UNIQUE INDEX idx_users_phone ON users (phone)").Error)
+	repo := repository.NewUserRepository(db)
+
+	require.NoError(t, repo.Create(&models.User{Name: "First", Email: "first@example.com", Age: 30, Phone: "+14155550100"}))
+
+	err := repo.Create(&models.User{Name: "Second", Email: "second@example.com", Age: 30, Phone: "+14155550100"})
+	var duplicate *models.UniqueViolationError
+	require.ErrorAs(t, err, &duplicate)
+	assert.Equal(t, "phone", duplicate.Field)
+	assert.Equal(t, "user with this phone already exists", err.Error())
+	assert.NotErrorIs(t, err, models.ErrEmailExists)
+
+	// Email conflicts caught by the index keep their own error
+	err = repo.Create(&models.User{Name: "Third", Email: "FIRST@example.com", Age: 30})
+	assert.ErrorIs(t, err, models.ErrEmailExists)
+	assert.Equal(t, "user with this email already exists", err.Error())
+}
+
+func TestUserController_PhoneConflict(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.Exec("CREATE UNIQUE INDEX idx_users_phone_number ON users (phone)").Error)
+	repository.RegisterUniqueConstraint("idx_users_phone_number", "phone", "this phone number is already registered")
+	t.Cleanup(func() { repository.RegisterUniqueConstraint("idx_users_phone_number", "phone", "") })
+	userService := service.NewUserService(repository.NewUserRepository(db), nil)
+
+	router := setupTestRouter()
+	router.POST("/users", controllers.NewUserController(userService).CreateUser)
+	create := func(email string) (int, map[string]interface{}) {
+		body, _ := json.Marshal(models.UserRequest{Name: "John Doe", Email: email, Age: 30, Phone: "+14155550100"})
+		req, _ := http.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	status, _ := create("john@example.com")
+	require.Equal(t, http.StatusCreated, status)
+
+	status, response := create("johnny@example.com")
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, models.CodeDuplicateValue, response["code"])
+	assert.Contains(t, response["error"], "this phone number is already registered")
+	assert.NotContains(t, response["error"], "email")
+	assert.Equal(t, "phone", response["field"])
+}
+
+func TestUserRepository_PostgresUniqueViolation(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+
+	// Fail inserts the way Postgres reports a violated unique constraint
+	require.NoError(t, db.Callback().Create().Before("gorm:create").Register("test:unique_violation", func(tx *gorm.DB) {
+		_ = tx.AddError(errors.New(`ERROR: duplicate key value violates unique constraint "users_phone_key" (SQLSTATE 23505)`))
+	}))
+
+	err := repo.Create(&models.User{Name: "John Doe", Email: "john@example.com", Age: 30, Phone: "+14155550100"})
+	var duplicate *models.UniqueViolationError
+	require.ErrorAs(t, err, &duplicate)
+	assert.Equal(t, "phone", duplicate.Field)
+}
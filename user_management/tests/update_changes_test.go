@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserController_UpdateUser_ReportsChanges(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	controller := controllers.NewUserController(service.NewUserService(repo, nil))
+	router := setupTestRouter()
+	router.PUT("/users/:id", controller.UpdateUser)
+
+	updatedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	user := seedUser(t, db, "john@example.com", true, updatedAt)
+
+	put := func(req models.UserRequest) []interface{} {
+		body, _ := json.Marshal(req)
+		httpReq, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/users/%d", user.ID), bytes.NewBuffer(body))
+		httpReq.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httpReq)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			Changed []interface{} `json:"changed"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.NotNil(t, response.Changed, "changed is always a list")
+		return response.Changed
+	}
+
+	t.Run("identical values write nothing", func(t *testing.T) {
+		changed := put(models.UserRequest{Name: user.Name, Email: user.Email, Age: user.Age})
+
+		assert.Empty(t, changed)
+		stored, err := repo.GetByID(user.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.UpdatedAt.Equal(updatedAt), "updated_at was bumped to %s", stored.UpdatedAt)
+	})
+
+	t.Run("changed values are listed and saved", func(t *testing.T) {
+		changed := put(models.UserRequest{Name: "John Renamed", Email: user.Email, Age: user.Age})
+
+		assert.Equal(t, []interface{}{"name"}, changed)
+		stored, err := repo.GetByID(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "John Renamed", stored.Name)
+		assert.True(t, stored.UpdatedAt.After(updatedAt))
+	})
+}
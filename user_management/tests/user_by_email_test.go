@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/cache"
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserController_GetUserByEmail(t *testing.T) {
+	db := setupTestDB(t)
+	memory := cache.NewMemory()
+	userService := service.NewUserService(repository.NewUserRepository(db), memory)
+	router := setupTestRouter()
+	router.GET("/users/by-email", controllers.NewUserController(userService).GetUserByEmail)
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "found", query: "?email=john@example.com", expectedStatus: http.StatusOK},
+		{name: "found ignoring case", query: "?email=%20John@Example.COM", expectedStatus: http.StatusOK},
+		{name: "not found", query: "?email=jane@example.com", expectedStatus: http.StatusNotFound, expectedCode: models.CodeUserNotFound},
+		{name: "missing email", query: "", expectedStatus: http.StatusBadRequest, expectedCode: models.CodeInvalidParameter},
+		{name: "blank email", query: "?email=%20", expectedStatus: http.StatusBadRequest, expectedCode: models.CodeInvalidParameter},
+		{name: "malformed email", query: "?email=not-an-email", expectedStatus: http.StatusBadRequest, expectedCode: models.CodeInvalidParameter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := getJSON(t, router, "/users/by-email"+tt.query)
+
+			assert.Equal(t, tt.expectedStatus, status)
+			if tt.expectedCode != "" {
+				assert.Equal(t, tt.expectedCode, response["code"])
+				return
+			}
+			data := response["data"].(map[string]interface{})
+			assert.Equal(t, float64(user.ID), data["id"])
+			assert.Equal(t, "john@example.com", data["email"])
+		})
+	}
+
+	// The lookup caches the user where GetUserByID finds it
+	require.NoError(t, memory.Del(context.Background(), "user:1"))
+	_, _ = getJSON(t, router, "/users/by-email?email=john@example.com")
+	_, err = memory.Get(context.Background(), "user:1")
+	assert.NoError(t, err)
+}
@@ -2,18 +2,25 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/IntouchOpec/user_management/controllers"
 	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockUserService is a mock implementation of UserService
@@ -21,6 +28,10 @@ type MockUserService struct {
 	mock.Mock
 }
 
+func (m *MockUserService) WithContext(ctx context.Context) service.UserService {
+	return m
+}
+
 func (m *MockUserService) CreateUser(req models.UserRequest) (*models.UserResponse, error) {
 	args := m.Called(req)
 	if args.Get(0) == nil {
@@ -42,19 +53,244 @@ func (m *MockUserService) GetAllUsers(page, pageSize int) ([]models.UserResponse
 	return args.Get(0).([]models.UserResponse), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockUserService) UpdateUser(id uint, req models.UserRequest) (*models.UserResponse, error) {
-	args := m.Called(id, req)
+func (m *MockUserService) SearchUsers(filter models.UserFilter, sort models.UserSort, page, pageSize int) ([]models.UserResponse, int64, error) {
+	args := m.Called(filter, sort, page, pageSize)
+	return args.Get(0).([]models.UserResponse), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserService) GetUsersPage(filter models.UserFilter, sort models.UserSort, page, pageSize int) ([]models.UserResponse, bool, error) {
+	args := m.Called(filter, sort, page, pageSize)
+	return args.Get(0).([]models.UserResponse), args.Bool(1), args.Error(2)
+}
+
+func (m *MockUserService) SetUserStatus(id uint, status models.UserStatus) (*models.UserResponse, error) {
+	args := m.Called(id, status)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserResponse), args.Error(1)
+}
+
+// ExportUsers hands the users given to On to fn as a single batch
+func (m *MockUserService) ExportUsers(filter models.UserFilter, fn func([]models.UserResponse) error) error {
+	args := m.Called(filter)
+	if users, ok := args.Get(0).([]models.UserResponse); ok && len(users) > 0 {
+		if err := fn(users); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockUserService) GetEmailHistory(id uint) ([]models.UserEmailHistory, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.UserEmailHistory), args.Error(1)
+}
+
+func (m *MockUserService) GetUserProfile(id uint) (*models.UserProfile, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserProfile), args.Error(1)
+}
+
+func (m *MockUserService) ExportUserData(id uint) (*models.UserDataExport, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserDataExport), args.Error(1)
+}
+
+func (m *MockUserService) ScheduleDeletion(id uint) (*models.UserResponse, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserResponse), args.Error(1)
+}
+
+func (m *MockUserService) CancelDeletion(id uint) (*models.UserResponse, error) {
+	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.UserResponse), args.Error(1)
 }
 
+func (m *MockUserService) DeleteDueAccounts(now time.Time) (int64, error) {
+	args := m.Called(now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserService) CountUsers() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserService) UsersVersion() (models.CollectionVersion, error) {
+	args := m.Called()
+	return args.Get(0).(models.CollectionVersion), args.Error(1)
+}
+
+func (m *MockUserService) UpdateUser(id uint, req models.UserRequest) (*models.UserResponse, []string, error) {
+	args := m.Called(id, req)
+	changed, _ := args.Get(1).([]string)
+	if args.Get(0) == nil {
+		return nil, changed, args.Error(2)
+	}
+	return args.Get(0).(*models.UserResponse), changed, args.Error(2)
+}
+
 func (m *MockUserService) DeleteUser(id uint) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *MockUserService) BulkCreateUsers(reqs []models.UserRequest) ([]models.UserResponse, error) {
+	args := m.Called(reqs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.UserResponse), args.Error(1)
+}
+
+func (m *MockUserService) BulkUpdateUsers(items []models.BulkUpdateItem) ([]models.UserResponse, error) {
+	args := m.Called(items)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.UserResponse), args.Error(1)
+}
+
+func (m *MockUserService) BulkDeleteUsers(ids []uint) (int64, error) {
+	args := m.Called(ids)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserService) DeleteInactiveUsers(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserService) GetUsersAfter(afterID uint, limit int) ([]models.UserResponse, *uint, error) {
+	args := m.Called(afterID, limit)
+	var next *uint
+	if args.Get(1) != nil {
+		next = args.Get(1).(*uint)
+	}
+	return args.Get(0).([]models.UserResponse), next, args.Error(2)
+}
+
+func (m *MockUserService) GetUserByEmail(email string) (*models.UserResponse, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserResponse), args.Error(1)
+}
+
+func (m *MockUserService) RestoreUser(id uint) (*models.UserResponse, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserResponse), args.Error(1)
+}
+
+func (m *MockUserService) BulkSetRole(filter models.UserFilter, role string, maxAffected int) (int64, error) {
+	args := m.Called(filter, role, maxAffected)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserService) BulkSetActive(filter models.UserFilter, active bool) (int64, error) {
+	args := m.Called(filter, active)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserService) ImportUsers(reqs []models.UserRequest, strategy models.DuplicateStrategy) (*models.ImportResult, error) {
+	args := m.Called(reqs, strategy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ImportResult), args.Error(1)
+}
+
+func (m *MockUserService) FindSimilarUsers(id uint) ([]models.SimilarUser, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SimilarUser), args.Error(1)
+}
+
+func (m *MockUserService) PreloadCache(ids []uint) error {
+	args := m.Called(ids)
+	return args.Error(0)
+}
+
+func (m *MockUserService) WarmCache(limit int) error {
+	args := m.Called(limit)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ResetUser(id uint) (*models.UserResponse, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserResponse), args.Error(1)
+}
+
+func (m *MockUserService) GetRecentUsers(limit int) ([]models.UserResponse, error) {
+	args := m.Called(limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.UserResponse), args.Error(1)
+}
+
+func (m *MockUserService) CountFilteredUsers(filter models.UserFilter) (int64, error) {
+	args := m.Called(filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserService) GetRandomUser() (*models.UserResponse, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserResponse), args.Error(1)
+}
+
+func (m *MockUserService) GetUserNeighbors(id uint) (*models.UserNeighbors, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserNeighbors), args.Error(1)
+}
+
+func (m *MockUserService) SignupCalendar(year, month int) (*models.SignupCalendar, error) {
+	args := m.Called(year, month)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SignupCalendar), args.Error(1)
+}
+
+func (m *MockUserService) CompareUsers(a, b uint) (*models.UserComparison, error) {
+	args := m.Called(a, b)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserComparison), args.Error(1)
+}
+
 func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -174,7 +410,7 @@ func TestUserController_GetUser(t *testing.T) {
 			name:           "user not found",
 			userID:         "999",
 			mockReturn:     nil,
-			mockError:      errors.New("user not found"),
+			mockError:      models.ErrUserNotFound,
 			expectedStatus: http.StatusNotFound,
 			expectedError:  true,
 		},
@@ -287,6 +523,7 @@ func TestUserController_GetUsers(t *testing.T) {
 			}
 
 			// Mock setup
+			mockService.On("UsersVersion").Return(models.CollectionVersion{Count: tt.mockTotal}, nil)
 			mockService.On("GetAllUsers", page, pageSize).Return(tt.mockUsers, tt.mockTotal, tt.mockError)
 
 			// Route setup
@@ -311,7 +548,7 @@ func TestUserController_GetUsers(t *testing.T) {
 				assert.Equal(t, tt.mockError.Error(), response["error"])
 			} else {
 				assert.Contains(t, response, "data")
-				assert.Contains(t, response, "pagination")
+				assert.Contains(t, response, "meta")
 			}
 
 			mockService.AssertExpectations(t)
@@ -361,7 +598,7 @@ func TestUserController_UpdateUser(t *testing.T) {
 				Age:   30,
 			},
 			mockReturn:     nil,
-			mockError:      errors.New("user not found"),
+			mockError:      models.ErrUserNotFound,
 			expectedStatus: http.StatusNotFound,
 			expectedError:  true,
 		},
@@ -376,7 +613,7 @@ func TestUserController_UpdateUser(t *testing.T) {
 
 			// Mock setup
 			userID, _ := strconv.ParseUint(tt.userID, 10, 32)
-			mockService.On("UpdateUser", uint(userID), tt.requestBody).Return(tt.mockReturn, tt.mockError)
+			mockService.On("UpdateUser", uint(userID), tt.requestBody).Return(tt.mockReturn, []string(nil), tt.mockError)
 
 			// Route setup
 			router.PUT("/users/:id", controller.UpdateUser)
@@ -427,7 +664,7 @@ func TestUserController_DeleteUser(t *testing.T) {
 		{
 			name:           "user not found",
 			userID:         "999",
-			mockError:      errors.New("failed to delete user: user not found"),
+			mockError:      fmt.Errorf("failed to delete user: %w", models.ErrUserNotFound),
 			expectedStatus: http.StatusNotFound,
 			expectedError:  true,
 		},
@@ -498,3 +735,365 @@ func TestUserController_HealthCheck(t *testing.T) {
 	assert.Equal(t, "healthy", response["status"])
 	assert.Contains(t, response, "timestamp")
 }
+
+func TestUserController_DeleteInactiveUsers(t *testing.T) {
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectCall     bool
+		expectedStatus int
+	}{
+		{name: "missing confirmation", query: "?before=2024-01-01T00:00:00Z", expectedStatus: http.StatusBadRequest},
+		{name: "confirmation not true", query: "?before=2024-01-01T00:00:00Z&confirm=yes", expectedStatus: http.StatusBadRequest},
+		{name: "missing cutoff", query: "?confirm=true", expectedStatus: http.StatusBadRequest},
+		{name: "invalid cutoff", query: "?confirm=true&before=yesterday", expectedStatus: http.StatusBadRequest},
+		{name: "confirmed deletion", query: "?confirm=true&before=2024-01-01T00:00:00Z", expectCall: true, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockService := new(MockUserService)
+			controller := controllers.NewUserController(mockService)
+			router := setupTestRouter()
+			router.DELETE("/users/inactive", controller.DeleteInactiveUsers)
+
+			if tt.expectCall {
+				mockService.On("DeleteInactiveUsers", cutoff).Return(int64(3), nil)
+			}
+
+			// Perform request
+			req, _ := http.NewRequest(http.MethodDelete, "/users/inactive"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// Assertions
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+
+			if tt.expectCall {
+				assert.Equal(t, float64(3), response["deleted"])
+				mockService.AssertExpectations(t)
+			} else {
+				assert.Contains(t, response, "error")
+				mockService.AssertNotCalled(t, "DeleteInactiveUsers", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestUserController_GetUsers_UpdatedByFilter(t *testing.T) {
+	t.Run("filters by actor", func(t *testing.T) {
+		mockService := new(MockUserService)
+		controller := controllers.NewUserController(mockService)
+		router := setupTestRouter()
+		router.GET("/users", controller.GetUsers)
+
+		actor := uint(5)
+		mockService.On("UsersVersion").Return(models.CollectionVersion{}, nil)
+		mockService.On("SearchUsers", models.UserFilter{UpdatedBy: &actor}, models.UserSort{}, 1, 10).
+			Return([]models.UserResponse{{ID: 2, Name: "Jane Doe", UpdatedBy: &actor}}, int64(1), nil)
+		mockService.On("CountUsers").Return(int64(4), nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/users?updated_by=5", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+		mockService.AssertNotCalled(t, "GetAllUsers", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects a non-numeric actor", func(t *testing.T) {
+		mockService := new(MockUserService)
+		controller := controllers.NewUserController(mockService)
+		router := setupTestRouter()
+		router.GET("/users", controller.GetUsers)
+
+		req, _ := http.NewRequest(http.MethodGet, "/users?updated_by=admin", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "SearchUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestUserController_GetUsers_SearchDepthCap(t *testing.T) {
+	t.Run("rejects a search page beyond the cap", func(t *testing.T) {
+		mockService := new(MockUserService)
+		controller := controllers.NewUserController(mockService, controllers.WithMaxSearchOffset(100))
+		router := setupTestRouter()
+		router.GET("/users", controller.GetUsers)
+
+		req, _ := http.NewRequest(http.MethodGet, "/users?search=smith&page=11&page_size=10", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, models.CodeSearchTooDeep, response["code"])
+		assert.Equal(t, float64(100), response["max_offset"])
+		assert.Contains(t, response["error"], "narrow the search")
+		mockService.AssertNotCalled(t, "SearchUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("allows the last page within the cap", func(t *testing.T) {
+		mockService := new(MockUserService)
+		controller := controllers.NewUserController(mockService, controllers.WithMaxSearchOffset(100))
+		router := setupTestRouter()
+		router.GET("/users", controller.GetUsers)
+
+		filter := models.UserFilter{Search: "smith"}
+		mockService.On("UsersVersion").Return(models.CollectionVersion{}, nil)
+		mockService.On("SearchUsers", filter, models.UserSort{}, 10, 10).Return([]models.UserResponse{}, int64(0), nil)
+		mockService.On("CountUsers").Return(int64(0), nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/users?search=smith&page=10&page_size=10", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("does not limit lists without a search term", func(t *testing.T) {
+		mockService := new(MockUserService)
+		controller := controllers.NewUserController(mockService, controllers.WithMaxSearchOffset(100))
+		router := setupTestRouter()
+		router.GET("/users", controller.GetUsers)
+
+		mockService.On("UsersVersion").Return(models.CollectionVersion{}, nil)
+		mockService.On("GetAllUsers", 50, 10).Return([]models.UserResponse{}, int64(0), nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/users?page=50&page_size=10", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestUserController_CreateUser_StrictJSON(t *testing.T) {
+	body := `{"name": "John Doe", "emial": "john@example.com", "age": 30}`
+
+	t.Run("rejects unknown fields in strict mode", func(t *testing.T) {
+		mockService := new(MockUserService)
+		controller := controllers.NewUserController(mockService, controllers.WithStrictJSON(true))
+		router := setupTestRouter()
+		router.POST("/users", controller.CreateUser)
+
+		req, _ := http.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "Invalid request body", response["error"])
+		assert.Contains(t, response["details"], `"emial"`)
+		mockService.AssertNotCalled(t, "CreateUser", mock.Anything)
+	})
+
+	t.Run("accepts known fields in strict mode", func(t *testing.T) {
+		mockService := new(MockUserService)
+		controller := controllers.NewUserController(mockService, controllers.WithStrictJSON(true))
+		router := setupTestRouter()
+		router.POST("/users", controller.CreateUser)
+
+		userReq := models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30}
+		mockService.On("CreateUser", userReq).Return(&models.UserResponse{ID: 1, Name: "John Doe", Email: "john@example.com", Age: 30}, nil)
+
+		jsonBody, _ := json.Marshal(userReq)
+		req, _ := http.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+}
+
+func TestUserController_BulkCreateAndImport_MinRegistrationAge(t *testing.T) {
+	db := setupTestDB(t)
+	userService := service.NewUserService(repository.NewUserRepository(db), nil, service.WithMinRegistrationAge(13))
+	controller := controllers.NewUserController(userService)
+	router := setupTestRouter()
+	router.POST("/users/bulk", controller.BulkCreateUsers)
+	router.POST("/users/import", controller.ImportUsers)
+	send := func(path, contentType, body string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	t.Run("bulk create", func(t *testing.T) {
+		status, response := send("/users/bulk", "application/json",
+			`{"users": [{"name": "Adult", "email": "adult@example.com", "age": 30}, {"name": "Young User", "email": "young@example.com", "age": 12}]}`)
+
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, models.CodeValidationFailed, response["code"])
+		assert.Equal(t, "age", response["field"])
+		assert.Equal(t, float64(13), response["min_age"])
+		assert.Equal(t, "users[1]: Users must be at least 13 years old to register", response["error"])
+	})
+
+	t.Run("import", func(t *testing.T) {
+		status, response := send("/users/import", "text/csv", "name,email,age\nAdult,adult@example.com,30\nYoung User,young@example.com,12\n")
+
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, models.CodeValidationFailed, response["code"])
+		assert.Equal(t, float64(13), response["min_age"])
+	})
+
+	var count int64
+	require.NoError(t, db.Model(&models.User{}).Count(&count).Error)
+	assert.Zero(t, count, "nothing is created when any user is too young")
+}
+
+func TestUserController_CreateUser_MinRegistrationAge(t *testing.T) {
+	postUser := func(controller *controllers.UserController, userReq models.UserRequest) *httptest.ResponseRecorder {
+		router := setupTestRouter()
+		router.POST("/users", controller.CreateUser)
+
+		jsonBody, _ := json.Marshal(userReq)
+		req, _ := http.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("rejects users below the minimum age", func(t *testing.T) {
+		db := setupTestDB(t)
+		userService := service.NewUserService(repository.NewUserRepository(db), nil, service.WithMinRegistrationAge(13))
+		controller := controllers.NewUserController(userService)
+
+		w := postUser(controller, models.UserRequest{Name: "Young User", Email: "young@example.com", Age: 12})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, models.CodeValidationFailed, response["code"])
+		assert.Equal(t, "age", response["field"])
+		assert.Equal(t, float64(13), response["min_age"])
+		assert.Equal(t, "Users must be at least 13 years old to register", response["error"])
+		var count int64
+		require.NoError(t, db.Model(&models.User{}).Count(&count).Error)
+		assert.Zero(t, count)
+	})
+
+	t.Run("accepts users at the minimum age", func(t *testing.T) {
+		mockService := new(MockUserService)
+		controller := controllers.NewUserController(mockService, controllers.WithMinRegistrationAge(13))
+
+		userReq := models.UserRequest{Name: "Teen User", Email: "teen@example.com", Age: 13}
+		mockService.On("CreateUser", userReq).Return(&models.UserResponse{ID: 1, Age: 13}, nil)
+
+		w := postUser(controller, userReq)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("without a minimum the model range allows age 0", func(t *testing.T) {
+		mockService := new(MockUserService)
+		controller := controllers.NewUserController(mockService)
+
+		userReq := models.UserRequest{Name: "Newborn", Email: "newborn@example.com", Age: 0}
+		mockService.On("CreateUser", userReq).Return(&models.UserResponse{ID: 1}, nil)
+
+		w := postUser(controller, userReq)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("updates are not subject to the minimum", func(t *testing.T) {
+		mockService := new(MockUserService)
+		controller := controllers.NewUserController(mockService, controllers.WithMinRegistrationAge(18))
+		router := setupTestRouter()
+		router.PUT("/users/:id", controller.UpdateUser)
+
+		userReq := models.UserRequest{Name: "Existing User", Email: "existing@example.com", Age: 0}
+		mockService.On("UpdateUser", uint(1), userReq).Return(&models.UserResponse{ID: 1}, []string{"age"}, nil)
+
+		jsonBody, _ := json.Marshal(userReq)
+		req, _ := http.NewRequest(http.MethodPut, "/users/1", bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestUserController_GetUsers_Meta(t *testing.T) {
+	t.Run("filtered and total counts differ with an active filter", func(t *testing.T) {
+		mockService := new(MockUserService)
+		controller := controllers.NewUserController(mockService)
+		router := setupTestRouter()
+		router.GET("/users", controller.GetUsers)
+
+		actor := uint(5)
+		users := make([]models.UserResponse, 2)
+		mockService.On("UsersVersion").Return(models.CollectionVersion{}, nil)
+		mockService.On("SearchUsers", models.UserFilter{UpdatedBy: &actor}, models.UserSort{}, 1, 2).Return(users, int64(3), nil)
+		mockService.On("CountUsers").Return(int64(12), nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/users?updated_by=5&page_size=2", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Meta map[string]float64 `json:"meta"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(3), response.Meta["filtered_count"])
+		assert.Equal(t, float64(12), response.Meta["total_count"])
+		assert.Equal(t, float64(2), response.Meta["total_pages"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("counts match without a filter", func(t *testing.T) {
+		mockService := new(MockUserService)
+		controller := controllers.NewUserController(mockService)
+		router := setupTestRouter()
+		router.GET("/users", controller.GetUsers)
+
+		mockService.On("UsersVersion").Return(models.CollectionVersion{}, nil)
+		mockService.On("GetAllUsers", 1, 10).Return([]models.UserResponse{}, int64(12), nil)
+
+		req, _ := http.NewRequest(http.MethodGet, "/users", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Meta map[string]float64 `json:"meta"`
+		}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, float64(12), response.Meta["filtered_count"])
+		assert.Equal(t, float64(12), response.Meta["total_count"])
+		mockService.AssertNotCalled(t, "CountUsers")
+	})
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/middleware"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportUserData(t *testing.T, router *gin.Engine, id uint, authorization string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/users/%d/export", id), nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w, response
+}
+
+func TestUserController_ExportUserData(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+	controller := controllers.NewUserController(userService)
+
+	router := setupTestRouter()
+	router.GET("/users/:id/export", middleware.RequireAuth(testJWTSecret), controller.ExportUserData)
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30, Address: "1 Main St"})
+	require.NoError(t, err)
+	_, _, err = userService.UpdateUser(user.ID, models.UserRequest{Name: "John Doe", Email: "jd@example.com", Age: 30, Address: "1 Main St"})
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.AuditEntry{Action: models.AuditUserDeleted, UserID: user.ID}).Error)
+	other, err := userService.CreateUser(models.UserRequest{Name: "Jane Doe", Email: "jane@example.com", Age: 30})
+	require.NoError(t, err)
+
+	self := "Bearer " + signTestToken(t, fmt.Sprint(user.ID), "user", time.Hour)
+
+	t.Run("own data includes related records", func(t *testing.T) {
+		w, response := exportUserData(t, router, user.ID, self)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "private, no-store", w.Header().Get("Cache-Control"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+
+		data := response["data"].(map[string]interface{})
+		exported := data["user"].(map[string]interface{})
+		assert.Equal(t, "jd@example.com", exported["email"])
+		assert.Equal(t, "1 Main St", exported["address"])
+
+		history := data["email_history"].([]interface{})
+		require.Len(t, history, 1)
+		assert.Equal(t, "john@example.com", history[0].(map[string]interface{})["email"])
+
+		audit := data["audit_log"].([]interface{})
+		require.Len(t, audit, 1)
+		assert.Equal(t, string(models.AuditUserDeleted), audit[0].(map[string]interface{})["action"])
+		assert.NotEmpty(t, data["exported_at"])
+	})
+
+	t.Run("admin may export anyone", func(t *testing.T) {
+		w, _ := exportUserData(t, router, other.ID, "Bearer "+signTestToken(t, "1000", "admin", time.Hour))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("other users are denied", func(t *testing.T) {
+		w, response := exportUserData(t, router, other.ID, self)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, models.CodeForbidden, response["code"])
+		assert.NotContains(t, w.Body.String(), "jane@example.com")
+	})
+
+	t.Run("anonymous callers are unauthorized", func(t *testing.T) {
+		w, response := exportUserData(t, router, user.ID, "")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, models.CodeUnauthorized, response["code"])
+	})
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserController_GetUsers_Filters(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	controller := controllers.NewUserController(service.NewUserService(repo, nil))
+	router := setupTestRouter()
+	router.GET("/users", controller.GetUsers)
+
+	inactive := false
+	for _, user := range []*models.User{
+		{Name: "John Smith", Email: "john@example.com", Age: 17, Status: models.StatusActive, IsActive: true},
+		{Name: "Joanna Jones", Email: "joanna@example.com", Age: 25, Status: models.StatusActive, IsActive: true},
+		{Name: "Bob Miller", Email: "bob@example.com", Age: 40, Status: models.StatusActive, IsActive: true},
+		{Name: "Marjorie Stone", Email: "marjorie@example.com", Age: 65, Status: models.StatusActive, IsActive: true},
+		{Name: "Mo_Jo", Email: "mojo@example.com", Age: 30, Status: models.StatusActive, IsActive: true},
+	} {
+		require.NoError(t, repo.Create(user))
+	}
+	_, err := service.NewUserService(repo, nil).BulkSetActive(models.UserFilter{Name: "bob"}, inactive)
+	require.NoError(t, err)
+
+	names := func(response map[string]interface{}) []string {
+		var got []string
+		for _, item := range response["data"].([]interface{}) {
+			got = append(got, item.(map[string]interface{})["name"].(string))
+		}
+		return got
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{name: "name substring ignoring case", query: "name=JO", expected: []string{"John Smith", "Joanna Jones", "Marjorie Stone", "Mo_Jo"}},
+		{name: "name wildcards match literally", query: "name=o_j", expected: []string{"Mo_Jo"}},
+		{name: "age range", query: "min_age=18&max_age=40", expected: []string{"Joanna Jones", "Bob Miller", "Mo_Jo"}},
+		{name: "name and age", query: "name=jo&min_age=18", expected: []string{"Joanna Jones", "Marjorie Stone", "Mo_Jo"}},
+		{name: "exact email ignoring case", query: "email=JOANNA@example.com", expected: []string{"Joanna Jones"}},
+		{name: "email does not match partially", query: "email=joanna", expected: nil},
+		{name: "active status", query: "is_active=false", expected: []string{"Bob Miller"}},
+		{name: "combined", query: "name=jo&is_active=true&min_age=18", expected: []string{"Joanna Jones", "Marjorie Stone", "Mo_Jo"}},
+		{name: "no filters return everyone", query: "", expected: []string{"John Smith", "Joanna Jones", "Bob Miller", "Marjorie Stone", "Mo_Jo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := getJSON(t, router, "/users?"+tt.query)
+
+			require.Equal(t, http.StatusOK, status)
+			assert.Equal(t, tt.expected, names(response))
+			meta := response["meta"].(map[string]interface{})
+			assert.Equal(t, float64(len(tt.expected)), meta["filtered_count"])
+			assert.Equal(t, float64(5), meta["total_count"])
+		})
+	}
+}
@@ -1,10 +1,16 @@
 package tests
 
 import (
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/IntouchOpec/user_management/models"
 	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/reqctx"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 )
 
@@ -48,3 +54,237 @@ func TestUserRepository_MethodsExist(t *testing.T) {
 	// The mere fact that this compiles means all interface methods are implemented
 	// with correct signatures
 }
+
+// seedUser inserts a user and then forces the given activity state and
+// update time, which GORM would otherwise default or auto-stamp
+func seedUser(t *testing.T, db *gorm.DB, email string, active bool, updatedAt time.Time) *models.User {
+	t.Helper()
+
+	status := models.StatusActive
+	if !active {
+		status = models.StatusSuspended
+	}
+
+	user := &models.User{Name: "Seed User", Email: email, Age: 30}
+	require.NoError(t, db.Create(user).Error)
+	require.NoError(t, db.Model(user).UpdateColumns(map[string]interface{}{
+		"status":     status,
+		"is_active":  active,
+		"updated_at": updatedAt,
+	}).Error)
+
+	user.Status = status
+	user.IsActive = active
+	user.UpdatedAt = updatedAt
+	return user
+}
+
+func TestUserRepository_DeleteWhere(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+
+	cutoff := time.Now().Add(-30 * 24 * time.Hour)
+	old := cutoff.Add(-24 * time.Hour)
+	recent := time.Now()
+
+	var stale []uint
+	for i := 0; i < 3; i++ {
+		stale = append(stale, seedUser(t, db, fmt.Sprintf("stale%d@example.com", i), false, old).ID)
+	}
+	recentInactive := seedUser(t, db, "recent@example.com", false, recent)
+	oldActive := seedUser(t, db, "active@example.com", true, old)
+
+	// A batch size smaller than the match count exercises multiple batches
+	deleted, err := repo.DeleteWhere(2, "is_active = ? AND updated_at < ?", false, cutoff)
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, stale, deleted)
+
+	for _, id := range stale {
+		_, err := repo.GetByID(id)
+		assert.Error(t, err)
+	}
+
+	// Active and recently updated users are untouched
+	_, err = repo.GetByID(recentInactive.ID)
+	assert.NoError(t, err)
+	_, err = repo.GetByID(oldActive.ID)
+	assert.NoError(t, err)
+
+	count, err := repo.Count()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestUserRepository_Search_UpdatedBy(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+
+	admin := uint(100)
+	other := uint(200)
+	for i, actor := range []*uint{&admin, &other, &admin, nil} {
+		user := &models.User{Name: "User", Email: fmt.Sprintf("user%d@example.com", i), Age: 30, UpdatedBy: actor}
+		require.NoError(t, repo.Create(user))
+	}
+
+	users, total, err := repo.Search(models.UserFilter{UpdatedBy: &admin}, models.UserSort{}, 0, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, users, 2)
+	for _, user := range users {
+		assert.Equal(t, admin, *user.UpdatedBy)
+	}
+}
+
+func TestUserRepository_TenantIsolation(t *testing.T) {
+	db := setupTestDB(t)
+	base := repository.NewUserRepository(db)
+	tenantA := base.WithContext(reqctx.WithTenantID(context.Background(), "tenant-a"))
+	tenantB := base.WithContext(reqctx.WithTenantID(context.Background(), "tenant-b"))
+
+	alice := &models.User{Name: "Alice", Email: "alice@example.com", Age: 30}
+	require.NoError(t, tenantA.Create(alice))
+	bob := &models.User{Name: "Bob", Email: "bob@example.com", Age: 30, TenantID: "tenant-a"}
+	require.NoError(t, tenantB.CreateBatch([]*models.User{bob}))
+
+	assert.Equal(t, "tenant-a", alice.TenantID)
+	assert.Equal(t, "tenant-b", bob.TenantID, "creates must take the tenant from context")
+
+	users, err := tenantA.GetAll(0, 10)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, alice.ID, users[0].ID)
+
+	count, err := tenantA.Count()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	_, err = tenantA.GetByID(bob.ID)
+	assert.EqualError(t, err, "user not found")
+	_, err = tenantA.GetByEmail(bob.Email)
+	assert.EqualError(t, err, "user not found")
+
+	assert.EqualError(t, tenantA.Delete(bob.ID), "user not found")
+	deleted, err := tenantA.DeleteByIDs([]uint{bob.ID}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+
+	found, err := tenantB.GetByID(bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", found.Name)
+
+	all, err := base.Count()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), all, "requests without a tenant are unscoped")
+}
+
+func TestUserRepository_SetActiveByFilter(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+
+	admin := uint(100)
+	var matching []uint
+	for i := 0; i < 2; i++ {
+		user := &models.User{Name: "User", Email: fmt.Sprintf("admin%d@example.com", i), Age: 30, IsActive: true, UpdatedBy: &admin}
+		require.NoError(t, repo.Create(user))
+		matching = append(matching, user.ID)
+	}
+	other := &models.User{Name: "Other", Email: "other@example.com", Age: 30, IsActive: true}
+	require.NoError(t, repo.Create(other))
+
+	actor := uint(7)
+	ids, err := repo.SetActiveByFilter(models.UserFilter{UpdatedBy: &admin}, false, &actor)
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, matching, ids)
+
+	for _, id := range matching {
+		user, err := repo.GetByID(id)
+		require.NoError(t, err)
+		assert.False(t, user.IsActive)
+		assert.Equal(t, actor, *user.UpdatedBy)
+	}
+
+	untouched, err := repo.GetByID(other.ID)
+	require.NoError(t, err)
+	assert.True(t, untouched.IsActive)
+}
+
+func TestUserRepository_PurgeSoftDeletedBefore(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+
+	cutoff := time.Now().Add(-30 * 24 * time.Hour)
+	oldTombstone := seedUser(t, db, "old@example.com", true, time.Now())
+	recentTombstone := seedUser(t, db, "recent@example.com", true, time.Now())
+	live := seedUser(t, db, "live@example.com", true, time.Now())
+
+	require.NoError(t, db.Create(&models.UserEmailHistory{UserID: oldTombstone.ID, Email: "older@example.com"}).Error)
+	require.NoError(t, db.Create(&models.UserEmailHistory{UserID: live.ID, Email: "previous@example.com"}).Error)
+	require.NoError(t, repo.Delete(oldTombstone.ID))
+	require.NoError(t, repo.Delete(recentTombstone.ID))
+	require.NoError(t, db.Unscoped().Model(&models.User{}).Where("id = ?", oldTombstone.ID).
+		UpdateColumn("deleted_at", cutoff.Add(-time.Hour)).Error)
+
+	purged, err := repo.PurgeSoftDeletedBefore(cutoff)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	var remaining []uint
+	require.NoError(t, db.Unscoped().Model(&models.User{}).Order("id").Pluck("id", &remaining).Error)
+	assert.Equal(t, []uint{recentTombstone.ID, live.ID}, remaining, "recent tombstones and live users are kept")
+
+	var historyOwners []uint
+	require.NoError(t, db.Model(&models.UserEmailHistory{}).Pluck("user_id", &historyOwners).Error)
+	assert.Equal(t, []uint{live.ID}, historyOwners, "purged users' email history is removed")
+}
+
+func TestUserRepository_GetRandom(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+
+	_, err := repo.GetRandom()
+	assert.ErrorIs(t, err, models.ErrUserNotFound, "an empty table has no random user")
+
+	ids := map[uint]bool{}
+	for i := 0; i < 3; i++ {
+		user := seedUser(t, db, fmt.Sprintf("random%d@example.com", i), true, time.Now())
+		ids[user.ID] = true
+	}
+
+	for i := 0; i < 10; i++ {
+		user, err := repo.GetRandom()
+		require.NoError(t, err)
+		assert.True(t, ids[user.ID], "picked user %d", user.ID)
+	}
+}
+
+func TestUserRepository_GetRecent(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+
+	base := time.Now().Add(-time.Hour)
+	for i, email := range []string{"oldest@example.com", "middle@example.com", "newest@example.com", "older@example.com"} {
+		created := base.Add(time.Duration(i) * time.Minute)
+		if email == "older@example.com" {
+			created = base.Add(-time.Minute)
+		}
+		require.NoError(t, db.Create(&models.User{Name: "Seed User", Email: email, Age: 30, CreatedAt: created}).Error)
+	}
+
+	users, err := repo.GetRecent(2)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "newest@example.com", users[0].Email)
+	assert.Equal(t, "middle@example.com", users[1].Email)
+
+	users, err = repo.GetRecent(10)
+	require.NoError(t, err)
+	var emails []string
+	for _, user := range users {
+		emails = append(emails, user.Email)
+	}
+	assert.Equal(t, []string{"newest@example.com", "middle@example.com", "oldest@example.com", "older@example.com"}, emails)
+}
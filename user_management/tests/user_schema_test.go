@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getUserSchema(t *testing.T, controller *controllers.UserController) models.JSONSchema {
+	t.Helper()
+
+	router := setupTestRouter()
+	router.GET("/users/schema", controller.GetUserSchema)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/schema", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var schema models.JSONSchema
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &schema))
+	return schema
+}
+
+func TestUserController_GetUserSchema(t *testing.T) {
+	schema := getUserSchema(t, controllers.NewUserController(new(MockUserService), controllers.WithAgeRequired(true)))
+
+	assert.Equal(t, "object", schema.Type)
+	assert.ElementsMatch(t, []string{"name", "email", "age"}, schema.Required)
+	assert.Nil(t, schema.AdditionalProperties)
+
+	name := schema.Properties["name"]
+	require.NotNil(t, name)
+	assert.Equal(t, "string", name.Type)
+	assert.Equal(t, 2, *name.MinLength)
+	assert.Equal(t, 100, *name.MaxLength)
+
+	email := schema.Properties["email"]
+	require.NotNil(t, email)
+	assert.Equal(t, "email", email.Format)
+
+	age := schema.Properties["age"]
+	require.NotNil(t, age)
+	assert.Equal(t, "integer", age.Type)
+	assert.Equal(t, float64(0), *age.Minimum)
+	assert.Equal(t, float64(150), *age.Maximum)
+
+	phone := schema.Properties["phone"]
+	require.NotNil(t, phone)
+	assert.Equal(t, 10, *phone.MinLength)
+	assert.Equal(t, 20, *phone.MaxLength)
+
+	assert.Equal(t, "boolean", schema.Properties["is_active"].Type)
+	assert.Equal(t, "date-time", schema.Properties["birth_date"].Format)
+	assert.NotContains(t, schema.Properties, "CreatedAt", "fields hidden from JSON are left out")
+}
+
+func TestUserController_GetUserSchema_Options(t *testing.T) {
+	schema := getUserSchema(t, controllers.NewUserController(new(MockUserService), controllers.WithStrictJSON(true)))
+
+	assert.ElementsMatch(t, []string{"name", "email"}, schema.Required)
+	require.NotNil(t, schema.AdditionalProperties)
+	assert.False(t, *schema.AdditionalProperties)
+}
@@ -1,13 +1,18 @@
 package tests
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/reqctx"
 	"github.com/IntouchOpec/user_management/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 // MockUserRepository is a mock implementation of UserRepository
@@ -15,6 +20,14 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
+func (m *MockUserRepository) WithContext(ctx context.Context) repository.UserRepository {
+	return m
+}
+
+func (m *MockUserRepository) WithTx(tx *gorm.DB) repository.UserRepository {
+	return m
+}
+
 func (m *MockUserRepository) Create(user *models.User) error {
 	args := m.Called(user)
 	return args.Error(0)
@@ -36,11 +49,167 @@ func (m *MockUserRepository) GetByEmail(email string) (*models.User, error) {
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetByIDs(ids []uint) ([]models.User, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmails(emails []string) ([]models.User, error) {
+	args := m.Called(emails)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) PurgeSoftDeletedBefore(t time.Time) (int64, error) {
+	args := m.Called(t)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) GetPage(page, size int, opts models.PageOptions) ([]models.User, bool, error) {
+	args := m.Called(page, size, opts)
+	return args.Get(0).([]models.User), args.Bool(1), args.Error(2)
+}
+
+func (m *MockUserRepository) FindInBatches(filter models.UserFilter, batchSize int, fn func([]models.User) error) error {
+	args := m.Called(filter, batchSize, fn)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetEmailHistory(userID uint) ([]models.UserEmailHistory, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.UserEmailHistory), args.Error(1)
+}
+
+func (m *MockUserRepository) CountAuditEntries(userID uint) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) GetAuditEntries(userID uint) ([]models.AuditEntry, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AuditEntry), args.Error(1)
+}
+
+func (m *MockUserRepository) DeleteScheduledBefore(t time.Time) ([]models.User, error) {
+	args := m.Called(t)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) CountCreatedByDay(from, to time.Time) (map[string]int64, error) {
+	args := m.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (m *MockUserRepository) CreateAuditEntries(entries []models.AuditEntry) error {
+	args := m.Called(entries)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) GetAll(offset, limit int) ([]models.User, error) {
 	args := m.Called(offset, limit)
 	return args.Get(0).([]models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) SetRoleByFilter(filter models.UserFilter, role string, updatedBy *uint, maxAffected int) ([]uint, error) {
+	args := m.Called(filter, role, updatedBy, maxAffected)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockUserRepository) GetAllCursor(afterID uint, limit int) ([]models.User, error) {
+	args := m.Called(afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByIDIncludingDeleted(id uint) (*models.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Restore(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetActiveByFilter(filter models.UserFilter, active bool, updatedBy *uint) ([]uint, error) {
+	args := m.Called(filter, active, updatedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockUserRepository) FindSimilar(user *models.User, limit int) ([]models.User, error) {
+	args := m.Called(user, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetRandom() (*models.User, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetRecent(limit int) ([]models.User, error) {
+	args := m.Called(limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) CountFiltered(filter models.UserFilter) (int64, error) {
+	args := m.Called(filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) FindNeighbors(id uint) (*models.User, *models.User, error) {
+	args := m.Called(id)
+	prev, _ := args.Get(0).(*models.User)
+	next, _ := args.Get(1).(*models.User)
+	return prev, next, args.Error(2)
+}
+
+func (m *MockUserRepository) CollectionVersion() (models.CollectionVersion, error) {
+	args := m.Called()
+	return args.Get(0).(models.CollectionVersion), args.Error(1)
+}
+
+func (m *MockUserRepository) Search(filter models.UserFilter, sort models.UserSort, offset, limit int) ([]models.User, int64, error) {
+	args := m.Called(filter, sort, offset, limit)
+	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
+}
+
 func (m *MockUserRepository) Update(user *models.User) error {
 	args := m.Called(user)
 	return args.Error(0)
@@ -51,11 +220,42 @@ func (m *MockUserRepository) Delete(id uint) error {
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) DeleteWithDependents(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) Count() (int64, error) {
 	args := m.Called()
 	return args.Get(0).(int64), args.Error(1)
 }
 
+func (m *MockUserRepository) CreateBatch(users []*models.User) error {
+	args := m.Called(users)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateBatch(users []*models.User) error {
+	args := m.Called(users)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) DeleteByIDs(ids []uint, deletedBy *uint) ([]uint, error) {
+	args := m.Called(ids, deletedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockUserRepository) DeleteWhere(batchSize int, query interface{}, conds ...interface{}) ([]uint, error) {
+	args := m.Called(batchSize, query, conds)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
 func TestUserService_CreateUser(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -299,7 +499,7 @@ func TestUserService_GetAllUsers(t *testing.T) {
 			page:              1,
 			pageSize:          10,
 			mockUsers:         []models.User{},
-			mockCount:         0,
+			mockCount:         1,
 			mockError:         errors.New("database connection failed"),
 			countError:        nil,
 			expectGetAllError: true,
@@ -335,10 +535,10 @@ func TestUserService_GetAllUsers(t *testing.T) {
 
 			offset := (expectedPage - 1) * expectedPageSize
 
-			// Mock setup
-			mockRepo.On("GetAll", offset, expectedPageSize).Return(tt.mockUsers, tt.mockError)
-			if !tt.expectGetAllError {
-				mockRepo.On("Count").Return(tt.mockCount, tt.countError)
+			// Mock setup; pages past the count are answered without GetAll
+			mockRepo.On("Count").Return(tt.mockCount, tt.countError)
+			if !tt.expectCountError && int64(offset) < tt.mockCount {
+				mockRepo.On("GetAll", offset, expectedPageSize).Return(tt.mockUsers, tt.mockError)
 			}
 
 			// Execute
@@ -499,7 +699,7 @@ func TestUserService_UpdateUser(t *testing.T) {
 			}
 
 			// Execute
-			result, err := userService.UpdateUser(tt.userID, tt.request)
+			result, _, err := userService.UpdateUser(tt.userID, tt.request)
 
 			// Assertions
 			if tt.expectedError {
@@ -518,6 +718,43 @@ func TestUserService_UpdateUser(t *testing.T) {
 	}
 }
 
+func TestUserService_UpdateUser_NoChanges(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := service.NewUserService(mockRepo, nil)
+
+	active := true
+	stored := &models.User{ID: 1, Name: "John Doe", Email: "john@example.com", Age: 30, Phone: "5550001111", Status: models.StatusActive, IsActive: true}
+	mockRepo.On("GetByID", uint(1)).Return(stored, nil)
+
+	result, changed, err := userService.UpdateUser(1, models.UserRequest{
+		Name: "John Doe", Email: "john@example.com", Age: 30, Phone: "5550001111", IsActive: &active,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", result.Name)
+	assert.NotNil(t, changed)
+	assert.Empty(t, changed)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestUserService_UpdateUser_ReportsChangedFields(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := service.NewUserService(mockRepo, nil)
+
+	inactive := false
+	stored := &models.User{ID: 1, Name: "John Doe", Email: "john@example.com", Age: 30, Status: models.StatusActive, IsActive: true}
+	mockRepo.On("GetByID", uint(1)).Return(stored, nil)
+	mockRepo.On("Update", mock.AnythingOfType("*models.User")).Return(nil)
+
+	_, changed, err := userService.UpdateUser(1, models.UserRequest{
+		Name: "John Doe", Email: "john@example.com", Age: 31, IsActive: &inactive,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"age", "status", "is_active"}, changed)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestUserService_DeleteUser(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -563,3 +800,54 @@ func TestUserService_DeleteUser(t *testing.T) {
 		})
 	}
 }
+
+func TestUserService_DeleteInactiveUsers(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := service.NewUserService(mockRepo, nil)
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mockRepo.On("DeleteWhere", mock.AnythingOfType("int"), "is_active = ? AND updated_at < ?", []interface{}{false, cutoff}).
+		Return([]uint{4, 9}, nil)
+
+	deleted, err := userService.DeleteInactiveUsers(cutoff)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_StampsActorOnWrites(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	ctx := reqctx.WithActorID(context.Background(), 42)
+	userService := service.NewUserService(mockRepo, nil).WithContext(ctx)
+
+	req := models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30}
+	mockRepo.On("GetByEmail", req.Email).Return(nil, errors.New("user not found"))
+	mockRepo.On("Create", mock.MatchedBy(func(user *models.User) bool {
+		return user.UpdatedBy != nil && *user.UpdatedBy == 42
+	})).Return(nil)
+
+	result, err := userService.CreateUser(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), *result.UpdatedBy)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_SearchUsers(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := service.NewUserService(mockRepo, nil)
+
+	actor := uint(7)
+	filter := models.UserFilter{UpdatedBy: &actor}
+	mockRepo.On("Search", filter, models.UserSort{}, 0, 10).Return([]models.User{
+		{ID: 3, Name: "John Doe", Email: "john@example.com", UpdatedBy: &actor},
+	}, int64(1), nil)
+
+	result, total, err := userService.SearchUsers(filter, models.UserSort{}, 0, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, result, 1)
+	mockRepo.AssertExpectations(t)
+}
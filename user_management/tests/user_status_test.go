@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/database"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/IntouchOpec/user_management/repository"
+	"github.com/IntouchOpec/user_management/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from, to models.UserStatus
+		allowed  bool
+	}{
+		{from: models.StatusPending, to: models.StatusActive, allowed: true},
+		{from: models.StatusActive, to: models.StatusSuspended, allowed: true},
+		{from: models.StatusSuspended, to: models.StatusActive, allowed: true},
+		{from: models.StatusActive, to: models.StatusBanned, allowed: true},
+		{from: models.StatusBanned, to: models.StatusActive, allowed: true},
+		{from: models.StatusBanned, to: models.StatusBanned, allowed: true},
+		{from: models.StatusBanned, to: models.StatusPending, allowed: false},
+		{from: models.StatusActive, to: models.StatusPending, allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s to %s", tt.from, tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.allowed, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func setStatusRequest(t *testing.T, controller *controllers.UserController, id uint, status string) (int, map[string]interface{}) {
+	t.Helper()
+
+	router := setupTestRouter()
+	router.PUT("/users/:id/status", controller.SetUserStatus)
+
+	body, _ := json.Marshal(map[string]string{"status": status})
+	req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/users/%d/status", id), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+func TestUserController_SetUserStatus(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+	controller := controllers.NewUserController(userService)
+
+	user, err := userService.CreateUser(models.UserRequest{Name: "John Doe", Email: "john@example.com", Age: 30})
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusActive, user.Status)
+
+	t.Run("valid transition", func(t *testing.T) {
+		status, response := setStatusRequest(t, controller, user.ID, "banned")
+
+		assert.Equal(t, http.StatusOK, status)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "banned", data["status"])
+		assert.Equal(t, false, data["is_active"])
+
+		stored, err := repo.GetByID(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusBanned, stored.Status)
+		assert.False(t, stored.IsActive, "is_active follows the status")
+	})
+
+	t.Run("invalid transition", func(t *testing.T) {
+		status, response := setStatusRequest(t, controller, user.ID, "pending")
+
+		assert.Equal(t, http.StatusConflict, status)
+		assert.Equal(t, models.CodeInvalidTransition, response["code"])
+		assert.Equal(t, "cannot change status from banned to pending", response["error"])
+
+		stored, err := repo.GetByID(user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusBanned, stored.Status)
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		status, response := setStatusRequest(t, controller, user.ID, "deleted")
+
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, models.CodeValidationFailed, response["code"])
+	})
+
+	t.Run("missing user", func(t *testing.T) {
+		status, _ := setStatusRequest(t, controller, 999, "active")
+
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+}
+
+func TestUserService_IsActiveMapsToStatus(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+
+	inactive := false
+	user, err := userService.CreateUser(models.UserRequest{Name: "Jane Doe", Email: "jane@example.com", Age: 30, IsActive: &inactive})
+	require.NoError(t, err)
+
+	stored, err := repo.GetByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusSuspended, stored.Status)
+	assert.False(t, stored.IsActive)
+
+	active := true
+	_, _, err = userService.UpdateUser(user.ID, models.UserRequest{Name: "Jane Doe", Email: "jane@example.com", Age: 30, IsActive: &active})
+	require.NoError(t, err)
+
+	stored, err = repo.GetByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusActive, stored.Status)
+	assert.True(t, stored.IsActive)
+}
+
+func TestUserRepository_SetActiveByFilter_KeepsBannedUsers(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userService := service.NewUserService(repo, nil)
+
+	active, err := userService.CreateUser(models.UserRequest{Name: "Active", Email: "active@example.com", Age: 30})
+	require.NoError(t, err)
+	banned, err := userService.CreateUser(models.UserRequest{Name: "Banned", Email: "banned@example.com", Age: 30})
+	require.NoError(t, err)
+	_, err = userService.SetUserStatus(banned.ID, models.StatusBanned)
+	require.NoError(t, err)
+
+	_, err = repo.SetActiveByFilter(models.UserFilter{MinAge: &active.Age}, false, nil)
+	require.NoError(t, err)
+
+	stored, err := repo.GetByID(active.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusSuspended, stored.Status)
+	assert.False(t, stored.IsActive)
+
+	stored, err = repo.GetByID(banned.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusBanned, stored.Status, "deactivating does not lift a ban")
+}
+
+func TestMigrateDatabase_BackfillsStatus(t *testing.T) {
+	db := setupTestDB(t)
+
+	// Recreate the table as it was before the status column existed
+	require.NoError(t, db.Migrator().DropTable(&models.User{}))
+	require.NoError(t, db.Exec(`CREATE TABLE users (
+		id integer PRIMARY KEY AUTOINCREMENT, name text NOT NULL, email text NOT NULL, age integer NOT NULL,
+		phone text, address text, is_active numeric DEFAULT true, updated_by integer, tenant_id text,
+		created_at datetime, updated_at datetime, deleted_at datetime)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO users (name, email, age, is_active) VALUES
+		('Active', 'active@example.com', 30, true), ('Inactive', 'inactive@example.com', 30, false)`).Error)
+
+	require.NoError(t, database.MigrateDatabase())
+
+	var users []models.User
+	require.NoError(t, db.Order("id").Find(&users).Error)
+	require.Len(t, users, 2)
+	assert.Equal(t, models.StatusActive, users[0].Status)
+	assert.Equal(t, models.StatusSuspended, users[1].Status)
+
+	// Later migrations leave statuses alone
+	require.NoError(t, db.Model(&users[0]).UpdateColumn("status", models.StatusBanned).Error)
+	require.NoError(t, database.MigrateDatabase())
+	require.NoError(t, db.First(&users[0], users[0].ID).Error)
+	assert.Equal(t, models.StatusBanned, users[0].Status)
+}
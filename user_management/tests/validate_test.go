@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type validateResponse struct {
+	Data []models.ValidationResult `json:"data"`
+	Meta struct {
+		Total   int `json:"total"`
+		Valid   int `json:"valid"`
+		Invalid int `json:"invalid"`
+	} `json:"meta"`
+	Code string `json:"code"`
+}
+
+func postValidate(t *testing.T, router *gin.Engine, body string) (int, validateResponse) {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodPost, "/users/validate", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response validateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), w.Body.String())
+	return w.Code, response
+}
+
+func setupValidateRouter(mockService *MockUserService, opts ...controllers.Option) *gin.Engine {
+	controller := controllers.NewUserController(mockService, opts...)
+	router := setupTestRouter()
+	router.POST("/users/validate", controller.ValidateUsers)
+	return router
+}
+
+func TestUserController_ValidateUsers_Mixed(t *testing.T) {
+	mockService := new(MockUserService)
+	router := setupValidateRouter(mockService)
+
+	status, response := postValidate(t, router, `[
+		{"name": "John Doe", "email": "john@example.com", "age": 30},
+		{"name": "Jane Doe", "email": "not-an-email", "age": 25},
+		{"name": "J", "email": "j@example.com", "age": 200, "phone": "123"},
+		{"name": "Bob Smith", "email": "bob@example.com", "age": 40, "address": "1 Main St"}
+	]`)
+
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, response.Data, 4)
+	assert.Equal(t, 4, response.Meta.Total)
+	assert.Equal(t, 2, response.Meta.Valid)
+	assert.Equal(t, 2, response.Meta.Invalid)
+
+	assert.Equal(t, models.ValidationResult{Index: 0, Valid: true}, response.Data[0])
+	assert.Equal(t, models.ValidationResult{Index: 1, Valid: false, Fields: map[string]string{
+		"email": "must be a valid email",
+	}}, response.Data[1])
+	assert.Equal(t, models.ValidationResult{Index: 2, Valid: false, Fields: map[string]string{
+		"name":  "must be at least 2 characters",
+		"age":   "must be at most 150",
+		"phone": "must be at least 10 characters",
+	}}, response.Data[2])
+	assert.True(t, response.Data[3].Valid)
+
+	// Validation never reaches the service, let alone the database
+	mockService.AssertExpectations(t)
+	mockService.AssertNotCalled(t, "CreateUser", mock.Anything)
+}
+
+func TestUserController_ValidateUsers_SingleObject(t *testing.T) {
+	router := setupValidateRouter(new(MockUserService))
+
+	status, response := postValidate(t, router, `{"email": "john@example.com", "age": 30}`)
+
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, response.Data, 1)
+	assert.False(t, response.Data[0].Valid)
+	assert.Equal(t, map[string]string{"name": "is required"}, response.Data[0].Fields)
+}
+
+func TestUserController_ValidateUsers_RegistrationAge(t *testing.T) {
+	router := setupValidateRouter(new(MockUserService), controllers.WithMinRegistrationAge(13))
+
+	_, response := postValidate(t, router, `{"name": "Kid", "email": "kid@example.com", "age": 12}`)
+
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, map[string]string{"age": "must be at least 13 to register"}, response.Data[0].Fields)
+}
+
+func TestUserController_ValidateUsers_BadRequests(t *testing.T) {
+	router := setupValidateRouter(new(MockUserService), controllers.WithMaxBulkSize(2))
+
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{name: "malformed", body: `[{"name": "John"`, code: models.CodeInvalidRequestBody},
+		{name: "wrong type", body: `[{"name": "John", "age": "thirty"}]`, code: models.CodeInvalidRequestBody},
+		{name: "empty array", body: `[]`, code: models.CodeValidationFailed},
+		{name: "too many", body: `[{}, {}, {}]`, code: models.CodeBulkLimitExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := postValidate(t, router, tt.body)
+
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Equal(t, tt.code, response.Code)
+		})
+	}
+}
+
+func TestValidate_ErrorMessage(t *testing.T) {
+	err := models.Validate(models.UserRequest{Name: "John Doe", Email: "bad", Age: 151})
+
+	var validation *models.ValidationError
+	require.ErrorAs(t, err, &validation)
+	assert.Equal(t, "validation failed: age must be at most 150; email must be a valid email", err.Error())
+}
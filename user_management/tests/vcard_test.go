@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUserResponse_ToVCard(t *testing.T) {
+	user := models.UserResponse{
+		ID:      1,
+		Name:    "John Doe",
+		Email:   "john@example.com",
+		Phone:   "+1234567890",
+		Address: "123 Main St, Springfield",
+	}
+
+	lines := strings.Split(user.ToVCard(), "\r\n")
+
+	assert.Equal(t, "BEGIN:VCARD", lines[0])
+	assert.Equal(t, "VERSION:3.0", lines[1])
+	assert.Contains(t, lines, "FN:John Doe")
+	assert.Contains(t, lines, "N:Doe;John;;;")
+	assert.Contains(t, lines, "EMAIL;TYPE=INTERNET:john@example.com")
+	assert.Contains(t, lines, "TEL;TYPE=CELL:+1234567890")
+	assert.Contains(t, lines, `ADR;TYPE=HOME:;;123 Main St\, Springfield;;;;`)
+	assert.Contains(t, lines, "END:VCARD")
+}
+
+func TestUserResponse_ToVCard_OmitsEmptyFields(t *testing.T) {
+	vcard := models.UserResponse{Name: "Cher", Email: "cher@example.com"}.ToVCard()
+
+	assert.Contains(t, vcard, "N:;Cher;;;\r\n")
+	assert.NotContains(t, vcard, "TEL")
+	assert.NotContains(t, vcard, "ADR")
+}
+
+func TestUserController_GetUser_VCard(t *testing.T) {
+	user := &models.UserResponse{ID: 1, Name: "John Doe", Email: "john@example.com", Phone: "+1234567890"}
+
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+	}{
+		{name: "vcf extension", path: "/users/1.vcf"},
+		{name: "accept header", path: "/users/1", accept: "text/vcard"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockUserService)
+			controller := controllers.NewUserController(mockService)
+			router := setupTestRouter()
+			router.GET("/users/:id", controller.GetUser)
+
+			mockService.On("GetUserByID", uint(1)).Return(user, nil)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "text/vcard; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.Equal(t, `attachment; filename="user-1.vcf"`, w.Header().Get("Content-Disposition"))
+			assert.Contains(t, w.Body.String(), "FN:John Doe\r\n")
+			assert.Contains(t, w.Body.String(), "EMAIL;TYPE=INTERNET:john@example.com\r\n")
+			assert.Contains(t, w.Body.String(), "TEL;TYPE=CELL:+1234567890\r\n")
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserController_GetUser_VCardInvalidID(t *testing.T) {
+	mockService := new(MockUserService)
+	controller := controllers.NewUserController(mockService)
+	router := setupTestRouter()
+	router.GET("/users/:id", controller.GetUser)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/abc.vcf", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "GetUserByID", mock.Anything)
+}
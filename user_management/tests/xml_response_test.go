@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/IntouchOpec/user_management/controllers"
+	"github.com/IntouchOpec/user_management/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserController_GetUser_XML(t *testing.T) {
+	mockService := new(MockUserService)
+	controller := controllers.NewUserController(mockService)
+	router := setupTestRouter()
+	router.GET("/users/:id", controller.GetUser)
+
+	actor := uint(7)
+	mockService.On("GetUserByID", uint(1)).Return(&models.UserResponse{
+		ID:        1,
+		Name:      "John & Jane",
+		Email:     "john@example.com",
+		Age:       30,
+		IsActive:  true,
+		UpdatedBy: &actor,
+	}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("Accept", "application/xml")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/xml")
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+
+	var body struct {
+		XMLName xml.Name            `xml:"response"`
+		Data    models.UserResponse `xml:"data"`
+	}
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &body), w.Body.String())
+	assert.Equal(t, uint(1), body.Data.ID)
+	assert.Equal(t, "John & Jane", body.Data.Name)
+	assert.Equal(t, "john@example.com", body.Data.Email)
+	assert.Equal(t, 30, body.Data.Age)
+	assert.True(t, body.Data.IsActive)
+	require.NotNil(t, body.Data.UpdatedBy)
+	assert.Equal(t, actor, *body.Data.UpdatedBy)
+}
+
+func TestUserController_GetUsers_XML(t *testing.T) {
+	mockService := new(MockUserService)
+	controller := controllers.NewUserController(mockService)
+	router := setupTestRouter()
+	router.GET("/users", controller.GetUsers)
+
+	mockService.On("UsersVersion").Return(models.CollectionVersion{}, nil)
+	mockService.On("GetAllUsers", 1, 10).Return([]models.UserResponse{
+		{ID: 1, Name: "John Doe", Email: "john@example.com"},
+		{ID: 2, Name: "Jane Doe", Email: "jane@example.com"},
+	}, int64(2), nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Accept", "text/xml")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		Data []models.UserResponse `xml:"data"`
+		Meta struct {
+			TotalCount int64 `xml:"total_count"`
+		} `xml:"meta"`
+	}
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &body), w.Body.String())
+	require.Len(t, body.Data, 2)
+	assert.Equal(t, "Jane Doe", body.Data[1].Name)
+	assert.Equal(t, int64(2), body.Meta.TotalCount)
+}
+
+func TestUserController_GetUser_DefaultsToJSON(t *testing.T) {
+	for _, accept := range []string{"", "*/*", "application/json, application/xml"} {
+		t.Run(accept, func(t *testing.T) {
+			mockService := new(MockUserService)
+			controller := controllers.NewUserController(mockService)
+			router := setupTestRouter()
+			router.GET("/users/:id", controller.GetUser)
+
+			mockService.On("GetUserByID", mock.Anything).Return(&models.UserResponse{ID: 1}, nil)
+
+			req, _ := http.NewRequest(http.MethodGet, "/users/1", nil)
+			if accept != "" {
+				req.Header.Set("Accept", accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+		})
+	}
+}
+
+func TestUserController_GetUser_PrettyJSON(t *testing.T) {
+	mockService := new(MockUserService)
+	controller := controllers.NewUserController(mockService)
+	router := setupTestRouter()
+	router.GET("/users/:id", controller.GetUser)
+
+	mockService.On("GetUserByID", uint(1)).Return(&models.UserResponse{ID: 1, Name: "John Doe"}, nil)
+
+	get := func(target string) string {
+		req, _ := http.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	pretty := get("/users/1?pretty=true")
+	assert.Contains(t, pretty, "\n    \"data\": {")
+
+	for _, target := range []string{"/users/1", "/users/1?pretty=false"} {
+		assert.NotContains(t, get(target), "\n", target)
+	}
+}
+
+func TestUserController_PrettyJSON_Errors(t *testing.T) {
+	controller := controllers.NewUserController(new(MockUserService))
+	router := setupTestRouter()
+	router.GET("/users/:id", controller.GetUser)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/abc?pretty", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "\n    \"code\": \"INVALID_ID\"")
+}